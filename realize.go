@@ -1,11 +1,18 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"go/build"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/oxequa/interact"
@@ -17,11 +24,31 @@ var r realize.Realize
 
 // Realize cli commands
 func main() {
-	r.Sync = make(chan string)
+	// custom log
+	log.SetFlags(0)
+	log.SetOutput(realize.LogWriter{})
+	if build.Default.GOPATH == "" {
+		log.Fatal("$GOPATH isn't set properly")
+	}
+	// the scripts and go install inherit it, the embedding programs keep their own env
+	path := filepath.SplitList(build.Default.GOPATH)
+	if err := os.Setenv("GOBIN", filepath.Join(path[len(path)-1], "bin")); err != nil {
+		log.Fatal(err)
+	}
+	r.Sync = make(chan string, 1)
 	app := &cli.App{
 		Name:        strings.Title(realize.RPrefix),
 		Version:     realize.RVersion,
 		Description: "Realize is the #1 Golang Task Runner which enhance your workflow by automating the most common tasks and using the best performing Golang live reloading.",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{Name: "no-color", Value: false, Usage: "Plain output without colors, NO_COLOR is supported too"},
+		},
+		Before: func(c *cli.Context) error {
+			if c.Bool("no-color") {
+				realize.Plain()
+			}
+			return nil
+		},
 		Commands: []*cli.Command{
 			{
 				Name:        "start",
@@ -30,20 +57,140 @@ func main() {
 				Flags: []cli.Flag{
 					&cli.StringFlag{Name: "path", Aliases: []string{"p"}, Value: ".", Usage: "Project base path"},
 					&cli.StringFlag{Name: "name", Aliases: []string{"n"}, Value: "", Usage: "Run a project by its name"},
+					&cli.StringFlag{Name: "only", Value: "", Usage: "Run only the projects with these comma separated names"},
+					&cli.StringFlag{Name: "tag", Value: "", Usage: "Run only the projects with one of these comma separated tags"},
 					&cli.BoolFlag{Name: "fmt", Aliases: []string{"f"}, Value: false, Usage: "Enable go fmt"},
 					&cli.BoolFlag{Name: "vet", Aliases: []string{"v"}, Value: false, Usage: "Enable go vet"},
+					&cli.BoolFlag{Name: "lint", Value: false, Usage: "Enable golangci-lint, or go vet if not installed, on the changed dirs"},
 					&cli.BoolFlag{Name: "test", Aliases: []string{"t"}, Value: false, Usage: "Enable go test"},
+					&cli.BoolFlag{Name: "affected", Value: false, Usage: "Enable go test of the changed package and of its importers only"},
 					&cli.BoolFlag{Name: "generate", Aliases: []string{"g"}, Value: false, Usage: "Enable go generate"},
 					&cli.BoolFlag{Name: "server", Aliases: []string{"srv"}, Value: false, Usage: "Start server"},
 					&cli.BoolFlag{Name: "open", Aliases: []string{"op"}, Value: false, Usage: "Open into the default browser"},
 					&cli.BoolFlag{Name: "install", Aliases: []string{"i"}, Value: false, Usage: "Enable go install"},
 					&cli.BoolFlag{Name: "build", Aliases: []string{"b"}, Value: false, Usage: "Enable go build"},
 					&cli.BoolFlag{Name: "run", Aliases: []string{"nr"}, Value: false, Usage: "Enable go run"},
+					&cli.BoolFlag{Name: "race", Value: false, Usage: "Build, run and test with the race detector, the config is unchanged"},
+					&cli.StringFlag{Name: "gcflags", Value: "", Usage: "Build, run and test with these gcflags, the config is unchanged"},
+					&cli.StringFlag{Name: "watch", Value: "", Usage: "Watch these comma separated paths, no config is read or created"},
+					&cli.StringFlag{Name: "ext", Value: "", Usage: "Watch these comma separated extensions, no config is read or created"},
+					&cli.StringFlag{Name: "cmd", Value: "", Usage: "Run this command instead of go run, no config is read or created"},
 					&cli.BoolFlag{Name: "legacy", Aliases: []string{"l"}, Value: false, Usage: "Legacy watch by polling instead fsnotify"},
 					&cli.BoolFlag{Name: "no-config", Aliases: []string{"nc"}, Value: false, Usage: "Ignore existing config and doesn't create a new one"},
+					&cli.BoolFlag{Name: "exit-on-error", Value: false, Usage: "Stop at the first failure and exit with its code"},
+					&cli.BoolFlag{Name: "no-watch", Value: false, Usage: "Run the tasks once and exit without watching"},
+					&cli.BoolFlag{Name: "dry-run", Value: false, Usage: "Print the resolved tasks of each project without running them"},
+					&cli.StringFlag{Name: "control", Value: "", Usage: "Serve the control api on a unix socket path or host:port"},
+					&cli.BoolFlag{Name: "daemon", Aliases: []string{"d"}, Value: false, Usage: "Run in background, see status, stop and restart"},
+					&cli.BoolFlag{Name: "tui", Value: false, Usage: "Show a terminal ui with a pane for each project"},
+					&cli.BoolFlag{Name: "no-keys", Value: false, Usage: "Don't read the r, c, p and q keys from the terminal"},
+					&cli.StringFlag{Name: "output", Value: "text", Usage: "Output format, text or ndjson for the events on stdout and the logs on stderr"},
+					&cli.BoolFlag{Name: "supervisor", Value: false, Usage: "Run unattended with journald output, set by default under systemd"},
+					&cli.StringFlag{Name: "verbosity", Value: "", Usage: "Lowest level printed: debug, info, warn or error"},
+					&cli.BoolFlag{Name: "status-line", Value: false, Usage: "Show the state of the projects in the terminal title"},
+					&cli.DurationFlag{Name: "max-runtime", Value: 0, Usage: "Stop the projects after this time, as 8h, running their after commands"},
 				},
 				Action: start,
 			},
+			{
+				Name:        "status",
+				Category:    "Daemon",
+				Description: "Print the state of the projects of a running " + strings.Title(realize.RPrefix) + ".",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "control", Value: "", Usage: "Control api address, a unix socket path or host:port"},
+					&cli.IntFlag{Name: "tail", Value: 0, Usage: "Print the last lines of each project"},
+					&cli.BoolFlag{Name: "prompt", Value: false, Usage: "Print the states on a single line, name:state, for a shell prompt"},
+				},
+				Action: status,
+			},
+			{
+				Name:        "stop",
+				Category:    "Daemon",
+				Description: "Stop a running " + strings.Title(realize.RPrefix) + " after its exit commands.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "control", Value: "", Usage: "Control api address, a unix socket path or host:port"},
+				},
+				Action: stop,
+			},
+			{
+				Name:        "restart",
+				Category:    "Daemon",
+				Description: "Restart the tasks of the projects of a running " + strings.Title(realize.RPrefix) + ".",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "control", Value: "", Usage: "Control api address, a unix socket path or host:port"},
+					&cli.StringFlag{Name: "name", Aliases: []string{"n"}, Value: "", Usage: "Restart only the named project"},
+				},
+				Action: restart,
+			},
+			{
+				Name:        "service",
+				Category:    "Daemon",
+				Description: "Make a systemd unit supervising the projects of a path.",
+				Subcommands: []*cli.Command{
+					{
+						Name:        "install",
+						Description: "Write a unit running realize start --supervisor on the given path.",
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "path", Aliases: []string{"p"}, Value: ".", Usage: "Project base path"},
+							&cli.StringFlag{Name: "name", Aliases: []string{"n"}, Value: "", Usage: "Unit name, realize-<dir> by default"},
+							&cli.StringFlag{Name: "args", Value: "", Usage: "Additional start flags, space separated"},
+							&cli.BoolFlag{Name: "system", Value: false, Usage: "System unit run as the current user instead of a user unit, the windows services are system ones"},
+							&cli.BoolFlag{Name: "print", Value: false, Usage: "Print the unit without writing it"},
+						},
+						Action: install,
+					},
+					{
+						Name:        "uninstall",
+						Description: "Remove a unit written by service install.",
+						Flags: []cli.Flag{
+							&cli.StringFlag{Name: "path", Aliases: []string{"p"}, Value: ".", Usage: "Project base path"},
+							&cli.StringFlag{Name: "name", Aliases: []string{"n"}, Value: "", Usage: "Unit name, realize-<dir> by default"},
+							&cli.BoolFlag{Name: "system", Value: false, Usage: "System unit instead of a user unit"},
+						},
+						Action: uninstall,
+					},
+				},
+			},
+			{
+				Name:        "history",
+				Category:    "History",
+				Description: "Print the last runs stored with the history setting.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "name", Aliases: []string{"n"}, Value: "", Usage: "Print only the runs of the named project"},
+					&cli.IntFlag{Name: "limit", Value: 20, Usage: "Number of runs printed"},
+				},
+				Action: history,
+			},
+			{
+				Name:        "stats",
+				Category:    "History",
+				Description: "Print the average and p95 durations of the tasks of the stored runs.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "name", Aliases: []string{"n"}, Value: "", Usage: "Print only the stats of the named project"},
+					&cli.IntFlag{Name: "limit", Value: 100, Usage: "Number of runs of each project used, 0 for all"},
+				},
+				Action: stats,
+			},
+			{
+				Name:        "record",
+				Category:    "Debug",
+				Description: "Record the file events of the watched paths until ctrl+c, nothing is run.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "name", Aliases: []string{"n"}, Value: "", Usage: "Record only the events of the named project"},
+					&cli.StringFlag{Name: "out", Aliases: []string{"o"}, Value: realize.FileEvents, Usage: "File of the recorded events"},
+				},
+				Action: record,
+			},
+			{
+				Name:        "replay",
+				Category:    "Debug",
+				ArgsUsage:   "[file]",
+				Description: "Replay the recorded file events against the config and print the reloads and why the other events are skipped, nothing is run.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "name", Aliases: []string{"n"}, Value: "", Usage: "Replay only the events of the named project"},
+				},
+				Action: replay,
+			},
 			{
 				Name:        "add",
 				Category:    "Configuration",
@@ -53,6 +200,7 @@ func main() {
 					&cli.StringFlag{Name: "path", Aliases: []string{"p"}, Value: realize.Wdir(), Usage: "Project base path"},
 					&cli.BoolFlag{Name: "fmt", Aliases: []string{"f"}, Value: false, Usage: "Enable go fmt"},
 					&cli.BoolFlag{Name: "vet", Aliases: []string{"v"}, Value: false, Usage: "Enable go vet"},
+					&cli.BoolFlag{Name: "lint", Value: false, Usage: "Enable golangci-lint, or go vet if not installed, on the changed dirs"},
 					&cli.BoolFlag{Name: "test", Aliases: []string{"t"}, Value: false, Usage: "Enable go test"},
 					&cli.BoolFlag{Name: "generate", Aliases: []string{"g"}, Value: false, Usage: "Enable go generate"},
 					&cli.BoolFlag{Name: "install", Aliases: []string{"i"}, Value: false, Usage: "Enable go install"},
@@ -61,6 +209,17 @@ func main() {
 				},
 				Action: add,
 			},
+			{
+				Name:        "new",
+				Category:    "Configuration",
+				ArgsUsage:   "<dir>",
+				Description: "Make a new project and its config from a template: " + strings.Join(realize.Templates(), ", ") + " or a template dir.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "template", Aliases: []string{"t"}, Value: "api", Usage: "Built-in template or template dir, its files are text templates of .Name and .Module"},
+					&cli.StringFlag{Name: "module", Aliases: []string{"m"}, Value: "", Usage: "Go module path, the dir name by default"},
+				},
+				Action: scaffold,
+			},
 			{
 				Name:        "init",
 				Category:    "Configuration",
@@ -87,6 +246,17 @@ func main() {
 					return clean()
 				},
 			},
+			{
+				Name:        "upgrade",
+				Description: "Replace " + strings.Title(realize.RPrefix) + " with the latest release of a channel, after checking its checksum.",
+				Flags: []cli.Flag{
+					&cli.StringFlag{Name: "channel", Value: realize.ChannelStable, Usage: "Release channel, stable or edge with the prereleases"},
+					&cli.BoolFlag{Name: "check", Value: false, Usage: "Print the latest release without upgrading"},
+					&cli.BoolFlag{Name: "force", Value: false, Usage: "Install the latest release even if it isn't newer"},
+					&cli.BoolFlag{Name: "insecure", Value: false, Usage: "Upgrade checking only the checksums, when built without a releases key"},
+				},
+				Action: upgrade,
+			},
 			{
 				Name:        "version",
 				Aliases:     []string{"v"},
@@ -108,6 +278,31 @@ func version() {
 	log.Println(r.Prefix(realize.Green.Bold(realize.RVersion)))
 }
 
+// Upgrade to the latest release of a channel
+func upgrade(c *cli.Context) error {
+	release, err := realize.Latest(c.String("channel"))
+	if err != nil {
+		return err
+	}
+	if !release.Newer() && !c.Bool("force") {
+		log.Println(r.Prefix(realize.Green.Bold("already up to date, " + realize.RVersion)))
+		return nil
+	}
+	if c.Bool("check") {
+		log.Println(r.Prefix(realize.Green.Bold(release.Tag + " available, current " + realize.RVersion)))
+		return nil
+	}
+	bin, err := release.Upgrade(c.Bool("insecure"))
+	if err != nil {
+		return err
+	}
+	if realize.ReleasesKey == "" {
+		log.Println(r.Prefix(realize.Red.Bold("unsigned, only the checksums were checked")))
+	}
+	log.Println(r.Prefix(realize.Green.Bold("upgraded to " + release.Tag + ", " + bin)))
+	return nil
+}
+
 // Clean remove realize file
 func clean() (err error) {
 	if err := r.Settings.Remove(realize.RFile); err != nil {
@@ -117,6 +312,73 @@ func clean() (err error) {
 	return nil
 }
 
+// Scaffold a new project from a template
+func scaffold(c *cli.Context) error {
+	dir := c.Args().First()
+	if dir == "" {
+		return errors.New("missing dir, realize new <dir>")
+	}
+	files, err := realize.Scaffold(dir, c.String("template"), c.String("module"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		log.Println(r.Prefix(realize.Green.Regular("created ") + filepath.Join(dir, file)))
+	}
+	log.Println(r.Prefix(realize.Green.Bold("cd " + dir + " && realize start")))
+	return nil
+}
+
+// Install a systemd unit, or a winsw service on windows, of the projects of a path
+func install(c *cli.Context) error {
+	s, err := realize.NewService(c.String("path"), c.String("name"), strings.Fields(c.String("args")))
+	if err != nil {
+		return err
+	}
+	if c.Bool("print") {
+		fmt.Fprint(realize.Output, s.Config(c.Bool("system")))
+		return nil
+	}
+	path, err := s.Install(c.Bool("system"))
+	if err != nil {
+		return err
+	}
+	log.Println(r.Prefix(realize.Green.Regular("created ") + path))
+	if runtime.GOOS == "windows" {
+		wrapper := strings.TrimSuffix(path, filepath.Ext(path)) + ".exe"
+		log.Println(r.Prefix(realize.Green.Bold("copy the winsw executable as " + wrapper + ", then " + wrapper + " install && " + wrapper + " start")))
+		return nil
+	}
+	log.Println(r.Prefix(realize.Green.Bold(systemctl(c.Bool("system")) + " daemon-reload && " + systemctl(c.Bool("system")) + " enable --now " + s.Name)))
+	return nil
+}
+
+// Uninstall a systemd unit, disable it before, or a winsw service once uninstalled by its wrapper
+func uninstall(c *cli.Context) error {
+	s, err := realize.NewService(c.String("path"), c.String("name"), nil)
+	if err != nil {
+		return err
+	}
+	path, err := realize.Uninstall(s.Name, c.Bool("system"))
+	if err != nil {
+		return err
+	}
+	log.Println(r.Prefix(realize.Green.Regular("removed ") + path))
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	log.Println(r.Prefix(realize.Green.Bold(systemctl(c.Bool("system")) + " daemon-reload")))
+	return nil
+}
+
+// Systemctl command of the system or of the user units
+func systemctl(system bool) string {
+	if system {
+		return "sudo systemctl"
+	}
+	return "systemctl --user"
+}
+
 // Add a project to an existing config or create a new one
 func add(c *cli.Context) (err error) {
 	// read a config if exist
@@ -945,7 +1207,10 @@ func setup(c *cli.Context) (err error) {
 									if err != nil {
 										return d.Err()
 									}
-									r.Schema.Projects[len(r.Schema.Projects)-1].Watcher.Scripts[len(r.Schema.Projects[len(r.Schema.Projects)-1].Watcher.Scripts)-1].Output = val
+									r.Schema.Projects[len(r.Schema.Projects)-1].Watcher.Scripts[len(r.Schema.Projects[len(r.Schema.Projects)-1].Watcher.Scripts)-1].Output = realize.OutputErrors
+									if val {
+										r.Schema.Projects[len(r.Schema.Projects)-1].Watcher.Scripts[len(r.Schema.Projects[len(r.Schema.Projects)-1].Watcher.Scripts)-1].Output = realize.OutputAll
+									}
 									return nil
 								},
 							},
@@ -1043,7 +1308,10 @@ func setup(c *cli.Context) (err error) {
 									if err != nil {
 										return d.Err()
 									}
-									r.Schema.Projects[len(r.Schema.Projects)-1].Watcher.Scripts[len(r.Schema.Projects[len(r.Schema.Projects)-1].Watcher.Scripts)-1].Output = val
+									r.Schema.Projects[len(r.Schema.Projects)-1].Watcher.Scripts[len(r.Schema.Projects[len(r.Schema.Projects)-1].Watcher.Scripts)-1].Output = realize.OutputErrors
+									if val {
+										r.Schema.Projects[len(r.Schema.Projects)-1].Watcher.Scripts[len(r.Schema.Projects[len(r.Schema.Projects)-1].Watcher.Scripts)-1].Output = realize.OutputAll
+									}
 									return nil
 								},
 							},
@@ -1109,6 +1377,35 @@ func setup(c *cli.Context) (err error) {
 
 // Start realize workflow
 func start(c *cli.Context) (err error) {
+	// events as json lines on stdout
+	switch c.String("output") {
+	case "text":
+	case "ndjson":
+		if c.Bool("tui") {
+			return errors.New("the terminal ui can't be used with the ndjson output")
+		}
+		realize.Stderr()
+		r.EventStream(os.Stdout)
+	default:
+		return errors.New("unknown output " + c.String("output") + ", use text or ndjson")
+	}
+	// unattended, under a service manager
+	supervised := c.Bool("supervisor") || realize.Journald()
+	if supervised {
+		realize.Supervise()
+	}
+	// detach in background
+	if c.Bool("daemon") {
+		if _, err := client(c).Projects(); err == nil {
+			return errors.New("already running, see realize status")
+		}
+		pid, err := realize.Daemon(os.Args[1:], address(c))
+		if err != nil {
+			return err
+		}
+		log.Println(r.Prefix(realize.Green.Bold("started in background, pid " + strconv.Itoa(pid))))
+		return nil
+	}
 	// set legacy watcher
 	if c.Bool("legacy") {
 		r.Settings.Legacy.Set(c.Bool("legacy"), 1)
@@ -1118,14 +1415,24 @@ func start(c *cli.Context) (err error) {
 		r.Server.Set(c.Bool("server"), c.Bool("open"), realize.Port, realize.Host)
 	}
 
+	// ad-hoc project from the flags, without any config
+	config := !c.Bool("no-config") && c.String("watch") == "" && c.String("ext") == "" && c.String("cmd") == ""
 	// check no-config and read
-	if !c.Bool("no-config") {
-		// read a config if exist
-		r.Settings.Read(&r)
+	if config {
+		// read a config if exist, an invalid one isn't replaced
+		if err = r.Settings.Read(&r); err != nil && !os.IsNotExist(err) {
+			return err
+		}
 		if c.String("name") != "" {
 			// filter by name flag if exist
 			r.Schema.Projects = r.Schema.Filter("Name", c.String("name"))
 		}
+		// subset by names and tags
+		if c.String("only") != "" || c.String("tag") != "" {
+			if r.Schema.Projects, err = r.Schema.Select(realize.List(c.String("only")), realize.List(c.String("tag"))); err != nil {
+				return err
+			}
+		}
 		// increase file limit
 		if r.Settings.FileLimit != 0 {
 			if err = r.Settings.Flimit(); err != nil {
@@ -1136,18 +1443,40 @@ func start(c *cli.Context) (err error) {
 	}
 	// check project list length
 	if len(r.Schema.Projects) == 0 {
-		// create a new project based on given params
-		project := r.Schema.New(c)
+		// a project for each module of a go workspace, or one based on given params
+		projects := r.Schema.Workspace(c)
+		if len(projects) > 0 {
+			log.Println(r.Prefix(realize.Green.Bold(realize.FileWork + " found, a project for each module")))
+		} else {
+			projects = append(projects, r.Schema.New(c))
+		}
 		// Add to projects list
-		r.Schema.Add(project)
+		for _, project := range projects {
+			r.Schema.Add(project)
+		}
 		// save config
-		if !c.Bool("no-config") {
+		if config && !c.Bool("dry-run") {
 			err = r.Settings.Write(r)
 			if err != nil {
 				return err
 			}
 		}
 	}
+	// race detector and gcflags of this run only
+	if c.Bool("race") || c.String("gcflags") != "" {
+		for i := range r.Schema.Projects {
+			r.Schema.Projects[i].Tools.Override(c.Bool("race"), c.String("gcflags"))
+		}
+	}
+	// print the plan and exit
+	if c.Bool("dry-run") {
+		r.Plan(realize.Output)
+		return nil
+	}
+	// nobody to open a browser or use a terminal ui
+	if supervised {
+		r.Server.Open = false
+	}
 	// Start web server
 	if r.Server.Status {
 		r.Server.Parent = &r
@@ -1160,8 +1489,258 @@ func start(c *cli.Context) (err error) {
 			return err
 		}
 	}
+	// stop at the first failure
+	if c.Bool("exit-on-error") {
+		r.Settings.ExitOnError = true
+	}
+	// run once without watching
+	r.Once = c.Bool("no-watch")
+	if c.Bool("tui") && runtime.GOOS == "windows" {
+		return errors.New("the terminal ui isn't supported on windows")
+	}
+	// terminal ui
+	r.Tui = c.Bool("tui") && !supervised
+	// restart, clear and quit keys
+	r.Keys = !c.Bool("no-keys") && !supervised
+	// lowest level printed
+	if c.String("verbosity") != "" {
+		r.Settings.Verbosity = c.String("verbosity")
+	}
+	if _, err = realize.ParseLevel(r.Settings.Verbosity); err != nil {
+		return err
+	}
+	// control api address
+	if c.String("control") != "" {
+		r.Settings.Control = c.String("control")
+	}
+	// states in the terminal title
+	if c.Bool("status-line") {
+		r.Settings.StatusLine = true
+	}
+	// stopped after it
+	if c.Duration("max-runtime") > 0 {
+		r.Settings.MaxRuntime = c.Duration("max-runtime")
+	}
 	// start workflow
-	return r.Start()
+	if err = r.Start(); err != nil {
+		return err
+	}
+	if code := r.ExitCode(); code != 0 {
+		return cli.Exit("", code)
+	}
+	return nil
+}
+
+// Client of a running realize, the address is taken by flag, config or the daemon default
+func client(c *cli.Context) *realize.Client {
+	return realize.NewClient(address(c))
+}
+
+// Address of the control api: the flag, the settings or the default socket
+func address(c *cli.Context) string {
+	if c.String("control") != "" {
+		return c.String("control")
+	}
+	r.Settings.Read(&r)
+	if r.Settings.Control != "" {
+		return r.Settings.Control
+	}
+	return realize.Socket()
+}
+
+// Status of the projects of a running realize
+func status(c *cli.Context) error {
+	cl := client(c)
+	list, err := cl.Projects()
+	if err != nil {
+		if realize.Pid() != 0 {
+			return errors.New("not running, stale pidfile " + realize.FilePid)
+		}
+		return errors.New("not running")
+	}
+	// name:state of each project on a line
+	if c.Bool("prompt") {
+		var states []string
+		for _, p := range list {
+			states = append(states, p.Name+":"+p.State)
+		}
+		fmt.Println(strings.Join(states, " "))
+		return nil
+	}
+	for _, p := range list {
+		state := p.State
+		if !p.Since.IsZero() {
+			state += " since " + p.Since.Format("15:04:05")
+		}
+		if p.Status != "" {
+			state += ", last run " + p.Status
+		}
+		if p.Paused {
+			state += ", paused"
+		}
+		log.Println(r.Prefix(realize.Magenta.Bold(p.Name) + " " + state + " " + strconv.FormatInt(p.Files, 10) + " file/s " + strconv.FormatInt(p.Folders, 10) + " folder/s"))
+		if c.Int("tail") > 0 {
+			lines, err := cl.Logs(p.Name, c.Int("tail"))
+			if err != nil {
+				return err
+			}
+			for _, l := range lines {
+				log.Println("  " + l.Time.Format("15:04:05") + " " + l.Text)
+			}
+		}
+	}
+	return nil
+}
+
+// History of the runs, the latest last
+func history(c *cli.Context) error {
+	runs, err := realize.History(realize.FileHistory, c.String("name"), c.Int("limit"))
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		log.Println(r.Prefix("No runs, enable the history setting to store them"))
+		return nil
+	}
+	for _, run := range runs {
+		status := realize.Green.Regular(run.Status)
+		if run.Status != realize.StatusSuccess {
+			status = realize.Red.Regular(run.Status)
+		}
+		file := run.File
+		if rel, err := filepath.Rel(realize.Wdir(), file); err == nil && file != "" {
+			file = rel
+		}
+		fmt.Fprintln(realize.Output, run.Start.Format("2006-01-02 15:04:05"), realize.Magenta.Bold(run.Project), status, strconv.FormatFloat(run.Duration, 'f', 3, 64)+" s", file)
+	}
+	return nil
+}
+
+// Stats of the durations of the stored runs
+func stats(c *cli.Context) error {
+	runs, err := realize.History(realize.FileHistory, c.String("name"), 0)
+	if err != nil {
+		return err
+	}
+	// last runs of each project
+	if limit := c.Int("limit"); limit > 0 {
+		count := make(map[string]int)
+		for i := len(runs) - 1; i >= 0; i-- {
+			count[runs[i].Project]++
+			if count[runs[i].Project] > limit {
+				runs = append(runs[:i], runs[i+1:]...)
+			}
+		}
+	}
+	list := realize.Stats(runs)
+	if len(list) == 0 {
+		log.Println(r.Prefix("No runs, enable the history setting to store them"))
+		return nil
+	}
+	names := make([]string, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	seconds := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 3, 64) + " s"
+	}
+	for _, name := range names {
+		fmt.Fprintln(realize.Output, realize.Magenta.Bold(name))
+		for _, s := range list[name] {
+			fmt.Fprintln(realize.Output, " ", realize.Green.Bold(s.Name), s.Runs, "runs", "avg", seconds(s.Average), "p95", seconds(s.P95), "last", seconds(s.Last))
+		}
+	}
+	return nil
+}
+
+// Record the file events of the projects of the config
+func record(c *cli.Context) error {
+	if err := r.Settings.Read(&r); err != nil {
+		return err
+	}
+	if c.String("name") != "" {
+		r.Schema.Projects = r.Schema.Filter("Name", c.String("name"))
+	}
+	if len(r.Schema.Projects) == 0 {
+		return errors.New("no project to record")
+	}
+	stop := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		close(stop)
+	}()
+	log.Println(r.Prefix(realize.Green.Bold("Recording the file events in " + c.String("out") + ", ctrl+c to stop")))
+	if err := r.Record(c.String("out"), stop); err != nil {
+		return err
+	}
+	log.Println(r.Prefix(realize.Green.Bold("Recorded, see realize replay " + c.String("out"))))
+	return nil
+}
+
+// Replay the recorded file events against the config
+func replay(c *cli.Context) error {
+	if err := r.Settings.Read(&r); err != nil {
+		return err
+	}
+	file := realize.FileEvents
+	if c.Args().Present() {
+		file = c.Args().First()
+	}
+	events, err := realize.ReadRecorded(file)
+	if err != nil {
+		return err
+	}
+	if c.String("name") != "" {
+		var list []realize.Recorded
+		for _, e := range events {
+			if e.Project == c.String("name") {
+				list = append(list, e)
+			}
+		}
+		events = list
+	}
+	if len(events) == 0 {
+		log.Println(r.Prefix("No events recorded, see realize record"))
+		return nil
+	}
+	r.Replay(realize.Output, events)
+	return nil
+}
+
+// Stop a running realize
+func stop(c *cli.Context) error {
+	cl := client(c)
+	timeout := realize.Timeout
+	if r.Settings.Shutdown > 0 {
+		timeout = r.Settings.Shutdown
+	}
+	if err := cl.Shutdown(timeout + 5*time.Second); err != nil {
+		return err
+	}
+	log.Println(r.Prefix(realize.Green.Bold("stopped")))
+	return nil
+}
+
+// Restart the projects of a running realize
+func restart(c *cli.Context) error {
+	cl := client(c)
+	list, err := cl.Projects()
+	if err != nil {
+		return err
+	}
+	for _, p := range list {
+		if c.String("name") != "" && !strings.EqualFold(c.String("name"), p.Name) {
+			continue
+		}
+		if _, err := cl.Action(p.Name, realize.ActionRestart); err != nil {
+			return err
+		}
+		log.Println(r.Prefix(realize.Green.Bold(p.Name + " restarted")))
+	}
+	return nil
 }
 
 // Remove a project from an existing config
@@ -0,0 +1,125 @@
+package realize
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Package listed by go list with its imports
+type goPackage struct {
+	ImportPath string
+	Dir        string
+	Imports    []string
+	// TestImports are the imports of the in-package and of the external tests
+	TestImports []string
+}
+
+// Format of go list, a tab separated line for each package
+const listFormat = `{{.ImportPath}}	{{.Dir}}	{{join .Imports " "}}	{{join .TestImports " "}} {{join .XTestImports " "}}`
+
+// Affected tests the package of a changed dir and the ones importing it, on changes only
+func (t *Tool) affected(ctx context.Context, dir string) (response Response) {
+	if t.parent == nil || !t.parent.init {
+		return
+	}
+	base := t.workdir(t.parent.Path)
+	pkgs, err := listPackages(ctx, base, t.environ())
+	if err != nil {
+		return Response{Name: t.name, Err: err}
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return Response{Name: t.name, Err: err}
+	}
+	targets := importers(pkgs, dir)
+	if len(targets) == 0 || ctx.Err() != nil {
+		return
+	}
+	if t.parent.parent.Settings.Recovery.Tools {
+		log.Println("Tool:", t.name, base, targets)
+	}
+	return t.cover(ctx, base, append(append([]string{}, t.Args...), targets...))
+}
+
+// List the packages below a dir
+func listPackages(ctx context.Context, dir string, env []string) ([]goPackage, error) {
+	var out, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", "list", "-e", "-f", listFormat, "./...")
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return nil, errors.New(strings.TrimSpace(stderr.String()))
+		}
+		return nil, err
+	}
+	return parsePackages(out.Bytes()), nil
+}
+
+// Parse the lines of go list
+func parsePackages(out []byte) (pkgs []goPackage) {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != 4 {
+			continue
+		}
+		pkgs = append(pkgs, goPackage{
+			ImportPath:  fields[0],
+			Dir:         fields[1],
+			Imports:     strings.Fields(fields[2]),
+			TestImports: strings.Fields(fields[3]),
+		})
+	}
+	return
+}
+
+// Importers of the package of a dir, the importers of the importers too, and the packages
+// whose tests import one of them, the test imports don't propagate
+func importers(pkgs []goPackage, dir string) []string {
+	affected := make(map[string]bool)
+	var queue []string
+	for _, p := range pkgs {
+		if filepath.Clean(p.Dir) == filepath.Clean(dir) {
+			affected[p.ImportPath] = true
+			queue = append(queue, p.ImportPath)
+		}
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, p := range pkgs {
+			if !affected[p.ImportPath] && contains(p.Imports, current) {
+				affected[p.ImportPath] = true
+				queue = append(queue, p.ImportPath)
+			}
+		}
+	}
+	if len(affected) == 0 {
+		return nil
+	}
+	result := make([]string, 0, len(affected))
+	for _, p := range pkgs {
+		if affected[p.ImportPath] {
+			result = append(result, p.ImportPath)
+			continue
+		}
+		for _, i := range p.TestImports {
+			if affected[i] {
+				result = append(result, p.ImportPath)
+				break
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
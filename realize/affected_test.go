@@ -0,0 +1,73 @@
+package realize
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImporters(t *testing.T) {
+	pkgs := parsePackages([]byte("app/a\t/app/a\tfmt\t\n" +
+		"app/b\t/app/b\tapp/a\ttesting \n" +
+		"app/c\t/app/c\tapp/b\t\n" +
+		"app/d\t/app/d\tfmt\tapp/c app/d\n" +
+		"app/e\t/app/e\tapp/d\t\n" +
+		"app/f\t/app/f\tfmt\t\n"))
+	if len(pkgs) != 6 || len(pkgs[3].TestImports) != 2 {
+		t.Fatal("Unexpected packages", pkgs)
+	}
+	// the test imports of d don't make e affected
+	if result := strings.Join(importers(pkgs, "/app/a"), " "); result != "app/a app/b app/c app/d" {
+		t.Error("Unexpected importers", result)
+	}
+	if result := strings.Join(importers(pkgs, "/app/f/"), " "); result != "app/f" {
+		t.Error("Unexpected importers", result)
+	}
+	if result := importers(pkgs, "/other"); result != nil {
+		t.Error("Unexpected importers", result)
+	}
+}
+
+func TestTool_Affected(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found")
+	}
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.mod":              "module example.com/app\n",
+		"lib/lib.go":          "package lib\n\nfunc Name() string { return \"lib\" }\n",
+		"api/api.go":          "package api\n\nimport \"example.com/app/lib\"\n\nfunc Name() string { return lib.Name() }\n",
+		"api/api_test.go":     "package api\n\nimport \"testing\"\n\nfunc TestName(t *testing.T) { t.Log(\"api tested\") }\n",
+		"other/other.go":      "package other\n",
+		"other/other_test.go": "package other\n\nimport \"testing\"\n\nfunc TestFail(t *testing.T) { t.Fatal(\"other tested\") }\n",
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{Path: dir, parent: &r})
+	p := &r.Projects[0]
+	p.Tools.Test = Tool{Status: true, Affected: true, Output: true, Args: []string{"-v"}}
+	p.Tools.Setup()
+	p.Tools.bind(p)
+	// nothing is tested while indexing
+	if response := p.Tools.Test.Exec(context.Background(), filepath.Join(dir, "lib", "lib.go")); response.Name != "" {
+		t.Error("Unexpected test", response)
+	}
+	p.init = true
+	response := p.Tools.Test.Exec(context.Background(), filepath.Join(dir, "lib", "lib.go"))
+	if response.Err != nil || !strings.Contains(response.Out, "api tested") || strings.Contains(response.Out, "other tested") {
+		t.Error("Unexpected test", response.Err, response.Out)
+	}
+}
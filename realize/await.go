@@ -0,0 +1,95 @@
+package realize
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Await watches the nearest existing folder of a missing path, it's indexed once created
+func (p *Project) await(path string) {
+	if p.watcher == nil {
+		return
+	}
+	dir := p.abs(path)
+	for {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return
+		}
+		dir = parent
+		if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+			break
+		}
+	}
+	if _, ok := p.awaited[dir]; ok {
+		return
+	}
+	if p.awaited == nil {
+		p.awaited = make(map[string]bool)
+	}
+	// a folder already watched by the walk is kept once the path is created
+	added := !p.dirs[dir]
+	if added {
+		if err := p.watcher.Add(dir); err != nil {
+			p.Err(err)
+			return
+		}
+	}
+	p.awaited[dir] = added
+}
+
+// Pickup indexes the missing paths created by an event, it reports if the event is about an awaited folder outside the watched paths
+func (p *Project) pickup(event fsnotify.Event) bool {
+	if len(p.awaited) == 0 {
+		return false
+	}
+	for _, path := range p.set.snapshot().Missing {
+		abs := p.abs(path)
+		if !within(abs, event.Name) {
+			continue
+		}
+		if fi, err := os.Stat(abs); err != nil || !fi.IsDir() {
+			// a folder on the way, the nearest one is watched
+			p.await(path)
+			continue
+		}
+		p.set.drop(path)
+		p.indexPath(path)
+		p.exhaust()
+		msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Blue.Bold("Watching"), Magenta.Bold(path))
+		out := BufferOut{Time: time.Now(), Text: "Watching " + path}
+		p.stamp("log", out, msg, "")
+	}
+	_, parent := p.awaited[filepath.Dir(event.Name)]
+	_, self := p.awaited[event.Name]
+	p.release()
+	return (parent || self) && !p.watched(event.Name) && !p.single(event.Name) && !p.input(event.Name)
+}
+
+// Release the awaited folders without missing paths inside, the ones watched by the walk or for the single files are kept
+func (p *Project) release() {
+	missing := p.set.snapshot().Missing
+	for dir, added := range p.awaited {
+		used := false
+		for _, path := range missing {
+			used = used || within(p.abs(path), dir)
+		}
+		if used {
+			continue
+		}
+		delete(p.awaited, dir)
+		for _, file := range p.Watcher.Files {
+			used = used || filepath.Dir(p.filePath(file)) == dir
+		}
+		for _, file := range p.EnvFiles {
+			used = used || filepath.Dir(p.filePath(file)) == dir
+		}
+		if added && !used && !p.dirs[dir] && p.watcher != nil {
+			p.watcher.Remove(dir)
+		}
+	}
+}
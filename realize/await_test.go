@@ -0,0 +1,54 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestProject_Pickup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{parent: &r, Path: dir, set: &recorder{}})
+	p := &r.Projects[0]
+	p.Watcher = Watch{Exts: []string{"go"}, Paths: []string{"gen/api"}}
+	p.watcher = PollingWatcher(10 * time.Millisecond)
+	defer p.watcher.Close()
+	p.indexPath("gen/api")
+	if added, ok := p.awaited[dir]; !ok || !added {
+		t.Fatal("Unexpected awaited folders", p.awaited)
+	}
+	// the other files of the awaited folder are skipped
+	if !p.pickup(fsnotify.Event{Name: filepath.Join(dir, "main.go"), Op: fsnotify.Write}) {
+		t.Error("Unexpected event of an awaited folder")
+	}
+	// a folder on the way is watched
+	os.Mkdir(filepath.Join(dir, "gen"), 0755)
+	p.pickup(fsnotify.Event{Name: dir, Op: fsnotify.Write})
+	if _, ok := p.awaited[filepath.Join(dir, "gen")]; !ok || len(p.set.snapshot().Missing) != 1 {
+		t.Error("Unexpected awaited folders", p.awaited)
+	}
+	os.Mkdir(filepath.Join(dir, "gen", "api"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "gen", "api", "api.go"), []byte("package api"), 0644)
+	p.pickup(fsnotify.Event{Name: filepath.Join(dir, "gen"), Op: fsnotify.Write})
+	set := p.set.snapshot()
+	if len(set.Missing) != 0 || len(set.Paths) != 1 || set.Paths[0].Files != 1 || p.files != 1 {
+		t.Error("Unexpected watch set", set, p.files)
+	}
+	// the awaited folders are released
+	w := p.watcher.(*filePoller)
+	w.mu.Lock()
+	_, watched := w.watches[dir]
+	w.mu.Unlock()
+	if len(p.awaited) != 0 || watched {
+		t.Error("Unexpected awaited folders", p.awaited)
+	}
+}
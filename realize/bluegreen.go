@@ -0,0 +1,96 @@
+package realize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Restart strategies of a managed run, stop stops the previous binary before the new one starts,
+// blue-green starts the new one on the other port and the proxy switches to it once healthy
+const (
+	StrategyStop      = "stop"
+	StrategyBlueGreen = "blue-green"
+)
+
+// Key of the port of a blue-green binary in the context of its launch
+type portKey struct{}
+
+// BlueGreen reports if the managed run switches over
+func (t *Tool) blueGreen() bool {
+	return strings.ToLower(strings.TrimSpace(t.Strategy)) == StrategyBlueGreen
+}
+
+// Check the restart strategy, blue-green requires a managed run, the proxy and two ports
+func (p *Project) checkStrategy() error {
+	switch strategy := strings.ToLower(strings.TrimSpace(p.Tools.Run.Strategy)); strategy {
+	case "", StrategyStop:
+		return nil
+	case StrategyBlueGreen:
+	default:
+		return errors.New("unknown restart strategy " + p.Tools.Run.Strategy + ", use stop or blue-green")
+	}
+	ports := p.Tools.Run.Ports
+	switch {
+	case !p.Tools.Run.Managed:
+		return errors.New("blue-green requires a managed run")
+	case p.Proxy == nil:
+		return errors.New("blue-green requires the proxy")
+	case len(ports) != 2 || ports[0] <= 0 || ports[1] <= 0 || ports[0] == ports[1]:
+		return errors.New("blue-green requires two ports")
+	}
+	return nil
+}
+
+// At the port, the healthcheck of a blue-green binary, the url is checked on the port and a dial without it
+func (h *Healthcheck) at(port int) *Healthcheck {
+	c := &Healthcheck{Port: port}
+	if h == nil {
+		return c
+	}
+	c.Timeout, c.Interval = h.Timeout, h.Interval
+	if u, err := url.Parse(h.URL); h.URL != "" && err == nil {
+		u.Host = u.Hostname() + ":" + strconv.Itoa(port)
+		c.URL = u.String()
+	}
+	return c
+}
+
+// Port of a blue-green binary, zero otherwise
+func port(ctx context.Context) int {
+	port, _ := ctx.Value(portKey{}).(int)
+	return port
+}
+
+// Switchover starts the new managed binary on the other port while the previous one serves the requests,
+// the proxy switches to it once healthy and then the previous one is stopped. A new binary not healthy is stopped, the previous one goes on.
+func (p *Project) switchover(ctx context.Context, bin string) Response {
+	port := p.Tools.Run.Ports[0]
+	if prev := p.serving(); prev != nil && prev.port == port {
+		port = p.Tools.Run.Ports[1]
+	}
+	run, cancel := context.WithCancel(context.WithValue(context.Background(), portKey{}, port))
+	m := &managed{bin: bin, cancel: cancel, done: make(chan bool), port: port}
+	go func() {
+		p.launch(run, bin)
+		close(m.done)
+	}()
+	r := p.healthy(ctx, p.Tools.Run.Health.at(port))
+	if ctx.Err() != nil || r.Err != nil {
+		m.stop()
+		return r
+	}
+	addr := ":" + strconv.Itoa(port)
+	p.Proxy.retarget(addr)
+	if prev := p.handover(m); prev != nil {
+		prev.stop()
+	}
+	msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Blue.Bold("Switched"), "to", Magenta.Bold(addr))
+	out := BufferOut{Time: time.Now(), Text: "Switched to " + addr}
+	p.stamp("log", out, msg, "")
+	return r
+}
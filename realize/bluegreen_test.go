@@ -0,0 +1,133 @@
+package realize
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestProject_CheckStrategy(t *testing.T) {
+	run := Tool{Managed: true, Strategy: "Blue-Green", Ports: []int{8081, 8082}}
+	cases := []struct {
+		run   Tool
+		proxy *Proxy
+		valid bool
+	}{
+		{Tool{}, nil, true},
+		{Tool{Strategy: "stop"}, nil, true},
+		{Tool{Strategy: "rolling"}, nil, false},
+		{run, &Proxy{}, true},
+		{run, nil, false},
+		{Tool{Strategy: StrategyBlueGreen, Ports: []int{8081, 8082}}, &Proxy{}, false},
+		{Tool{Managed: true, Strategy: StrategyBlueGreen, Ports: []int{8081, 8081}}, &Proxy{}, false},
+	}
+	for _, c := range cases {
+		p := Project{Proxy: c.proxy}
+		p.Tools.Run = c.run
+		if err := p.checkStrategy(); (err == nil) != c.valid {
+			t.Error("Unexpected check", c.run.Strategy, c.run.Ports, err)
+		}
+	}
+	if !run.blueGreen() {
+		t.Error("Expected a blue-green run")
+	}
+}
+
+func TestHealthcheck_At(t *testing.T) {
+	var h *Healthcheck
+	if c := h.at(8082); c.Port != 8082 || c.URL != "" {
+		t.Error("Unexpected healthcheck", c)
+	}
+	h = &Healthcheck{URL: "http://localhost:8081/health?full=1", Timeout: time.Second}
+	if c := h.at(8082); c.URL != "http://localhost:8082/health?full=1" || c.Timeout != time.Second {
+		t.Error("Unexpected healthcheck", c)
+	}
+}
+
+func TestProject_Switchover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module bluegreen\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte(`package main
+
+import (
+	"net/http"
+	"os"
+)
+
+func main() {
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(os.Getenv("PORT")))
+	})
+	http.ListenAndServe("localhost:"+os.Getenv("PORT"), nil)
+}
+`), 0644)
+	free := func() int {
+		l, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer l.Close()
+		return l.Addr().(*net.TCPAddr).Port
+	}
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{parent: &r, Name: "bluegreen", Path: dir, Proxy: &Proxy{Timeout: 5 * time.Second}})
+	p := &r.Projects[0]
+	p.Tools.Run = Tool{Status: true, Managed: true, Strategy: StrategyBlueGreen, Ports: []int{free(), free()}}
+	p.Tools.Run.Health = &Healthcheck{Timeout: 2 * time.Second, Interval: 100 * time.Millisecond}
+	p.Tools.Setup()
+	defer p.kill()
+	proxy := httptest.NewServer(p.Proxy.handler())
+	defer proxy.Close()
+	get := func() string {
+		res, err := http.Get(proxy.URL)
+		if err != nil {
+			return err.Error()
+		}
+		defer res.Body.Close()
+		body, _ := ioutil.ReadAll(res.Body)
+		return string(body)
+	}
+	for _, port := range []int{p.Tools.Run.Ports[0], p.Tools.Run.Ports[1], p.Tools.Run.Ports[0]} {
+		bin, build := p.Tools.Run.Swap(context.Background(), dir)
+		if build.Err != nil {
+			t.Fatal("Unexpected error", build.Err)
+		}
+		if res := p.switchover(context.Background(), bin); res.Err != nil {
+			t.Fatal("Unexpected error", res.Err)
+		}
+		if body := get(); body != strconv.Itoa(port) {
+			t.Error("Unexpected upstream", body, port)
+		}
+	}
+	// the previous binary is stopped
+	if _, err := net.DialTimeout("tcp", "localhost:"+strconv.Itoa(p.Tools.Run.Ports[1]), time.Second); err == nil {
+		t.Error("Expected the previous binary stopped")
+	}
+	// a binary not healthy is stopped, the current one goes on
+	sleep := filepath.Join(dir, "sleep")
+	if content, err := ioutil.ReadFile("/bin/sleep"); err != nil {
+		t.Skip("sleep not available", err)
+	} else {
+		ioutil.WriteFile(sleep, content, 0755)
+	}
+	if res := p.switchover(context.Background(), sleep); res.Err == nil {
+		t.Error("Expected an unhealthy binary")
+	}
+	if body := get(); body != strconv.Itoa(p.Tools.Run.Ports[0]) {
+		t.Error("Unexpected upstream", body)
+	}
+	if _, err := os.Stat(sleep); err == nil {
+		t.Error("Expected the unhealthy binary removed")
+	}
+}
@@ -0,0 +1,99 @@
+package realize
+
+import (
+	"log"
+	"sync"
+)
+
+// Messages queued by the broker, the tasks wait the sinks beyond them
+const brokerSize = 256
+
+// Broker writes the messages of a project from a single goroutine, in order and without the tasks waiting the sinks
+type broker struct {
+	mu     sync.Mutex
+	ch     chan Message
+	done   chan bool
+	closed bool
+}
+
+// NewBroker starts the writer of the messages
+func newBroker(write func(Message)) *broker {
+	b := &broker{ch: make(chan Message, brokerSize), done: make(chan bool)}
+	go func() {
+		for m := range b.ch {
+			write(m)
+		}
+		close(b.done)
+	}()
+	return b
+}
+
+// Send a message, false without a running broker
+func (b *broker) send(m Message) bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return false
+	}
+	b.ch <- m
+	return true
+}
+
+// Close the broker once the queued messages are written
+func (b *broker) close() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.ch)
+	}
+	b.mu.Unlock()
+	<-b.done
+}
+
+// Message of a project, the run is the one of the task or the current one
+func (p *Project) message(t string, o BufferOut, msg string, stream string) Message {
+	if o.Run = runOf(o.ID); o.Run == "" {
+		o.Run = p.runs.current()
+	}
+	return Message{Project: p.Name, Type: t, Level: stampLevels[t], Out: o, Text: msg, Stream: stream}
+}
+
+// The messages written without a broker, the tasks of a project write them concurrently
+var writeMu sync.Mutex
+
+// Publish a message through the broker, it's written directly without it
+func (p *Project) publish(m Message) {
+	if !p.broker.send(m) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		p.write(m)
+	}
+}
+
+// Write a message in the buffer and on the sinks, a retained one only in the buffer
+func (p *Project) write(m Message) {
+	unlock := p.locked()
+	switch m.Type {
+	case "out":
+		p.Buffer.StdOut = append(p.Buffer.StdOut, m.Out)
+	case "debug", "log":
+		p.Buffer.StdLog = append(p.Buffer.StdLog, m.Out)
+	case "warn", "error":
+		p.Buffer.StdErr = append(p.Buffer.StdErr, m.Out)
+	}
+	unlock()
+	if m.retained {
+		return
+	}
+	for _, s := range p.outputs() {
+		if err := s.Write(m); err != nil {
+			log.Println(p.pname(p.Name, 2), ":", Red.Regular(err.Error()))
+		}
+	}
+}
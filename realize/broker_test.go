@@ -0,0 +1,49 @@
+package realize
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBroker(t *testing.T) {
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{Name: "test", parent: &r})
+	p := &r.Projects[0]
+	sink := &mockSink{}
+	p.AddSink(sink)
+	p.broker = newBroker(p.write)
+	// the messages of each task keep their order
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(task int) {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				p.stamp("out", BufferOut{Time: time.Now(), Text: strconv.Itoa(n), ID: strconv.Itoa(task)}, "", "")
+			}
+		}(i)
+	}
+	wg.Wait()
+	out := BufferOut{Time: time.Now(), Text: "quiet"}
+	m := p.message("error", out, "", "")
+	m.retained = true
+	p.publish(m)
+	p.broker.close()
+	if len(sink.messages) != 400 || len(p.Buffer.StdOut) != 400 || len(p.Buffer.StdErr) != 1 {
+		t.Fatal("Unexpected messages", len(sink.messages), len(p.Buffer.StdOut), len(p.Buffer.StdErr))
+	}
+	next := map[string]int{}
+	for _, m := range sink.messages {
+		if m.Out.Text != strconv.Itoa(next[m.Out.ID]) {
+			t.Fatal("Unexpected order", m.Out.ID, m.Out.Text)
+		}
+		next[m.Out.ID]++
+	}
+	// written directly once closed
+	p.stamp("log", BufferOut{Time: time.Now(), Text: "late"}, "", "")
+	if len(sink.messages) != 401 {
+		t.Error("Unexpected messages", len(sink.messages))
+	}
+}
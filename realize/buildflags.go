@@ -0,0 +1,96 @@
+package realize
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// BuildVars are the variables of the ldflags template, the git ones are empty outside a repository
+type BuildVars struct {
+	Project string
+	Commit  string
+	Branch  string
+	Tag     string
+	Time    string
+	Date    string
+}
+
+// Build flags of a go build, install or run: the flags, the tags and the rendered ldflags
+func (t *Tool) buildFlags(dir string) ([]string, error) {
+	if t.Ldflags == "" {
+		return t.flags(""), nil
+	}
+	tmpl, err := template.New("ldflags").Option("missingkey=error").Parse(t.Ldflags)
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err = tmpl.Execute(&b, t.buildVars(dir)); err != nil {
+		return nil, err
+	}
+	return t.flags(b.String()), nil
+}
+
+// Flags with the given ldflags, the plan prints them unrendered
+func (t *Tool) flags(ldflags string) []string {
+	flags := append([]string{}, t.Flags...)
+	if len(t.Tags) > 0 {
+		flags = append(flags, "-tags", strings.Join(t.Tags, ","))
+	}
+	if ldflags != "" {
+		flags = append(flags, "-ldflags", ldflags)
+	}
+	return flags
+}
+
+// Variables of the current build
+func (t *Tool) buildVars(dir string) BuildVars {
+	now := time.Now().UTC()
+	v := BuildVars{
+		Commit: git(dir, "rev-parse", "--short", "HEAD"),
+		Branch: git(dir, "rev-parse", "--abbrev-ref", "HEAD"),
+		Tag:    git(dir, "describe", "--tags", "--always", "--dirty"),
+		Time:   now.Format(time.RFC3339),
+		Date:   now.Format("2006-01-02"),
+	}
+	if t.parent != nil {
+		v.Project = t.parent.Name
+	}
+	return v
+}
+
+// Output of a git command, empty on error
+func git(dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Override adds the race detector and the gcflags to the go install, build, run and test, the config is unchanged
+func (t *Tools) Override(race bool, gcflags string) {
+	var flags []string
+	if race {
+		flags = append(flags, "-race")
+	}
+	if gcflags != "" {
+		flags = append(flags, "-gcflags="+gcflags)
+	}
+	for _, tool := range []*Tool{&t.Install, &t.Build, &t.Run, &t.Test} {
+		tool.extra = flags
+	}
+}
+
+// Go command with the overrides, a custom method is left as is
+func (t *Tool) override(cmd []string) []string {
+	if t.Method != "" {
+		return cmd
+	}
+	return append(append([]string{}, cmd...), t.extra...)
+}
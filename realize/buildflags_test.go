@@ -0,0 +1,39 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTool_BuildFlags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tool := Tool{
+		Flags:   []string{"-trimpath"},
+		Tags:    []string{"prod", "netgo"},
+		Ldflags: "-X main.name={{.Project}} -X main.date={{.Date}} -X main.commit={{.Commit}}",
+		parent:  &Project{Name: "app"},
+	}
+	flags, err := tool.buildFlags(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Now().UTC().Format("2006-01-02")
+	expected := []string{"-trimpath", "-tags", "prod,netgo", "-ldflags", "-X main.name=app -X main.date=" + date + " -X main.commit="}
+	if strings.Join(flags, " ") != strings.Join(expected, " ") {
+		t.Error("Unexpected flags", flags)
+	}
+	tool.Ldflags = "-X main.version={{.Version}}"
+	if _, err := tool.buildFlags(dir); err == nil {
+		t.Error("Expected an error, unknown variable")
+	}
+	if flags := (&Tool{}).flags(""); len(flags) != 0 {
+		t.Error("Unexpected flags", flags)
+	}
+}
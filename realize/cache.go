@@ -0,0 +1,266 @@
+package realize
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entries of a command kept in the cache, the older ones are removed
+const cacheKeep = 5
+
+// Cache of a command, skipped when its inputs hash like a previous successful run, the outputs of that run are restored.
+// Inputs and outputs are globs or folders relative to the command path.
+type Cache struct {
+	Inputs  []string `yaml:"inputs" json:"inputs"`
+	Outputs []string `yaml:"outputs,omitempty" json:"outputs,omitempty"`
+}
+
+// Entry of a cached run, its output and the stored files
+type cacheEntry struct {
+	Out   string   `json:"out"`
+	Files []string `json:"files"`
+}
+
+// Check the inputs, a cache without them would never run the command again
+func (c *Cache) check() error {
+	if len(c.Inputs) == 0 {
+		return errors.New("cache without inputs")
+	}
+	for _, list := range [][]string{c.Inputs, c.Outputs} {
+		for _, v := range list {
+			if _, err := filepath.Match(v, ""); err != nil {
+				return errors.New("invalid cache pattern " + v + ": " + err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// Files matched by the patterns, the folders are walked, sorted and relative to the dir
+func expand(dir string, patterns []string) ([]string, error) {
+	set := make(map[string]bool)
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			err := filepath.Walk(match, func(path string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() || !info.Mode().IsRegular() {
+					return err
+				}
+				rel, err := filepath.Rel(dir, path)
+				if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+					return errors.New(path + " is outside " + dir)
+				}
+				set[rel] = true
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	files := make([]string, 0, len(set))
+	for file := range set {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Cached run of a command, restored when its inputs are unchanged and stored after a success
+func (p *Project) cached(ctx context.Context, cmd Command) Response {
+	if cmd.Cache == nil {
+		return cmd.run(ctx, p.Path)
+	}
+	dir := workdir(p.Path, cmd.Path)
+	key, err := cmd.cacheKey(dir)
+	if err != nil {
+		p.Err(err)
+		return cmd.run(ctx, p.Path)
+	}
+	if r, ok := cmd.restore(dir, key); ok {
+		if r.Err == nil {
+			msg := fmt.Sprintln(p.pname(p.Name, 5), ":", Green.Bold(cmd.label()), "restored from the cache")
+			out := BufferOut{Time: time.Now(), Text: cmd.label() + " restored from the cache"}
+			p.stamp("log", out, msg, "")
+		}
+		return r
+	}
+	r := cmd.run(ctx, p.Path)
+	if r.Err == nil && ctx.Err() == nil {
+		if err := cmd.store(dir, key, r); err != nil {
+			p.Err(err)
+		}
+	}
+	return r
+}
+
+// Folder of the entries of a command
+func (c *Command) cacheDir(dir string) string {
+	sum := sha256.Sum256([]byte(dir + "\x00" + c.line()))
+	base, _ := filepath.Abs(FileCache)
+	return filepath.Join(base, hex.EncodeToString(sum[:8]))
+}
+
+// Key of the inputs, the command and the patterns are part of it
+func (c *Command) cacheKey(dir string) (string, error) {
+	files, err := expand(dir, c.Cache.Inputs)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	fmt.Fprintln(h, c.line(), dir, c.Cache.Inputs, c.Cache.Outputs)
+	for _, file := range files {
+		sum, err := digest(filepath.Join(dir, file))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintln(h, file, sum)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Restore the outputs of the entry of a key, only the changed files are written
+func (c *Command) restore(dir, key string) (Response, bool) {
+	entry := filepath.Join(c.cacheDir(dir), key)
+	content, err := ioutil.ReadFile(filepath.Join(entry, "result.json"))
+	if err != nil {
+		return Response{}, false
+	}
+	var result cacheEntry
+	if json.Unmarshal(content, &result) != nil {
+		return Response{}, false
+	}
+	for _, file := range result.Files {
+		src, dst := filepath.Join(entry, "files", file), filepath.Join(dir, file)
+		want, err := digest(src)
+		if err != nil {
+			return Response{}, false
+		}
+		// the same file isn't written again, the watcher would reload
+		if got, err := digest(dst); err == nil && got == want {
+			continue
+		}
+		if err := copyFile(src, dst); err != nil {
+			return Response{Name: c.line(), Err: err}, true
+		}
+	}
+	// used recently, kept by the pruning
+	now := time.Now()
+	os.Chtimes(entry, now, now)
+	return Response{Name: c.line(), Out: result.Out}, true
+}
+
+// Store the outputs of a successful run under its key, the older entries of the command are pruned
+func (c *Command) store(dir, key string, r Response) error {
+	files, err := expand(dir, c.Cache.Outputs)
+	if err != nil {
+		return err
+	}
+	base := c.cacheDir(dir)
+	if err := os.MkdirAll(base, 0755); err != nil {
+		return err
+	}
+	// written aside, an entry is complete or missing
+	tmp, err := ioutil.TempDir(base, "tmp")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	for _, file := range files {
+		if err := copyFile(filepath.Join(dir, file), filepath.Join(tmp, "files", file)); err != nil {
+			return err
+		}
+	}
+	content, err := json.Marshal(cacheEntry{Out: r.Out, Files: files})
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmp, "result.json"), content, 0644); err != nil {
+		return err
+	}
+	entry := filepath.Join(base, key)
+	os.RemoveAll(entry)
+	if err := os.Rename(tmp, entry); err != nil {
+		return err
+	}
+	return prune(base, cacheKeep)
+}
+
+// Prune the entries of a command but the most recent ones
+func prune(base string, keep int) error {
+	infos, err := ioutil.ReadDir(base)
+	if err != nil {
+		return err
+	}
+	var entries []os.FileInfo
+	for _, info := range infos {
+		if info.IsDir() && !strings.HasPrefix(info.Name(), "tmp") {
+			entries = append(entries, info)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime().After(entries[j].ModTime())
+	})
+	for i := keep; i < len(entries); i++ {
+		if err := os.RemoveAll(filepath.Join(base, entries[i].Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Digest of the content of a file
+func digest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Copy a file with its mode, the folders are created
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
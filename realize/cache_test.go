@@ -0,0 +1,121 @@
+package realize
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCache_Check(t *testing.T) {
+	if err := (&Cache{Outputs: []string{"gen"}}).check(); err == nil {
+		t.Error("Unexpected cache without inputs")
+	}
+	if err := (&Cache{Inputs: []string{"proto/["}}).check(); err == nil {
+		t.Error("Unexpected invalid pattern")
+	}
+	if err := (&Cache{Inputs: []string{"proto", "*.yaml"}, Outputs: []string{"gen"}}).check(); err != nil {
+		t.Error("Unexpected error", err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "proto", "v1"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "proto", "v1", "a.proto"), []byte("a"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "b.yaml"), []byte("b"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "c.txt"), []byte("c"), 0644)
+	files, err := expand(dir, []string{"*.yaml", "proto", "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(files, ",") != "b.yaml,"+filepath.Join("proto", "v1", "a.proto") {
+		t.Error("Unexpected files", files)
+	}
+	if _, err := expand(filepath.Join(dir, "proto"), []string{"../c.txt"}); err == nil {
+		t.Error("Unexpected file outside the dir")
+	}
+}
+
+func TestProject_Cached(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh")
+	}
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+	input, output := filepath.Join(dir, "in.txt"), filepath.Join(dir, "gen", "out.txt")
+	ioutil.WriteFile(input, []byte("a"), 0644)
+	p := Project{parent: &Realize{}, Path: dir}
+	cmd := Command{Cmd: "sh -c 'echo run >> runs; mkdir -p gen; cp in.txt gen/out.txt; echo built'", parent: &p,
+		Cache: &Cache{Inputs: []string{"in.txt"}, Outputs: []string{"gen"}}}
+	runs := func() int {
+		content, _ := ioutil.ReadFile(filepath.Join(dir, "runs"))
+		return strings.Count(string(content), "run")
+	}
+	if r := p.cached(context.Background(), cmd); r.Err != nil || r.Out != "built\n" || runs() != 1 {
+		t.Fatal("Unexpected run", r.Out, r.Err, runs())
+	}
+	// unchanged inputs, the removed output is restored without a run
+	os.RemoveAll(filepath.Join(dir, "gen"))
+	if r := p.cached(context.Background(), cmd); r.Err != nil || r.Out != "built\n" || runs() != 1 {
+		t.Error("Unexpected run", r.Out, r.Err, runs())
+	}
+	if content, _ := ioutil.ReadFile(output); string(content) != "a" {
+		t.Error("Unexpected restored output", string(content))
+	}
+	// an output unchanged isn't written again
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(output, old, old)
+	p.cached(context.Background(), cmd)
+	if fi, _ := os.Stat(output); !fi.ModTime().Equal(old) {
+		t.Error("Unexpected write of an unchanged output")
+	}
+	// changed inputs run the command again
+	ioutil.WriteFile(input, []byte("b"), 0644)
+	p.cached(context.Background(), cmd)
+	if content, _ := ioutil.ReadFile(output); string(content) != "b" || runs() != 2 {
+		t.Error("Unexpected output", string(content), runs())
+	}
+	// the cache isn't watched
+	if !p.shouldIgnore(filepath.Join(dir, FileCache, "x")) {
+		t.Error("Unexpected cache watched")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for i, name := range []string{"a", "b", "c", "tmp1"} {
+		os.Mkdir(filepath.Join(dir, name), 0755)
+		at := time.Now().Add(time.Duration(i) * time.Minute)
+		os.Chtimes(filepath.Join(dir, name), at, at)
+	}
+	if err := prune(dir, 2); err != nil {
+		t.Fatal(err)
+	}
+	infos, _ := ioutil.ReadDir(dir)
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	if strings.Join(names, ",") != "b,c,tmp1" {
+		t.Error("Unexpected entries", names)
+	}
+}
@@ -0,0 +1,334 @@
+package realize
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Diff of the files up to the size by default
+const diffSize = 32 * 1024
+
+// Lines of context around a change of a diff, the diff is skipped above the lines compared
+const (
+	diffContext = 3
+	diffLimit   = 1 << 22
+)
+
+// Changes lists on each reload the files changed since the last successful run grouped by folder, with a diff of the small text files
+type Changes struct {
+	Diff    bool   `yaml:"diff,omitempty" json:"diff,omitempty"`
+	MaxSize string `yaml:"max_size,omitempty" json:"max_size,omitempty"`
+}
+
+// Limit of the size of the diffed files
+func (c *Changes) limit() int64 {
+	if n, err := size(c.MaxSize); c.MaxSize != "" && err == nil && n > 0 {
+		return int64(n)
+	}
+	return diffSize
+}
+
+// Changed is a file changed since the last successful run, its status is created, modified or removed
+type changed struct {
+	path   string
+	status string
+	diff   string
+	seq    int
+}
+
+// Delta of the files changed since the last successful run and their content at that run
+type delta struct {
+	mu      sync.Mutex
+	root    string
+	diff    bool
+	limit   int64
+	seq     int
+	created map[string]bool
+	changed map[string]int
+	base    map[string][]byte
+}
+
+// NewDelta of the changes settings, the diffs are relative to the root
+func newDelta(c *Changes, root string) *delta {
+	return &delta{root: root, diff: c.Diff, limit: c.limit(), created: make(map[string]bool), changed: make(map[string]int), base: make(map[string][]byte)}
+}
+
+// Keep the content of a small text file, the diffs compare it
+func (d *delta) keep(path string, info os.FileInfo) {
+	if d == nil || !d.diff || info.IsDir() || info.Size() > d.limit {
+		return
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil || !textual(content) {
+		return
+	}
+	d.mu.Lock()
+	d.base[path] = content
+	d.mu.Unlock()
+}
+
+// Change of a file, created once the first change since the last successful run creates it
+func (d *delta) change(event fsnotify.Event) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.changed[event.Name]; !ok {
+		_, known := d.base[event.Name]
+		d.created[event.Name] = event.Op&fsnotify.Create != 0 && !known
+	}
+	d.seq++
+	d.changed[event.Name] = d.seq
+}
+
+// List of the changed files sorted by path, the diffs of the modified ones
+func (d *delta) list() []changed {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var list []changed
+	for path, seq := range d.changed {
+		c := changed{path: path, status: "modified", seq: seq}
+		info, err := os.Stat(path)
+		switch {
+		case err != nil && d.created[path]:
+			continue
+		case err != nil:
+			c.status = "removed"
+		case info.IsDir():
+			continue
+		case d.created[path]:
+			c.status = "created"
+		}
+		if old, ok := d.base[path]; ok && d.diff && c.status == "modified" && info.Size() <= d.limit {
+			if content, err := ioutil.ReadFile(path); err == nil && textual(content) {
+				name, err := filepath.Rel(d.root, path)
+				if err != nil {
+					name = path
+				}
+				c.diff = unified(name, string(old), string(content))
+			}
+		}
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].path < list[j].path })
+	return list
+}
+
+// Commit the listed changes after a successful run, the files changed again since the list stay
+func (d *delta) commit(list []changed) {
+	if d == nil {
+		return
+	}
+	for _, c := range list {
+		d.mu.Lock()
+		again := d.changed[c.path] != c.seq
+		if !again {
+			delete(d.changed, c.path)
+			delete(d.created, c.path)
+			delete(d.base, c.path)
+		}
+		d.mu.Unlock()
+		if info, err := os.Stat(c.path); !again && err == nil {
+			d.keep(c.path, info)
+		}
+	}
+}
+
+// Explain a reload, the files changed since the last successful run grouped by folder and their diffs
+func (p *Project) explain() []changed {
+	list := p.delta.list()
+	if len(list) == 0 {
+		return nil
+	}
+	groups := make(map[string][]changed)
+	var dirs []string
+	for _, c := range list {
+		dir, err := filepath.Rel(p.abs(""), filepath.Dir(c.path))
+		if err != nil {
+			dir = filepath.Dir(c.path)
+		}
+		if _, ok := groups[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		groups[dir] = append(groups[dir], c)
+	}
+	sort.Strings(dirs)
+	text := strconv.Itoa(len(list)) + " file/s changed since the last successful run"
+	msg := fmt.Sprintln(p.pname(p.Name, 4), ":", Magenta.Bold(len(list)), "file/s changed since the last successful run")
+	var stream []string
+	for _, dir := range dirs {
+		var plain, colored []string
+		for _, c := range groups[dir] {
+			plain = append(plain, filepath.Base(c.path)+" "+c.status)
+			colored = append(colored, Magenta.Regular(filepath.Base(c.path))+" "+c.status)
+		}
+		stream = append(stream, "  "+dir+string(filepath.Separator)+" "+strings.Join(plain, ", "))
+		msg += "  " + Blue.Regular(dir+string(filepath.Separator)) + " " + strings.Join(colored, ", ") + "\n"
+	}
+	for _, c := range list {
+		for _, line := range lines(c.diff) {
+			stream = append(stream, line)
+			switch {
+			case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+				msg += Magenta.Bold(line) + "\n"
+			case strings.HasPrefix(line, "@@"):
+				msg += Blue.Regular(line) + "\n"
+			case strings.HasPrefix(line, "+"):
+				msg += Green.Regular(line) + "\n"
+			case strings.HasPrefix(line, "-"):
+				msg += Red.Regular(line) + "\n"
+			default:
+				msg += line + "\n"
+			}
+		}
+	}
+	out := BufferOut{Time: time.Now(), Text: text, Stream: strings.Join(stream, "\n")}
+	p.stamp("log", out, msg, "")
+	return list
+}
+
+// Textual content, valid utf8 without null bytes
+func textual(content []byte) bool {
+	return utf8.Valid(content) && bytes.IndexByte(content, 0) < 0
+}
+
+// Edit of a diff, the line is kept, removed or added
+type edit struct {
+	op   byte
+	line string
+}
+
+// Edits turning a into b, the longest common subsequence of the lines between their common prefix and suffix
+func edits(a, b []string) []edit {
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	x, y := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	if len(x)*len(y) > diffLimit {
+		return nil
+	}
+	lcs := make([][]int32, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			switch {
+			case x[i] == y[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	list := make([]edit, 0, len(a)+len(y))
+	for _, line := range a[:pre] {
+		list = append(list, edit{' ', line})
+	}
+	for i, j := 0, 0; i < len(x) || j < len(y); {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			list = append(list, edit{' ', x[i]})
+			i, j = i+1, j+1
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			list = append(list, edit{'-', x[i]})
+			i++
+		default:
+			list = append(list, edit{'+', y[j]})
+			j++
+		}
+	}
+	for _, line := range a[len(a)-suf:] {
+		list = append(list, edit{' ', line})
+	}
+	return list
+}
+
+// Unified diff of two contents, empty without changes or too large
+func unified(name, a, b string) string {
+	list := edits(lines(a), lines(b))
+	var out strings.Builder
+	for start := 0; start < len(list); {
+		// next change
+		k := start
+		for k < len(list) && list[k].op == ' ' {
+			k++
+		}
+		if k == len(list) {
+			break
+		}
+		from := k - diffContext
+		if from < start {
+			from = start
+		}
+		// the hunk ends after the context of its last change, the changes closer are merged
+		end := k
+		for end < len(list) {
+			if list[end].op != ' ' {
+				end++
+				continue
+			}
+			same := end
+			for same < len(list) && list[same].op == ' ' {
+				same++
+			}
+			if same == len(list) || same-end > 2*diffContext {
+				end += diffContext
+				if end > len(list) {
+					end = len(list)
+				}
+				break
+			}
+			end = same
+		}
+		al, bl := 1, 1
+		for _, e := range list[:from] {
+			if e.op != '+' {
+				al++
+			}
+			if e.op != '-' {
+				bl++
+			}
+		}
+		an, bn := 0, 0
+		for _, e := range list[from:end] {
+			if e.op != '+' {
+				an++
+			}
+			if e.op != '-' {
+				bn++
+			}
+		}
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- a/%s\n+++ b/%s\n", filepath.ToSlash(name), filepath.ToSlash(name))
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", al, an, bl, bn)
+		for _, e := range list[from:end] {
+			fmt.Fprintf(&out, "%c%s\n", e.op, e.line)
+		}
+		start = end
+	}
+	return out.String()
+}
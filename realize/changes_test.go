@@ -0,0 +1,91 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestUnified(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\nm\n"
+	b := "a\nb\nc\nD\ne\nf\ng\nh\ni\nj\nk\nl\nm\nn\n"
+	expected := `--- a/main.go
++++ b/main.go
+@@ -1,7 +1,7 @@
+ a
+ b
+ c
+-d
++D
+ e
+ f
+ g
+@@ -11,3 +11,4 @@
+ k
+ l
+ m
++n
+`
+	if diff := unified("main.go", a, b); diff != expected {
+		t.Error("Unexpected diff", diff)
+	}
+	if diff := unified("main.go", a, a); diff != "" {
+		t.Error("Unexpected diff", diff)
+	}
+	// the changes closer than the context are in the same hunk
+	b = "a\nB\nc\nd\ne\nF\ng\n"
+	if diff := unified("main.go", "a\nb\nc\nd\ne\nf\ng\n", b); strings.Count(diff, "@@ ") != 1 || !strings.Contains(diff, "@@ -1,7 +1,7 @@") {
+		t.Error("Unexpected hunks", diff)
+	}
+	if !textual([]byte("package main")) || textual([]byte{'a', 0, 'b'}) {
+		t.Error("Unexpected text detection")
+	}
+}
+
+func TestProject_Explain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	main, gen := filepath.Join(dir, "main.go"), filepath.Join(dir, "api", "gen.go")
+	os.Mkdir(filepath.Join(dir, "api"), 0755)
+	ioutil.WriteFile(main, []byte("package main\n\nfunc main() {}\n"), 0644)
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{parent: &r, Path: dir})
+	p := &r.Projects[0]
+	p.delta = newDelta(&Changes{Diff: true}, dir)
+	if info, err := os.Stat(main); err == nil {
+		p.delta.keep(main, info)
+	}
+	ioutil.WriteFile(main, []byte("package main\n\nfunc main() { run() }\n"), 0644)
+	ioutil.WriteFile(gen, []byte("package api\n"), 0644)
+	p.delta.change(fsnotify.Event{Name: main, Op: fsnotify.Write})
+	p.delta.change(fsnotify.Event{Name: gen, Op: fsnotify.Create})
+	listed := p.explain()
+	if len(listed) != 2 || listed[0].path != gen || listed[0].status != "created" || listed[1].status != "modified" {
+		t.Fatal("Unexpected changes", listed)
+	}
+	if !strings.Contains(listed[1].diff, "-func main() {}\n+func main() { run() }\n") {
+		t.Error("Unexpected diff", listed[1].diff)
+	}
+	if len(p.Buffer.StdLog) != 1 || !strings.Contains(p.Buffer.StdLog[0].Stream, "api"+string(filepath.Separator)+" gen.go created") {
+		t.Error("Unexpected report", p.Buffer.StdLog)
+	}
+	// changed again during the run, it's listed by the next reload
+	p.delta.change(fsnotify.Event{Name: gen, Op: fsnotify.Write})
+	p.delta.commit(listed)
+	if listed = p.delta.list(); len(listed) != 1 || listed[0].path != gen {
+		t.Fatal("Unexpected changes", listed)
+	}
+	p.delta.commit(listed)
+	os.Remove(main)
+	p.delta.change(fsnotify.Event{Name: main, Op: fsnotify.Remove})
+	if listed = p.delta.list(); len(listed) != 1 || listed[0].status != "removed" {
+		t.Error("Unexpected changes", listed)
+	}
+}
@@ -1,15 +1,16 @@
 package realize
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"go/build"
 	"log"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
@@ -34,22 +35,40 @@ type (
 
 	// Realize main struct
 	Realize struct {
-		Settings Settings `yaml:"settings" json:"settings"`
-		Server   Server   `yaml:"server,omitempty" json:"server,omitempty"`
-		Schema   `yaml:",inline" json:",inline"`
-		Sync     chan string `yaml:"-" json:"-"`
-		Err      Func        `yaml:"-" json:"-"`
-		After    Func        `yaml:"-"  json:"-"`
-		Before   Func        `yaml:"-"  json:"-"`
-		Change   Func        `yaml:"-"  json:"-"`
-		Reload   Func        `yaml:"-"  json:"-"`
+		Settings  Settings           `yaml:"settings" json:"settings"`
+		Server    Server             `yaml:"server,omitempty" json:"server,omitempty"`
+		Notify    Notify             `yaml:"notify,omitempty" json:"notify,omitempty"`
+		Commands  map[string]Command `yaml:"commands,omitempty" json:"commands,omitempty"`
+		Schema    `yaml:",inline" json:",inline"`
+		Sync      chan string `yaml:"-" json:"-"`
+		Err       Func        `yaml:"-" json:"-"`
+		After     Func        `yaml:"-"  json:"-"`
+		Before    Func        `yaml:"-"  json:"-"`
+		Change    Func        `yaml:"-"  json:"-"`
+		Reload    Func        `yaml:"-"  json:"-"`
+		Once      bool        `yaml:"-"  json:"-"`
+		Tui       bool        `yaml:"-"  json:"-"`
+		Keys      bool        `yaml:"-"  json:"-"`
+		forward   *forwarder
+		control   *control
+		line      *statusLine
+		events    *events
+		shared    *registry
+		started   time.Time
+		code      int32
+		labels    int
+		executors []TaskExecutor
 	}
 
+	// errSignal is sent to the projects when realize exits on error
+	errSignal struct{}
+
 	// Context is used as argument for func
 	Context struct {
 		Path    string
 		Project *Project
-		Stop    <-chan bool
+		Ctx     context.Context
+		Stop    <-chan struct{}
 		Watcher FileWatcher
 		Event   fsnotify.Event
 	}
@@ -58,46 +77,157 @@ type (
 	Func func(Context)
 )
 
-// init check
-func init() {
-	// custom log
-	log.SetFlags(0)
-	log.SetOutput(LogWriter{})
-	if build.Default.GOPATH == "" {
-		log.Fatal("$GOPATH isn't set properly")
-	}
-	path := filepath.SplitList(build.Default.GOPATH)
-	if err := os.Setenv("GOBIN", filepath.Join(path[len(path)-1], "bin")); err != nil {
-		log.Fatal(err)
-	}
-}
-
 // Stop realize workflow
 func (r *Realize) Stop() error {
 	for k := range r.Schema.Projects {
-		if r.Schema.Projects[k].exit != nil {
-			close(r.Schema.Projects[k].exit)
-		}
+		r.Schema.Projects[k].Stop()
 	}
 	return nil
 }
 
 // Start realize workflow
 func (r *Realize) Start() error {
+	r.started = time.Now()
+	// the scripts using a named command
+	if err := r.resolve(); err != nil {
+		r.report(err.Error())
+		return err
+	}
 	if len(r.Schema.Projects) > 0 {
 		var wg sync.WaitGroup
+		r.labels = r.width()
+		var ui *tui
+		if r.Tui {
+			ui = &tui{parent: r}
+			if err := ui.start(); err != nil {
+				log.Println(r.Prefix(Red.Regular(err.Error())))
+				ui = nil
+			}
+		}
+		// single keys from the terminal, the terminal ui has its own
+		var kb *keys
+		if r.Keys && ui == nil && !r.Once && !r.stdin() {
+			kb = &keys{parent: r}
+			if kb.start() != nil {
+				kb = nil
+			}
+		}
+		// states of the projects in the terminal title
+		if r.Settings.StatusLine && ui == nil && !journal {
+			r.line = newStatusLine()
+			defer r.line.clear()
+		}
+		r.forward = &forwarder{}
+		r.Notify.setup()
+		// a single fs-event watcher for the overlapping projects
+		r.shared = &registry{}
 		wg.Add(len(r.Schema.Projects))
 		for k := range r.Schema.Projects {
 			r.Schema.Projects[k].exit = make(chan os.Signal, 1)
-			signal.Notify(r.Schema.Projects[k].exit, os.Interrupt)
+			signal.Notify(r.Schema.Projects[k].exit, os.Interrupt, syscall.SIGTERM)
 			r.Schema.Projects[k].parent = r
-			go r.Schema.Projects[k].Watch(&wg)
+			r.Schema.Projects[k].lifecycle = &lifecycle{}
+			r.Schema.Projects[k].records = &records{}
+			if r.Once {
+				go r.Schema.Projects[k].Once(&wg)
+			} else {
+				r.Schema.Projects[k].control = make(chan string, 1)
+				r.Schema.Projects[k].changes = make(chan pathChange, 1)
+				go r.Schema.Projects[k].Watch(&wg)
+			}
+		}
+		// stopped after their max runtime
+		defer r.expire()()
+		// forward signals to the running commands
+		r.forward.start()
+		defer r.forward.stop()
+		// pause and resume the watching by signal
+		if !r.Once {
+			defer r.toggled()()
+		}
+		// control api
+		if r.Settings.Control != "" {
+			r.control = &control{parent: r}
+			if err := r.control.start(r.Settings.Control); err != nil {
+				log.Println(r.Prefix(Red.Regular(err.Error())))
+			}
+			defer r.control.stop()
 		}
 		wg.Wait()
-	} else {
-		return errors.New("there are no projects")
+		ui.stop()
+		kb.stop()
+		r.summary()
+		reason := "stopped"
+		for _, p := range r.Schema.Projects {
+			if p.reason != "" {
+				reason = p.reason
+			}
+		}
+		return r.report(reason)
+	}
+	err := errors.New("there are no projects")
+	r.report(err.Error())
+	return err
+}
+
+// Width of the longest output label, the prefixes are aligned to it
+func (r *Realize) width() (width int) {
+	for k := range r.Schema.Projects {
+		p := &r.Schema.Projects[k]
+		for _, task := range p.labels() {
+			if n := len(p.Name) + len(task) + 1; n > width {
+				width = n
+			}
+		}
+	}
+	return
+}
+
+// Fail keeps the exit code of the first failure and stops all the projects on exit on error
+func (r *Realize) fail(res Response) {
+	code := int32(res.Code)
+	if code == 0 {
+		code = 1
+	}
+	// the projects fail concurrently, the first one wins
+	if !atomic.CompareAndSwapInt32(&r.code, 0, code) {
+		return
+	}
+	if !r.Settings.ExitOnError {
+		return
+	}
+	for k := range r.Schema.Projects {
+		select {
+		case r.Schema.Projects[k].exit <- errSignal{}:
+		default:
+		}
+	}
+}
+
+// ExitCode of the realize session, not zero if stopped on error
+func (r *Realize) ExitCode() int {
+	return int(atomic.LoadInt32(&r.code))
+}
+
+// Signal implements os.Signal
+func (errSignal) Signal() {}
+
+// String implements os.Signal
+func (errSignal) String() string {
+	return "error"
+}
+
+// Summary prints the results of the commands run on exit
+func (r *Realize) summary() {
+	for _, p := range r.Schema.Projects {
+		for _, res := range p.shutdown {
+			if res.Err != nil {
+				log.Println(p.pname(p.Name, 2), ":", Red.Bold("Exit"), Red.Bold("\"")+res.Name+Red.Bold("\""), Red.Regular(res.Err.Error()))
+			} else {
+				log.Println(p.pname(p.Name, 5), ":", Green.Bold("Exit"), Green.Bold("\"")+res.Name+Green.Bold("\""), "completed")
+			}
+		}
 	}
-	return nil
 }
 
 // Prefix a given string with tool name
@@ -108,8 +238,11 @@ func (r *Realize) Prefix(input string) string {
 	return input
 }
 
-// Rewrite the layout of the log timestamp
+// Rewrite the layout of the log timestamp, the journal has its own
 func (w LogWriter) Write(bytes []byte) (int, error) {
+	if len(bytes) > 0 && journal {
+		return fmt.Fprint(Output, string(bytes))
+	}
 	if len(bytes) > 0 {
 		return fmt.Fprint(Output, Yellow.Regular("["), time.Now().Format("15:04:05"), Yellow.Regular("]"), string(bytes))
 	}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -59,3 +60,32 @@ func TestLogWriter_Write(t *testing.T) {
 		t.Error("Unexpected error", err, "string length should be 0 instead", val)
 	}
 }
+
+func TestRealize_Fail(t *testing.T) {
+	r := Realize{}
+	r.Settings.ExitOnError = true
+	r.Projects = append(r.Projects, Project{exit: make(chan os.Signal, 1)})
+	r.fail(Response{Code: 3})
+	r.fail(Response{Code: 4})
+	if r.ExitCode() != 3 {
+		t.Error("Expected exit code 3 instead", r.ExitCode())
+	}
+	if sig := <-r.Projects[0].exit; sig.String() != "error" {
+		t.Error("Unexpected signal", sig)
+	}
+	// the projects fail concurrently, a single code is kept
+	r = Realize{}
+	var wg sync.WaitGroup
+	for i := 1; i <= 8; i++ {
+		wg.Add(1)
+		go func(code int) {
+			defer wg.Done()
+			r.fail(Response{Code: code})
+			r.ExitCode()
+		}(i)
+	}
+	wg.Wait()
+	if r.ExitCode() == 0 {
+		t.Error("Expected an exit code")
+	}
+}
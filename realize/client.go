@@ -0,0 +1,129 @@
+package realize
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Client of the control api of a running realize
+type Client struct {
+	Addr string
+	http *http.Client
+	base string
+}
+
+// NewClient returns a client of the control api, addr is a unix socket path or host:port
+func NewClient(addr string) *Client {
+	c := &Client{Addr: addr, base: "http://" + addr}
+	transport := &http.Transport{}
+	if strings.ContainsAny(addr, "/\\") {
+		c.base = "http://" + RPrefix
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", addr)
+		}
+	}
+	c.http = &http.Client{Transport: transport, Timeout: 10 * time.Second}
+	return c
+}
+
+// Projects returns the state of the projects
+func (c *Client) Projects() (list []ProjectState, err error) {
+	err = c.do(http.MethodGet, "/projects", &list)
+	return
+}
+
+// Action sends restart, pause or resume to a project
+func (c *Client) Action(name, action string) (state ProjectState, err error) {
+	err = c.do(http.MethodPost, "/projects/"+url.PathEscape(name)+"/"+action, &state)
+	return
+}
+
+// Watch adds a path, relative to the project, to the watched ones of a project
+func (c *Client) Watch(name, path string) (state ProjectState, err error) {
+	err = c.do(http.MethodPost, "/projects/"+url.PathEscape(name)+"/paths?path="+url.QueryEscape(path), &state)
+	return
+}
+
+// Unwatch removes a watched path of a project
+func (c *Client) Unwatch(name, path string) (state ProjectState, err error) {
+	err = c.do(http.MethodDelete, "/projects/"+url.PathEscape(name)+"/paths?path="+url.QueryEscape(path), &state)
+	return
+}
+
+// Logs returns the last n lines of a project
+func (c *Client) Logs(name string, n int) (lines []LogLine, err error) {
+	err = c.do(http.MethodGet, fmt.Sprintf("/projects/%s/logs?n=%d", url.PathEscape(name), n), &lines)
+	return
+}
+
+// WatchSet returns the watched paths of a project, the ignore rules that matched and the missing paths
+func (c *Client) WatchSet(name string) (set WatchSet, err error) {
+	err = c.do(http.MethodGet, "/projects/"+url.PathEscape(name)+"/watch", &set)
+	return
+}
+
+// Diagnostics returns the problems of the last completed run of a project
+func (c *Client) Diagnostics(name string) (pr Problems, err error) {
+	err = c.do(http.MethodGet, "/projects/"+url.PathEscape(name)+"/diagnostics", &pr)
+	return
+}
+
+// Restart the projects of a file
+func (c *Client) Restart(file string) (list []ProjectState, err error) {
+	err = c.do(http.MethodPost, "/restart?file="+url.QueryEscape(file), &list)
+	return
+}
+
+// Stop asks realize to stop all the projects and exit
+func (c *Client) Stop() error {
+	return c.do(http.MethodPost, "/stop", nil)
+}
+
+// Shutdown stops realize and waits for its exit
+func (c *Client) Shutdown(timeout time.Duration) error {
+	if err := c.Stop(); err != nil {
+		return err
+	}
+	for start := time.Now(); time.Since(start) < timeout; time.Sleep(100 * time.Millisecond) {
+		if _, err := c.Projects(); err != nil {
+			os.Remove(FilePid)
+			return nil
+		}
+	}
+	return errors.New("realize is still running after " + timeout.String())
+}
+
+// Do a request and decode the response, the message of an api error is returned
+func (c *Client) do(method, path string, out interface{}) error {
+	req, err := http.NewRequest(method, c.base+path, nil)
+	if err != nil {
+		return err
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusBadRequest {
+		var e struct {
+			Message string `json:"message"`
+		}
+		if json.NewDecoder(res.Body).Decode(&e) == nil && e.Message != "" {
+			return errors.New(e.Message)
+		}
+		return errors.New(res.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
@@ -0,0 +1,50 @@
+package realize
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Inherit the fields of a named command, the ones set by the script override it
+func (c Command) inherit(def Command) Command {
+	v, d := reflect.ValueOf(&c).Elem(), reflect.ValueOf(def)
+	for i := 0; i < v.NumField(); i++ {
+		if f := v.Field(i); f.CanSet() && f.IsZero() {
+			f.Set(d.Field(i))
+		}
+	}
+	return c
+}
+
+// Scripts run by a project, the ones using a named command resolved
+func (w *Watch) scripts() []Command {
+	if w.resolved != nil {
+		return w.resolved
+	}
+	return w.Scripts
+}
+
+// Resolve the scripts using a named command into a copy run in their place, the config keeps their use
+func (r *Realize) resolve() error {
+	for name, def := range r.Commands {
+		if def.Use != "" {
+			return errors.New("command " + name + " uses " + def.Use + ", only the scripts use the named commands")
+		}
+	}
+	for k := range r.Schema.Projects {
+		p := &r.Schema.Projects[k]
+		resolved := append([]Command(nil), p.Watcher.Scripts...)
+		for i, c := range resolved {
+			if c.Use == "" {
+				continue
+			}
+			def, ok := r.Commands[c.Use]
+			if !ok {
+				return errors.New(p.Name + ": unknown command " + c.Use)
+			}
+			resolved[i] = c.inherit(def)
+		}
+		p.Watcher.resolved = resolved
+	}
+	return nil
+}
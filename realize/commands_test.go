@@ -0,0 +1,70 @@
+package realize
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestRealize_Resolve(t *testing.T) {
+	config := `
+commands:
+  vet:
+    command: go vet ./...
+    output: errors
+  migrate:
+    name: migrate
+    command: migrate up
+    path: db
+schema:
+- name: api
+  watcher:
+    scripts:
+    - type: before
+      use: vet
+    - type: after
+      use: migrate
+      path: db/api
+      output: all
+- name: web
+  watcher:
+    scripts:
+    - type: before
+      use: migrate
+`
+	r := Realize{}
+	if err := yaml.Unmarshal([]byte(config), &r); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.resolve(); err != nil {
+		t.Fatal(err)
+	}
+	api, web := r.Schema.Projects[0].Watcher.scripts(), r.Schema.Projects[1].Watcher.scripts()
+	if api[0].Cmd != "go vet ./..." || api[0].Type != "before" || api[0].Output != OutputErrors {
+		t.Error("Unexpected script", api[0])
+	}
+	// the fields of the script override the named command
+	if api[1].Cmd != "migrate up" || api[1].Name != "migrate" || api[1].Path != "db/api" || api[1].Output != OutputAll || api[1].Type != "after" {
+		t.Error("Unexpected script", api[1])
+	}
+	if web[0].Cmd != "migrate up" || web[0].Path != "db" || web[0].Type != "before" {
+		t.Error("Unexpected script", web[0])
+	}
+	// the scripts written back to the config keep their use
+	if s := r.Schema.Projects[0].Watcher.Scripts[1]; s.Use != "migrate" || s.Cmd != "" {
+		t.Error("Unexpected written script", s)
+	}
+	// the definitions aren't changed
+	if r.Commands["migrate"].Path != "db" || r.Commands["migrate"].Type != "" {
+		t.Error("Unexpected command", r.Commands["migrate"])
+	}
+	r.Schema.Projects[1].Watcher.Scripts = []Command{{Type: "before", Use: "lint"}}
+	if err := r.resolve(); err == nil {
+		t.Error("Unexpected unknown command resolved")
+	}
+	r.Schema.Projects[1].Watcher.Scripts = nil
+	r.Commands["lint"] = Command{Use: "vet"}
+	if err := r.resolve(); err == nil {
+		t.Error("Unexpected named command using another one")
+	}
+}
@@ -0,0 +1,316 @@
+package realize
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// Control actions handled by a watching project
+const (
+	ActionRestart = "restart"
+	ActionPause   = "pause"
+	ActionResume  = "resume"
+)
+
+// Control is a local http api used by editors and scripts to drive a running realize
+type control struct {
+	parent  *Realize
+	echo    *echo.Echo
+	socket  string
+	reports reports
+}
+
+// PathChange adds or removes a watched path of a project, or the imported dirs listed again
+type pathChange struct {
+	path string
+	add  bool
+	// dirs of the imported packages listed again
+	imports bool
+	dirs    []string
+}
+
+// ProjectState is the state of a project returned by the control api
+type ProjectState struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Status  string    `json:"status"`
+	State   string    `json:"state"`
+	Since   time.Time `json:"since"`
+	Paused  bool      `json:"paused"`
+	Watch   bool      `json:"watch"`
+	Paths   []string  `json:"paths"`
+	Files   int64     `json:"files"`
+	Folders int64     `json:"folders"`
+}
+
+// LogLine is an output or log line of a project, kind is out, log or error
+type LogLine struct {
+	Kind string `json:"kind"`
+	BufferOut
+}
+
+// Listen on a unix socket if the address is a path, on tcp otherwise
+func listen(addr string) (net.Listener, error) {
+	if strings.ContainsAny(addr, "/\\") {
+		// remove a stale socket, never another file
+		if fi, err := os.Lstat(addr); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			os.Remove(addr)
+		}
+		return net.Listen("unix", addr)
+	}
+	return net.Listen("tcp", addr)
+}
+
+// Start the control api
+func (c *control) start(addr string) error {
+	l, err := listen(addr)
+	if err != nil {
+		return err
+	}
+	if l.Addr().Network() == "unix" {
+		c.socket = addr
+	}
+	c.echo = echo.New()
+	c.echo.HideBanner = true
+	c.echo.HidePort = true
+	c.echo.Listener = l
+	c.echo.GET("/projects", c.projects)
+	c.echo.GET("/projects/:name/logs", c.logs)
+	c.echo.GET("/projects/:name/watch", c.watchSet)
+	c.echo.GET("/projects/:name/diagnostics", c.diagnostics)
+	c.echo.GET("/diagnostics", c.editor)
+	c.echo.POST("/restart", c.restartFile)
+	c.echo.POST("/projects/:name/paths", c.paths)
+	c.echo.DELETE("/projects/:name/paths", c.paths)
+	c.echo.POST("/projects/:name/:action", c.action)
+	c.echo.POST("/stop", c.stopAll)
+	go c.echo.Start("")
+	log.Println(c.parent.Prefix("Control api on " + addr))
+	return nil
+}
+
+// Stop the control api
+func (c *control) stop() {
+	if c == nil || c.echo == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	c.echo.Shutdown(ctx)
+	if c.socket != "" {
+		os.Remove(c.socket)
+	}
+}
+
+// StopAll stops all the projects, realize exits when their after commands are done
+func (c *control) stopAll(ctx echo.Context) error {
+	go c.parent.Stop()
+	return ctx.NoContent(http.StatusAccepted)
+}
+
+// Project by name
+func (c *control) project(name string) (*Project, error) {
+	for k := range c.parent.Schema.Projects {
+		if strings.EqualFold(c.parent.Schema.Projects[k].Name, name) {
+			return &c.parent.Schema.Projects[k], nil
+		}
+	}
+	return nil, echo.NewHTTPError(http.StatusNotFound, "project "+name+" not found")
+}
+
+// Projects returns the state of all the projects
+func (c *control) projects(ctx echo.Context) error {
+	list := []ProjectState{}
+	for _, p := range c.parent.Schema.Projects {
+		list = append(list, p.state())
+	}
+	return ctx.JSON(http.StatusOK, list)
+}
+
+// Action sends restart, pause or resume to a project
+func (c *control) action(ctx echo.Context) error {
+	p, err := c.project(ctx.Param("name"))
+	if err != nil {
+		return err
+	}
+	switch ctx.Param("action") {
+	case ActionRestart, ActionPause, ActionResume:
+	default:
+		return echo.NewHTTPError(http.StatusNotFound, "unknown action "+ctx.Param("action"))
+	}
+	if err := p.send(ctx.Param("action")); err != nil {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	return ctx.JSON(http.StatusAccepted, p.state())
+}
+
+// Paths adds (POST) or removes (DELETE) a watched path of a project, the path is relative to the project
+func (c *control) paths(ctx echo.Context) error {
+	p, err := c.project(ctx.Param("name"))
+	if err != nil {
+		return err
+	}
+	if ctx.QueryParam("path") == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "missing path")
+	}
+	change := pathChange{path: filepath.Clean(ctx.QueryParam("path")), add: ctx.Request().Method == http.MethodPost}
+	found := false
+	_, paths, _, _ := p.watching()
+	for _, v := range paths {
+		if filepath.Clean(v) == change.path {
+			found = true
+			break
+		}
+	}
+	switch {
+	case change.add && found:
+		return echo.NewHTTPError(http.StatusConflict, "path "+change.path+" is already watched")
+	case !change.add && !found:
+		return echo.NewHTTPError(http.StatusNotFound, "path "+change.path+" isn't watched")
+	case change.add:
+		if fi, err := os.Stat(p.abs(change.path)); err != nil || !fi.IsDir() {
+			return echo.NewHTTPError(http.StatusBadRequest, "path "+change.path+" isn't a directory")
+		}
+	}
+	if err := p.change(change); err != nil {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	return ctx.JSON(http.StatusAccepted, p.state())
+}
+
+// Logs returns the last lines of a project, 100 by default
+func (c *control) logs(ctx echo.Context) error {
+	p, err := c.project(ctx.Param("name"))
+	if err != nil {
+		return err
+	}
+	n := 100
+	if v := ctx.QueryParam("n"); v != "" {
+		if n, err = strconv.Atoi(v); err != nil || n < 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid n")
+		}
+	}
+	return ctx.JSON(http.StatusOK, p.lines(n))
+}
+
+// WatchSet returns the watched paths of a project, the ignore rules that matched and the missing paths
+func (c *control) watchSet(ctx echo.Context) error {
+	p, err := c.project(ctx.Param("name"))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, p.set.snapshot())
+}
+
+// View of the watch loop state, published by the loop for the other goroutines
+type view struct {
+	mu      sync.Mutex
+	paused  bool
+	paths   []string
+	files   int64
+	folders int64
+}
+
+// Show the state of the watch loop to the other goroutines
+func (p *Project) show() {
+	if p.view == nil {
+		return
+	}
+	p.view.mu.Lock()
+	defer p.view.mu.Unlock()
+	p.view.paused, p.view.files, p.view.folders = p.paused, p.files, p.folders
+	p.view.paths = append([]string(nil), p.Watcher.Paths...)
+}
+
+// Watching state of a project, the published one while its loop runs
+func (p *Project) watching() (paused bool, paths []string, files, folders int64) {
+	if p.view == nil {
+		return p.paused, p.Watcher.Paths, p.files, p.folders
+	}
+	p.view.mu.Lock()
+	defer p.view.mu.Unlock()
+	return p.view.paused, p.view.paths, p.view.files, p.view.folders
+}
+
+// State of a project
+func (p *Project) state() ProjectState {
+	state, since := p.lifecycle.current()
+	paused, paths, files, folders := p.watching()
+	// copied under the lock of its writers
+	unlock := p.locked()
+	status := p.status
+	unlock()
+	return ProjectState{
+		Name:    p.Name,
+		Path:    p.Path,
+		Status:  status,
+		State:   state,
+		Since:   since,
+		Paused:  paused,
+		Watch:   p.control != nil,
+		Paths:   paths,
+		Files:   files,
+		Folders: folders,
+	}
+}
+
+// Send an action to a watching project
+func (p *Project) send(action string) error {
+	if p.control == nil {
+		return errors.New("project " + p.Name + " isn't watching")
+	}
+	select {
+	case p.control <- action:
+		return nil
+	default:
+		return errors.New("project " + p.Name + " is busy")
+	}
+}
+
+// Change sends a path change to a watching project
+func (p *Project) change(c pathChange) error {
+	if p.changes == nil {
+		return errors.New("project " + p.Name + " isn't watching")
+	}
+	select {
+	case p.changes <- c:
+		return nil
+	default:
+		return errors.New("project " + p.Name + " is busy")
+	}
+}
+
+// Lines returns the last n lines of the project buffer sorted by time
+func (p *Project) lines(n int) []LogLine {
+	unlock := p.locked()
+	list := []LogLine{}
+	for _, v := range p.Buffer.StdOut {
+		list = append(list, LogLine{Kind: "out", BufferOut: v})
+	}
+	for _, v := range p.Buffer.StdLog {
+		list = append(list, LogLine{Kind: "log", BufferOut: v})
+	}
+	for _, v := range p.Buffer.StdErr {
+		list = append(list, LogLine{Kind: "error", BufferOut: v})
+	}
+	unlock()
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Time.Before(list[j].Time)
+	})
+	if len(list) > n {
+		list = list[len(list)-n:]
+	}
+	return list
+}
@@ -0,0 +1,339 @@
+package realize
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestControl(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "r.sock")
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{Name: "test", control: make(chan string, 1)})
+	r.Projects[0].Buffer.StdLog = []BufferOut{{Time: time.Now(), Text: "first"}}
+	r.Projects[0].Buffer.StdOut = []BufferOut{{Time: time.Now().Add(time.Second), Text: "second"}}
+	c := &control{parent: &r}
+	if err := c.start(socket); err != nil {
+		t.Fatal(err)
+	}
+	defer c.stop()
+	client := http.Client{Transport: &http.Transport{
+		DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+			return net.Dial("unix", socket)
+		},
+	}}
+	var list []ProjectState
+	res, err := client.Get("http://realize/projects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.NewDecoder(res.Body).Decode(&list)
+	res.Body.Close()
+	if len(list) != 1 || list[0].Name != "test" || !list[0].Watch || list[0].State != StatePending {
+		t.Error("Unexpected projects", list)
+	}
+	res, err = client.Post("http://realize/projects/test/restart", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusAccepted || <-r.Projects[0].control != ActionRestart {
+		t.Error("Unexpected restart", res.StatusCode)
+	}
+	res, err = client.Post("http://realize/projects/missing/restart", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Error("Unexpected status", res.StatusCode)
+	}
+	var lines []LogLine
+	res, err = client.Get("http://realize/projects/test/logs?n=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.NewDecoder(res.Body).Decode(&lines)
+	res.Body.Close()
+	if len(lines) != 1 || lines[0].Text != "second" || lines[0].Kind != "out" {
+		t.Error("Unexpected logs", lines)
+	}
+}
+
+func TestProject_Send(t *testing.T) {
+	p := Project{}
+	if p.send(ActionPause) == nil {
+		t.Error("Expected an error")
+	}
+	p.control = make(chan string, 1)
+	if err := p.send(ActionPause); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	if p.send(ActionResume) == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "r.sock")
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{Name: "test", exit: make(chan os.Signal, 1), control: make(chan string, 1)})
+	c := &control{parent: &r}
+	if err := c.start(socket); err != nil {
+		t.Fatal(err)
+	}
+	defer c.stop()
+	cl := NewClient(socket)
+	list, err := cl.Projects()
+	if err != nil || len(list) != 1 {
+		t.Fatal("Unexpected error", err, list)
+	}
+	if _, err := cl.Action("test", ActionPause); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	if _, err := cl.Action("missing", ActionPause); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Error("Expected an error", err)
+	}
+	if set, err := cl.WatchSet("test"); err != nil || set.Paths == nil || len(set.Missing) != 0 {
+		t.Error("Unexpected watch set", set, err)
+	}
+	if err := cl.Stop(); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	select {
+	case _, ok := <-r.Projects[0].exit:
+		if ok {
+			t.Error("Expected a closed channel")
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected a stop")
+	}
+}
+
+func TestClient_Watch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "gen"), 0755)
+	socket := filepath.Join(dir, "r.sock")
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{Name: "test", Path: dir, changes: make(chan pathChange, 1)})
+	r.Projects[0].Watcher.Paths = []string{"/"}
+	c := &control{parent: &r}
+	if err := c.start(socket); err != nil {
+		t.Fatal(err)
+	}
+	defer c.stop()
+	cl := NewClient(socket)
+	if _, err := cl.Watch("test", "./gen"); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	if change := <-r.Projects[0].changes; change.path != "gen" || !change.add {
+		t.Error("Unexpected change", change)
+	}
+	if _, err := cl.Watch("test", "missing"); err == nil || !strings.Contains(err.Error(), "isn't a directory") {
+		t.Error("Expected an error", err)
+	}
+	if _, err := cl.Watch("test", "/"); err == nil || !strings.Contains(err.Error(), "already watched") {
+		t.Error("Expected an error", err)
+	}
+	if _, err := cl.Unwatch("test", "gen"); err == nil || !strings.Contains(err.Error(), "isn't watched") {
+		t.Error("Expected an error", err)
+	}
+	if _, err := cl.Unwatch("test", "/"); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	if change := <-r.Projects[0].changes; change.path != "/" || change.add {
+		t.Error("Unexpected change", change)
+	}
+}
+
+func TestClient_Diagnostics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "r.sock")
+	main, api := filepath.Join(dir, "main.go"), filepath.Join(dir, "api", "api.go")
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{Name: "test", Path: dir, control: make(chan string, 1)})
+	r.Projects = append(r.Projects, Project{Name: "api", Path: filepath.Join(dir, "api"), control: make(chan string, 1)})
+	r.control = &control{parent: &r}
+	if err := r.control.start(socket); err != nil {
+		t.Fatal(err)
+	}
+	defer r.control.stop()
+	p := &r.Projects[0]
+	p.parent = &r
+	p.outcome = StatusFailure
+	p.problems = []Diagnostic{{File: main, Line: 3, Message: "undefined: x", Severity: SeverityError}}
+	p.diagnosed("run1")
+	cl := NewClient(socket)
+	pr, err := cl.Diagnostics("test")
+	if err != nil || pr.Run != "run1" || pr.Status != StatusFailure || len(pr.Files[main]) != 1 {
+		t.Fatal("Unexpected problems", pr, err)
+	}
+	// the stream starts with the current problems
+	client := &http.Client{Transport: &http.Transport{DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", socket)
+	}}}
+	rs, err := client.Get("http://" + RPrefix + "/diagnostics?project=test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rs.Body.Close()
+	events := make(chan Problems)
+	go func() {
+		scanner := bufio.NewScanner(rs.Body)
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.HasPrefix(line, "data: ") {
+				var pr Problems
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &pr)
+				events <- pr
+			}
+		}
+	}()
+	next := func() Problems {
+		select {
+		case pr := <-events:
+			return pr
+		case <-time.After(time.Second):
+			t.Fatal("Expected an event")
+		}
+		return Problems{}
+	}
+	if pr := next(); pr.Run != "run1" {
+		t.Error("Unexpected event", pr)
+	}
+	// the fixed file is listed empty
+	p.outcome, p.problems = StatusSuccess, nil
+	p.diagnosed("run2")
+	if pr := next(); pr.Run != "run2" || pr.Files[main] == nil || len(pr.Files[main]) != 0 {
+		t.Error("Unexpected event", pr)
+	}
+	// the deepest project of the file is restarted
+	list, err := cl.Restart(api)
+	if err != nil || len(list) != 1 || list[0].Name != "api" {
+		t.Error("Unexpected restart", list, err)
+	}
+	if action := <-r.Projects[1].control; action != ActionRestart {
+		t.Error("Unexpected action", action)
+	}
+	if _, err := cl.Restart(os.TempDir()); err == nil || !strings.Contains(err.Error(), "no project") {
+		t.Error("Expected an error", err)
+	}
+}
+
+func TestListen(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets not removed on windows")
+	}
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// a file isn't removed
+	file := filepath.Join(dir, "file")
+	ioutil.WriteFile(file, []byte("data"), 0644)
+	if _, err := listen(file); err == nil {
+		t.Error("Expected an error")
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Error("Unexpected removed file", err)
+	}
+	// a stale socket is
+	socket := filepath.Join(dir, "r.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	if l, err = listen(socket); err != nil {
+		t.Error("Unexpected error", err)
+	} else {
+		l.Close()
+	}
+}
+
+func TestProject_Watching(t *testing.T) {
+	p := Project{files: 2, folders: 1, paused: true}
+	if s := p.state(); !s.Paused || s.Files != 2 || s.Folders != 1 {
+		t.Error("Unexpected state", s)
+	}
+	// the state of a watching project is the one shown by its loop
+	p.view = &view{}
+	if s := p.state(); s.Paused || s.Files != 0 {
+		t.Error("Unexpected state", s)
+	}
+	p.Watcher.Paths = []string{"/"}
+	p.show()
+	p.Watcher.Paths[0] = "app"
+	if s := p.state(); !s.Paused || s.Files != 2 || s.Folders != 1 || s.Paths[0] != "/" {
+		t.Error("Unexpected state", s)
+	}
+}
+
+func TestProject_StateRunning(t *testing.T) {
+	p := &Project{Name: "app", parent: &Realize{}, lifecycle: &lifecycle{}, records: &records{}, view: &view{}}
+	done := make(chan bool)
+	// the loop and the broker write while the control api reads
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			p.write(Message{Type: "error", Out: BufferOut{Time: time.Now(), Text: "line"}, retained: true})
+			p.notify(StatusFailure, "")
+			p.show()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		p.state()
+		p.lines(10)
+	}
+	<-done
+}
+
+func TestSubscribers(t *testing.T) {
+	s := subscribers{}
+	ch := s.add()
+	// the reports of an editor not reading are kept once by project
+	for _, v := range []string{"b", "a", "b"} {
+		s.send(v)
+	}
+	<-ch
+	if list := s.take(ch); len(list) != 2 || list[0] != "a" || list[1] != "b" {
+		t.Error("Unexpected projects", list)
+	}
+	if list := s.take(ch); len(list) != 0 {
+		t.Error("Unexpected projects", list)
+	}
+	s.remove(ch)
+	s.send("a")
+	if list := s.take(ch); len(list) != 0 {
+		t.Error("Unexpected projects", list)
+	}
+}
@@ -0,0 +1,177 @@
+package realize
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo"
+)
+
+// Coverage report of the test tool, relative to the project path
+const FileCover = "coverage.html"
+
+// Files of the coverage, the html report and its profile
+func (t *Tool) coverage() (html string, profile string) {
+	html = t.Path
+	if html == "" {
+		html = FileCover
+	}
+	if !filepath.IsAbs(html) && t.parent != nil {
+		html = filepath.Join(t.parent.Path, html)
+	}
+	html, _ = filepath.Abs(html)
+	return html, strings.TrimSuffix(html, filepath.Ext(html)) + ".out"
+}
+
+// Coverage files of the project test tool
+func (p *Project) coverage() (html string, profile string) {
+	t := p.Tools.Test
+	t.parent = p
+	return t.coverage()
+}
+
+// The profiles of the tested dirs are merged one at a time
+var coverMu sync.Mutex
+
+// Test with the coverage profile, the html report is regenerated after a successful run
+// the profile of a dir is merged in the one of the project, the other dirs keep their coverage
+func (t *Tool) cover(ctx context.Context, dir string, args []string) Response {
+	if !t.Cover {
+		return t.exec(ctx, dir, args)
+	}
+	html, profile := t.coverage()
+	// out of the project, it isn't watched
+	part, err := ioutil.TempFile("", "realize-cover")
+	if err != nil {
+		return Response{Name: t.name, Err: err}
+	}
+	part.Close()
+	defer os.Remove(part.Name())
+	response := t.exec(ctx, dir, append([]string{"-coverprofile=" + part.Name()}, args...))
+	if response.Err != nil || ctx.Err() != nil {
+		return response
+	}
+	coverMu.Lock()
+	defer coverMu.Unlock()
+	if err := mergeProfile(profile, part.Name()); err != nil {
+		response.Err = err
+		return response
+	}
+	total, err := coverReport(ctx, dir, profile, html)
+	if err != nil {
+		response.Err = err
+		return response
+	}
+	response.Out += "coverage " + total + " of statements, " + html + "\n"
+	if t.parent != nil && t.parent.parent != nil {
+		t.parent.parent.Server.Reload(coverEvent(t.parent.Name))
+	}
+	return response
+}
+
+// Merge a coverage profile in another one, the blocks of its files replace the previous ones
+func mergeProfile(profile, part string) error {
+	content, err := ioutil.ReadFile(part)
+	if err != nil {
+		return err
+	}
+	// without test files there is no profile
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if !strings.HasPrefix(lines[0], "mode:") {
+		return errors.New("no coverage in " + part)
+	}
+	files := make(map[string]bool)
+	for _, line := range lines[1:] {
+		files[profileFile(line)] = true
+	}
+	merged := []string{lines[0]}
+	// a profile of another mode is replaced
+	if previous, err := ioutil.ReadFile(profile); err == nil {
+		old := strings.Split(strings.TrimSpace(string(previous)), "\n")
+		if old[0] == lines[0] {
+			for _, line := range old[1:] {
+				if !files[profileFile(line)] {
+					merged = append(merged, line)
+				}
+			}
+		}
+	}
+	merged = append(merged, lines[1:]...)
+	return ioutil.WriteFile(profile, []byte(strings.Join(merged, "\n")+"\n"), 0644)
+}
+
+// File of a block of a coverage profile, e.g. example.com/app/lib.go:3.20,3.40 1 1
+func profileFile(line string) string {
+	if i := strings.LastIndex(line, ":"); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// Write the html report of a profile and return the total coverage
+func coverReport(ctx context.Context, dir, profile, html string) (string, error) {
+	if _, err := goTool(ctx, dir, "cover", "-html="+profile, "-o", html); err != nil {
+		return "", err
+	}
+	out, err := goTool(ctx, dir, "cover", "-func="+profile)
+	if err != nil {
+		return "", err
+	}
+	// the last line is the total, e.g. total: (statements) 75.0%
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) == 0 {
+		return "", errors.New("no coverage in " + profile)
+	}
+	return fields[len(fields)-1], nil
+}
+
+// Run a go tool in a dir and return its output
+func goTool(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "go", append([]string{"tool"}, args...)...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return "", errors.New(strings.TrimSpace(stderr.String()))
+		}
+		return "", err
+	}
+	return stdout.String(), nil
+}
+
+// Reload event of the coverage report of a project
+func coverEvent(project string) string {
+	return "cover/" + project
+}
+
+// Cover serves the coverage report of a project, refreshed after each run
+func (s *Server) cover(c echo.Context) error {
+	for _, p := range s.Parent.Schema.Projects {
+		if p.Name == c.Param("project") && p.Tools.Test.Cover {
+			html, _ := p.coverage()
+			data, err := ioutil.ReadFile(html)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusNotFound)
+			}
+			script := `<script src="/livereload/reload.js?project=` + url.QueryEscape(coverEvent(p.Name)) + `"></script>`
+			data = bytes.Replace(data, []byte("</body>"), []byte(script+"</body>"), 1)
+			return c.HTMLBlob(http.StatusOK, data)
+		}
+	}
+	return echo.NewHTTPError(http.StatusNotFound)
+}
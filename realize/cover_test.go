@@ -0,0 +1,99 @@
+package realize
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+)
+
+func TestTool_Cover(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found")
+	}
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.mod":      "module example.com/app\n",
+		"lib.go":      "package lib\n\nfunc Name() string { return \"lib\" }\n",
+		"lib_test.go": "package lib\n\nimport \"testing\"\n\nfunc TestName(t *testing.T) { Name() }\n",
+		"sub/sub.go":  "package sub\n\nfunc Sub() string { return \"sub\" }\n",
+	}
+	os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := Realize{}
+	r.Server.clients = &clients{}
+	ch := r.Server.clients.add()
+	r.Projects = append(r.Projects, Project{Name: "app", Path: dir, parent: &r})
+	p := &r.Projects[0]
+	p.Tools.Test = Tool{Status: true, Cover: true}
+	p.Tools.Setup()
+	p.Tools.bind(p)
+	response := p.Tools.Test.Exec(context.Background(), filepath.Join(dir, "lib.go"))
+	if response.Err != nil || !strings.Contains(response.Out, "coverage 100.0% of statements") {
+		t.Fatal("Unexpected response", response.Err, response.Out)
+	}
+	if _, err := os.Stat(filepath.Join(dir, FileCover)); err != nil {
+		t.Error("Expected the report", err)
+	}
+	if v := <-ch; v != "cover/app" {
+		t.Error("Unexpected reload", v)
+	}
+	// the profile of another dir is merged
+	if response = p.Tools.Test.Exec(context.Background(), filepath.Join(dir, "sub", "sub.go")); response.Err != nil || !strings.Contains(response.Out, "coverage 50.0% of statements") {
+		t.Error("Unexpected response", response.Err, response.Out)
+	}
+	<-ch
+	profile, _ := ioutil.ReadFile(filepath.Join(dir, "coverage.out"))
+	if !strings.Contains(string(profile), "example.com/app/lib.go:") || !strings.Contains(string(profile), "example.com/app/sub/sub.go:") {
+		t.Error("Unexpected profile", string(profile))
+	}
+	// the report doesn't trigger a new run
+	if p.Validate(filepath.Join(dir, FileCover), false) || p.Validate(filepath.Join(dir, "coverage.out"), false) {
+		t.Error("Unexpected coverage files watched")
+	}
+}
+
+func TestServer_Cover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "cover.html"), []byte("<html><body>report</body></html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{Name: "app", Path: dir, Tools: Tools{Test: Tool{Cover: true, Path: "cover.html"}}})
+	r.Server.Parent = &r
+	e := echo.New()
+	e.GET("/cover/:project", r.Server.cover)
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	resp, err := http.Get(ts.URL + "/cover/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `<script src="/livereload/reload.js?project=cover%2Fapp"></script></body>`) {
+		t.Error("Unexpected report", string(body))
+	}
+	if resp, err = http.Get(ts.URL + "/cover/other"); err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Error("Expected not found", err)
+	}
+}
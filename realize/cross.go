@@ -0,0 +1,76 @@
+package realize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Target of a cross build
+func target(value string) (goos string, goarch string, err error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", errors.New("invalid target " + value + ", use goos/goarch")
+	}
+	return parts[0], parts[1], nil
+}
+
+// Cross builds the targets in parallel, each one is reported once all are done and the first failure is returned
+func (p *Project) cross(ctx context.Context) (response Response) {
+	msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Green.Regular(p.Tools.Cross.name), "started")
+	out := BufferOut{Time: time.Now(), Text: p.Tools.Cross.name + " started"}
+	p.stamp("log", out, msg, "")
+	start := time.Now()
+	results := make([]Response, len(p.Tools.Cross.Targets))
+	var wg sync.WaitGroup
+	for i, value := range p.Tools.Cross.Targets {
+		wg.Add(1)
+		id := p.started(ctx, p.Tools.Cross.name+" "+value)
+		go func(i int, value string) {
+			defer wg.Done()
+			results[i] = p.Tools.Cross.target(ctx, p.Path, value)
+			results[i].ID = id
+		}(i, value)
+	}
+	wg.Wait()
+	response.Name = p.Tools.Cross.name
+	if ctx.Err() != nil {
+		return
+	}
+	failed := 0
+	for _, r := range results {
+		r.print(start, p)
+		if r.Err != nil {
+			if failed == 0 {
+				response = r
+			}
+			failed++
+		}
+	}
+	msg = fmt.Sprintln(p.pname(p.Name, 5), ":", Green.Bold(p.Tools.Cross.name), len(results)-failed, "of", len(results), "targets built")
+	out = BufferOut{Time: time.Now(), Text: fmt.Sprint(p.Tools.Cross.name, " ", len(results)-failed, " of ", len(results), " targets built")}
+	p.stamp("log", out, msg, "")
+	return
+}
+
+// Build a target in a temp dir, a package main would be written in the project otherwise
+func (t Tool) target(ctx context.Context, path string, value string) Response {
+	t.name += " " + value
+	goos, goarch, err := target(value)
+	if err != nil {
+		return Response{Name: t.name, Err: err}
+	}
+	dir, err := ioutil.TempDir("", RPrefix+"-cross")
+	if err != nil {
+		return Response{Name: t.name, Err: err}
+	}
+	defer os.RemoveAll(dir)
+	t.env = append(append([]string{}, t.env...), "GOOS="+goos, "GOARCH="+goarch)
+	return t.compile(ctx, path, append([]string{"-o", dir + string(filepath.Separator)}, t.Args...))
+}
@@ -0,0 +1,59 @@
+package realize
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTarget(t *testing.T) {
+	goos, goarch, err := target("windows/arm64")
+	if err != nil || goos != "windows" || goarch != "arm64" {
+		t.Error("Unexpected target", goos, goarch, err)
+	}
+	for _, value := range []string{"windows", "/arm64", "linux/amd64/v2"} {
+		if _, _, err := target(value); err == nil {
+			t.Error("Expected an error", value)
+		}
+	}
+}
+
+func TestTool_Target(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found")
+	}
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.mod":      "module example.com/app\n",
+		"main.go":     "package main\n\nfunc main() { run() }\n",
+		"run_unix.go": "// +build !windows\n\npackage main\n\nfunc run() {}\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tools := Tools{Cross: Tool{Status: true, Targets: []string{"linux/arm64", "windows/amd64"}}}
+	tools.Setup()
+	if r := tools.Cross.target(context.Background(), dir, "linux/arm64"); r.Err != nil || r.Name != "Cross linux/arm64" {
+		t.Error("Unexpected error", r.Name, r.Err)
+	}
+	if r := tools.Cross.target(context.Background(), dir, "windows/amd64"); r.Err == nil || !strings.Contains(r.Err.Error(), "run") {
+		t.Error("Expected an error, run is undefined on windows", r.Err)
+	}
+	if r := tools.Cross.target(context.Background(), dir, "windows"); r.Err == nil {
+		t.Error("Expected an error, invalid target")
+	}
+	// the binary isn't written in the project
+	if _, err := os.Stat(filepath.Join(dir, "app")); err == nil {
+		t.Error("Unexpected binary in the project")
+	}
+}
@@ -0,0 +1,71 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Daemon starts realize in background with the given args, its output is written
+// in the daemon log and the control api is served on the address if not set by the args
+func Daemon(args []string, address string) (int, error) {
+	bin, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(FileDaemon, os.O_CREATE|os.O_WRONLY|os.O_APPEND, Permission)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	cmd := exec.Command(bin, daemonArgs(args, address)...)
+	cmd.Stdout = f
+	cmd.Stderr = f
+	detach(cmd)
+	if err = cmd.Start(); err != nil {
+		return 0, err
+	}
+	pid := cmd.Process.Pid
+	if err = ioutil.WriteFile(FilePid, []byte(strconv.Itoa(pid)), Permission); err != nil {
+		return pid, err
+	}
+	return pid, cmd.Process.Release()
+}
+
+// Socket is the default control api address of a daemon
+func Socket() string {
+	return filepath.Join(Wdir(), FileSock)
+}
+
+// Pid of the daemon, 0 if the pidfile doesn't exist
+func Pid() int {
+	content, err := ioutil.ReadFile(FilePid)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(content)))
+	return pid
+}
+
+// Args of the daemon process, the daemon flag is removed and the control api is added
+func daemonArgs(args []string, socket string) []string {
+	var result []string
+	control := false
+	for _, arg := range args {
+		name := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+		if strings.HasPrefix(arg, "-") && (name == "daemon" || name == "d") {
+			continue
+		}
+		if strings.HasPrefix(arg, "-") && name == "control" {
+			control = true
+		}
+		result = append(result, arg)
+	}
+	if !control {
+		result = append(result, "--control", socket)
+	}
+	return result
+}
@@ -0,0 +1,17 @@
+package realize
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDaemonArgs(t *testing.T) {
+	args := daemonArgs([]string{"start", "--daemon", "--run"}, "/tmp/r.sock")
+	if !reflect.DeepEqual(args, []string{"start", "--run", "--control", "/tmp/r.sock"}) {
+		t.Error("Unexpected args", args)
+	}
+	args = daemonArgs([]string{"start", "-d", "--daemon=true", "--control=:5003"}, "/tmp/r.sock")
+	if !reflect.DeepEqual(args, []string{"start", "--control=:5003"}) {
+		t.Error("Unexpected args", args)
+	}
+}
@@ -0,0 +1,13 @@
+// +build !windows
+
+package realize
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Detach the command from the terminal session
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
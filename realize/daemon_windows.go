@@ -0,0 +1,13 @@
+// +build windows
+
+package realize
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// Detach the command from the console
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: 0x00000008 | syscall.CREATE_NEW_PROCESS_GROUP}
+}
@@ -0,0 +1,83 @@
+package realize
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Debounce modes, leading reloads on the first change and suppresses the followers in the same second,
+// trailing waits for a quiet period and reloads once with the batched changes
+const (
+	DebounceLeading  = "leading"
+	DebounceTrailing = "trailing"
+)
+
+// Quiet period of the trailing debounce by default
+const quiet = 500 * time.Millisecond
+
+// Key of the batched files in the context of a reload
+type batchKey struct{}
+
+// Trailing reports if the changes are batched, unknown modes are leading
+func (w *Watch) trailing() bool {
+	return strings.ToLower(strings.TrimSpace(w.DebounceMode)) == DebounceTrailing
+}
+
+// Quiet period of the trailing debounce
+func (w *Watch) quiet() time.Duration {
+	if w.Debounce > 0 {
+		return w.Debounce
+	}
+	return quiet
+}
+
+// Debounce checks the mode
+func debounce(mode string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", DebounceLeading, DebounceTrailing:
+		return nil
+	}
+	return errors.New("unknown debounce mode " + mode + ", use leading or trailing")
+}
+
+// Settle restarts once with the files changed in the quiet period, the last one is the path of the reload
+func (p *Project) settle(paths []string, last fsnotify.Event) {
+	path := ""
+	if len(paths) > 0 {
+		path = paths[len(paths)-1]
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.reload(triggered(context.WithValue(ctx, batchKey{}, paths), last), cancel, path, false)
+}
+
+// Batch of the files of a reload, its path without a trailing debounce
+func batch(ctx context.Context, path string) []string {
+	if paths, ok := ctx.Value(batchKey{}).([]string); ok {
+		return paths
+	}
+	if path == "" {
+		return nil
+	}
+	return []string{path}
+}
+
+// Pending files of a trailing debounce, in order of change without duplicates
+type pending []string
+
+// Add a changed file, the removed ones have no path
+func (b pending) add(path string) pending {
+	if path == "" {
+		return b
+	}
+	for i, v := range b {
+		if v == path {
+			// moved to the end, the last changed file is the path of the reload
+			return append(append(b[:i:i], b[i+1:]...), path)
+		}
+	}
+	return append(b, path)
+}
@@ -0,0 +1,49 @@
+package realize
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDebounce(t *testing.T) {
+	for _, mode := range []string{"", "leading", " Trailing"} {
+		if err := debounce(mode); err != nil {
+			t.Error("Unexpected error", mode, err)
+		}
+	}
+	if err := debounce("both"); err == nil {
+		t.Error("Expected an error")
+	}
+	w := Watch{DebounceMode: "Trailing"}
+	if !w.trailing() || w.quiet() != quiet {
+		t.Error("Unexpected trailing debounce", w.trailing(), w.quiet())
+	}
+	w = Watch{Debounce: time.Second}
+	if w.trailing() || w.quiet() != time.Second {
+		t.Error("Unexpected leading debounce", w.trailing(), w.quiet())
+	}
+}
+
+func TestPending(t *testing.T) {
+	var b pending
+	b = b.add("a.go").add("").add("b.go").add("a.go")
+	if !reflect.DeepEqual(b, pending{"b.go", "a.go"}) {
+		t.Error("Unexpected pending files", b)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	ctx := context.Background()
+	if paths := batch(ctx, ""); len(paths) != 0 {
+		t.Error("Unexpected batch", paths)
+	}
+	if paths := batch(ctx, "a.go"); !reflect.DeepEqual(paths, []string{"a.go"}) {
+		t.Error("Unexpected batch", paths)
+	}
+	ctx = context.WithValue(ctx, batchKey{}, []string{"b.go", "a.go"})
+	if paths := batch(ctx, "a.go"); !reflect.DeepEqual(paths, []string{"b.go", "a.go"}) {
+		t.Error("Unexpected batch", paths)
+	}
+}
@@ -0,0 +1,109 @@
+package realize
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Diagnostics severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Diagnostic is a positioned message of the go compiler, vet or test
+type Diagnostic struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column,omitempty"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+// File position and message, file.go:line:col: message
+var position = regexp.MustCompile(`^\s*(\S+\.go):(\d+)(?::(\d+))?: (.+)$`)
+
+// Parse the diagnostics of an output, relative files are joined to base
+func parseDiagnostics(text, base, severity string) (list []Diagnostic) {
+	for _, line := range strings.Split(text, "\n") {
+		m := position.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if m == nil {
+			continue
+		}
+		d := Diagnostic{File: m[1], Message: m[4], Severity: severity}
+		d.Line, _ = strconv.Atoi(m[2])
+		d.Column, _ = strconv.Atoi(m[3])
+		if !filepath.IsAbs(d.File) && base != "" {
+			d.File = filepath.Join(base, d.File)
+		}
+		list = append(list, d)
+	}
+	return
+}
+
+// Position of the diagnostic, file:line:col with the file relative to base
+func (d Diagnostic) position(base string) string {
+	pos := d.File
+	if rel, err := filepath.Rel(base, d.File); err == nil && !strings.HasPrefix(rel, "..") {
+		pos = rel
+	}
+	pos += ":" + strconv.Itoa(d.Line)
+	if d.Column > 0 {
+		pos += ":" + strconv.Itoa(d.Column)
+	}
+	return pos
+}
+
+// Link the position to the file, terminals without hyperlinks print only the text
+func (d Diagnostic) link(text string) string {
+	return "\x1b]8;;file://" + filepath.ToSlash(d.File) + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// Diagnose parses the errors of a response, they are listed at the end of the run
+func (p *Project) diagnose(r *Response) {
+	if r.Err == nil {
+		return
+	}
+	severity := SeverityError
+	if strings.EqualFold(r.Name, "vet") || strings.EqualFold(r.Name, "lint") {
+		severity = SeverityWarning
+	}
+	base, _ := filepath.Abs(p.Path)
+	r.Diagnostics = parseDiagnostics(r.Err.Error(), base, severity)
+	p.problems = append(p.problems, r.Diagnostics...)
+}
+
+// Describe the diagnostics, a line for each one with its position relative to the project
+func (p *Project) describe(list []Diagnostic) []string {
+	base, _ := filepath.Abs(p.Path)
+	lines := make([]string, 0, len(list))
+	for _, d := range list {
+		pos := d.position(base)
+		if p.parent.Settings.Hyperlinks {
+			pos = d.link(pos)
+		}
+		severity := Red.Regular(d.Severity)
+		if d.Severity == SeverityWarning {
+			severity = Yellow.Regular(d.Severity)
+		}
+		lines = append(lines, fmt.Sprint("  ", Magenta.Regular(pos), " ", severity, " ", d.Message))
+	}
+	return lines
+}
+
+// Summarize prints the diagnostics of the last run
+func (p *Project) summarize() {
+	if len(p.problems) == 0 {
+		return
+	}
+	lines := p.describe(p.problems)
+	text := fmt.Sprint(len(p.problems), " problems")
+	msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold(text)) + strings.Join(lines, "\n") + "\n"
+	out := BufferOut{Time: time.Now(), Text: text, Type: "diagnostics", Stream: strings.Join(lines, "\n")}
+	p.stamp("error", out, msg, "")
+	p.problems = nil
+}
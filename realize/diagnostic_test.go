@@ -0,0 +1,51 @@
+package realize
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	base := filepath.Join(string(filepath.Separator), "src", "app")
+	text := "# app\n./main.go:12:5: undefined: foo\nmain.go:20: missing return\n--- FAIL: TestA (0.00s)\n    a_test.go:8: expected 1\nFAIL\n"
+	list := parseDiagnostics(text, base, SeverityError)
+	if len(list) != 3 {
+		t.Fatal("Expected 3 diagnostics instead", list)
+	}
+	d := list[0]
+	if d.File != filepath.Join(base, "main.go") || d.Line != 12 || d.Column != 5 || d.Message != "undefined: foo" || d.Severity != SeverityError {
+		t.Error("Unexpected diagnostic", d)
+	}
+	if list[1].Column != 0 || list[2].File != filepath.Join(base, "a_test.go") || list[2].Message != "expected 1" {
+		t.Error("Unexpected diagnostics", list)
+	}
+	if pos := d.position(base); pos != "main.go:12:5" {
+		t.Error("Unexpected position", pos)
+	}
+	if link := d.link("main.go"); !strings.Contains(link, "file://") || !strings.Contains(link, "main.go\x1b]8;;") {
+		t.Error("Unexpected link", link)
+	}
+}
+
+func TestProject_Diagnose(t *testing.T) {
+	r := Realize{}
+	p := Project{parent: &r, Name: "test", Path: "."}
+	res := Response{Name: "Vet", Err: errors.New("./main.go:3:2: unreachable code")}
+	p.diagnose(&res)
+	if len(res.Diagnostics) != 1 || res.Diagnostics[0].Severity != SeverityWarning {
+		t.Error("Expected a warning", res.Diagnostics)
+	}
+	if len(p.problems) != 1 {
+		t.Error("Expected a problem", p.problems)
+	}
+	if task := newTaskResult(res, time.Time{}); len(task.Diagnostics) != 1 {
+		t.Error("Expected diagnostics in the task result")
+	}
+	p.summarize()
+	if len(p.problems) != 0 || len(p.Buffer.StdErr) != 1 {
+		t.Error("Expected the problems printed", p.Buffer.StdErr)
+	}
+}
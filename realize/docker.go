@@ -0,0 +1,169 @@
+package realize
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TaskDocker is the label of the container output
+const TaskDocker = "docker"
+
+// Docker rebuilds an image or restarts a compose service in place of the go build and run
+type Docker struct {
+	Service    string   `yaml:"compose_service,omitempty" json:"compose_service,omitempty"`
+	Compose    string   `yaml:"compose_file,omitempty" json:"compose_file,omitempty"`
+	Image      string   `yaml:"image,omitempty" json:"image,omitempty"`
+	Dockerfile string   `yaml:"dockerfile,omitempty" json:"dockerfile,omitempty"`
+	Container  string   `yaml:"container,omitempty" json:"container,omitempty"`
+	Args       []string `yaml:"args,omitempty" json:"args,omitempty"`
+}
+
+// Characters not allowed in a container name
+var unsafeName = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// Compose command with the optional compose file
+func (d *Docker) compose(args ...string) []string {
+	cmd := []string{"docker", "compose"}
+	if d.Compose != "" {
+		cmd = append(cmd, "-f", d.Compose)
+	}
+	return append(cmd, args...)
+}
+
+// Name of the container started from the image
+func (d *Docker) name(p *Project) string {
+	if d.Container != "" {
+		return d.Container
+	}
+	return "realize-" + strings.Trim(unsafeName.ReplaceAllString(strings.ToLower(p.Name), "-"), "-")
+}
+
+// Build the image, compose services are built by up
+func (d *Docker) build() []string {
+	if d.Service != "" {
+		return nil
+	}
+	cmd := []string{"docker", "build", "-t", d.Image}
+	if d.Dockerfile != "" {
+		cmd = append(cmd, "-f", d.Dockerfile)
+	}
+	return append(cmd, ".")
+}
+
+// Start a new container or recreate the compose service
+func (d *Docker) start(p *Project) []string {
+	if d.Service != "" {
+		return d.compose("up", "-d", "--build", "--force-recreate", d.Service)
+	}
+	cmd := append([]string{"docker", "run", "-d", "--name", d.name(p)}, d.Args...)
+	return append(cmd, d.Image)
+}
+
+// Stop the compose service or remove the container
+func (d *Docker) stop(p *Project) []string {
+	if d.Service != "" {
+		return d.compose("stop", d.Service)
+	}
+	return []string{"docker", "rm", "-f", d.name(p)}
+}
+
+// Follow the logs written after since
+func (d *Docker) logs(p *Project, since time.Time) []string {
+	if d.Service != "" {
+		return d.compose("logs", "-f", "--no-log-prefix", "--since", since.Format(time.RFC3339), d.Service)
+	}
+	return []string{"docker", "logs", "-f", d.name(p)}
+}
+
+// Validate the docker task, a compose service or an image is required
+func (d *Docker) validate() error {
+	if d.Service == "" && d.Image == "" {
+		return errors.New("Docker requires a compose_service or an image")
+	}
+	return nil
+}
+
+// Docker rebuilds and restarts the container, its logs are followed until the next change
+func (p *Project) docker(ctx context.Context) (r Response) {
+	d := p.Docker
+	r.Name = "Docker"
+	if r.Err = d.validate(); r.Err != nil {
+		return
+	}
+	msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Green.Regular(r.Name), "started")
+	out := BufferOut{Time: time.Now(), Text: r.Name + " started"}
+	p.stamp("log", out, msg, "")
+	id := p.started(ctx, r.Name)
+	start := time.Now()
+	defer func() {
+		r.Name, r.ID = "Docker", id
+		if ctx.Err() == nil {
+			r.print(start, p)
+		}
+	}()
+	if cmd := d.build(); cmd != nil {
+		if r = execute(ctx, p.Path, cmd); r.Err != nil {
+			return
+		}
+	}
+	// previous logs and container
+	p.kill()
+	if d.Service == "" {
+		execute(ctx, p.Path, d.stop(p))
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	r = execute(ctx, p.Path, d.start(p))
+	if r.Err == nil && !p.parent.Once {
+		p.transition(StateRunning)
+		p.follow(d.logs(p, start))
+	}
+	return
+}
+
+// Follow the container logs through the project sinks, stopped by kill
+func (p *Project) follow(args []string) {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &managed{cancel: cancel, done: make(chan bool)}
+	if prev := p.handover(m); prev != nil {
+		prev.stop()
+	}
+	go func() {
+		defer close(m.done)
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Dir = p.Path
+		r, w, err := os.Pipe()
+		if err != nil {
+			p.Err(err)
+			return
+		}
+		defer r.Close()
+		cmd.Stdout, cmd.Stderr = w, w
+		err = cmd.Start()
+		w.Close()
+		if err != nil {
+			p.Err(err)
+			return
+		}
+		go cmd.Wait()
+		// children of the command may keep the pipe open
+		go func() {
+			<-ctx.Done()
+			r.Close()
+		}()
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			msg := fmt.Sprintln(p.prefix(TaskDocker), ":", scanner.Text())
+			out := BufferOut{Time: time.Now(), Text: scanner.Text(), Type: "Docker"}
+			p.stamp("out", out, msg, "")
+		}
+	}()
+}
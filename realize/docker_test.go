@@ -0,0 +1,47 @@
+package realize
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDocker_Compose(t *testing.T) {
+	p := Project{Name: "api"}
+	d := Docker{Service: "api", Compose: "dev.yml"}
+	if d.build() != nil {
+		t.Error("Unexpected image build")
+	}
+	if cmd := d.start(&p); !reflect.DeepEqual(cmd, []string{"docker", "compose", "-f", "dev.yml", "up", "-d", "--build", "--force-recreate", "api"}) {
+		t.Error("Unexpected command", cmd)
+	}
+	if cmd := d.stop(&p); !reflect.DeepEqual(cmd, []string{"docker", "compose", "-f", "dev.yml", "stop", "api"}) {
+		t.Error("Unexpected command", cmd)
+	}
+	since := time.Date(2018, 3, 10, 10, 0, 0, 0, time.UTC)
+	if cmd := strings.Join(d.logs(&p, since), " "); cmd != "docker compose -f dev.yml logs -f --no-log-prefix --since 2018-03-10T10:00:00Z api" {
+		t.Error("Unexpected command", cmd)
+	}
+}
+
+func TestDocker_Image(t *testing.T) {
+	p := Project{Name: "My App"}
+	d := Docker{Image: "app:dev", Dockerfile: "dev.Dockerfile", Args: []string{"-p", "8080:8080"}}
+	if cmd := d.build(); !reflect.DeepEqual(cmd, []string{"docker", "build", "-t", "app:dev", "-f", "dev.Dockerfile", "."}) {
+		t.Error("Unexpected command", cmd)
+	}
+	if cmd := d.start(&p); !reflect.DeepEqual(cmd, []string{"docker", "run", "-d", "--name", "realize-my-app", "-p", "8080:8080", "app:dev"}) {
+		t.Error("Unexpected command", cmd)
+	}
+	if cmd := d.stop(&p); !reflect.DeepEqual(cmd, []string{"docker", "rm", "-f", "realize-my-app"}) {
+		t.Error("Unexpected command", cmd)
+	}
+	d.Container = "app"
+	if cmd := d.logs(&p, time.Now()); !reflect.DeepEqual(cmd, []string{"docker", "logs", "-f", "app"}) {
+		t.Error("Unexpected command", cmd)
+	}
+	if err := (&Docker{}).validate(); err == nil {
+		t.Error("Expected an error")
+	}
+}
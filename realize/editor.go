@@ -0,0 +1,217 @@
+package realize
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// Problems are the diagnostics of the last completed run of a project by absolute file.
+// The files of the previous report without diagnostics are listed empty, the editors clear them.
+type Problems struct {
+	Project string                  `json:"project"`
+	Run     string                  `json:"run"`
+	Status  string                  `json:"status"`
+	Time    time.Time               `json:"time"`
+	Files   map[string][]Diagnostic `json:"files"`
+}
+
+// Reports of the projects published to the editors
+type reports struct {
+	mu      sync.Mutex
+	last    map[string]Problems
+	editors subscribers
+}
+
+// Subscribers are the editors connected, each with the projects published since its last read.
+// The reports are coalesced by project, a slow editor streams the last one of each project.
+type subscribers struct {
+	mu   sync.Mutex
+	list map[chan struct{}]map[string]bool
+}
+
+// Add an editor, its channel wakes it on a new report
+func (s *subscribers) add() chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.list == nil {
+		s.list = make(map[chan struct{}]map[string]bool)
+	}
+	ch := make(chan struct{}, 1)
+	s.list[ch] = make(map[string]bool)
+	return ch
+}
+
+// Remove an editor
+func (s *subscribers) remove(ch chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.list, ch)
+}
+
+// Send a project to all the editors, it's pending until read
+func (s *subscribers) send(project string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch, pending := range s.list {
+		pending[project] = true
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Take the projects pending for an editor, sorted
+func (s *subscribers) take(ch chan struct{}) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []string
+	for project := range s.list[ch] {
+		list = append(list, project)
+		delete(s.list[ch], project)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Publish the problems of a run, the editors connected receive them
+func (c *control) publish(pr Problems) {
+	if c == nil {
+		return
+	}
+	c.reports.mu.Lock()
+	if c.reports.last == nil {
+		c.reports.last = make(map[string]Problems)
+	}
+	for file, list := range c.reports.last[pr.Project].Files {
+		if _, ok := pr.Files[file]; !ok && len(list) > 0 {
+			pr.Files[file] = []Diagnostic{}
+		}
+	}
+	c.reports.last[pr.Project] = pr
+	c.reports.mu.Unlock()
+	c.reports.editors.send(pr.Project)
+}
+
+// Problems of a project, empty before its first run
+func (c *control) problems(name string) Problems {
+	c.reports.mu.Lock()
+	defer c.reports.mu.Unlock()
+	if pr, ok := c.reports.last[name]; ok {
+		return pr
+	}
+	return Problems{Project: name, Files: map[string][]Diagnostic{}}
+}
+
+// Diagnosed publishes the diagnostics of a completed run to the editors
+func (p *Project) diagnosed(run string) {
+	pr := Problems{Project: p.Name, Run: run, Status: p.outcome, Time: time.Now(), Files: make(map[string][]Diagnostic)}
+	for _, d := range p.problems {
+		pr.Files[d.File] = append(pr.Files[d.File], d)
+	}
+	p.parent.control.publish(pr)
+}
+
+// Diagnostics returns the problems of the last completed run of a project
+func (c *control) diagnostics(ctx echo.Context) error {
+	p, err := c.project(ctx.Param("name"))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, c.problems(p.Name))
+}
+
+// Editor streams the problems of the projects as server sent events, the current ones first, optionally of a project only
+func (c *control) editor(ctx echo.Context) error {
+	ch := c.reports.editors.add()
+	defer c.reports.editors.remove(ch)
+	filter := ctx.QueryParam("project")
+	rs := ctx.Response()
+	rs.Header().Set(echo.HeaderContentType, "text/event-stream")
+	rs.Header().Set("Cache-Control", "no-cache")
+	rs.WriteHeader(http.StatusOK)
+	send := func(name string) error {
+		content, err := json.Marshal(c.problems(name))
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(rs, "event: diagnostics\ndata: %s\n\n", content); err != nil {
+			return err
+		}
+		rs.Flush()
+		return nil
+	}
+	for _, p := range c.parent.Schema.Projects {
+		if filter == "" || strings.EqualFold(filter, p.Name) {
+			if err := send(p.Name); err != nil {
+				return nil
+			}
+		}
+	}
+	rs.Flush()
+	for {
+		select {
+		case <-ctx.Request().Context().Done():
+			return nil
+		case <-ch:
+			for _, name := range c.reports.editors.take(ch) {
+				if filter != "" && !strings.EqualFold(filter, name) {
+					continue
+				}
+				if err := send(name); err != nil {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// Owners of a file, the projects with the deepest path containing it
+func (c *control) owners(file string) []*Project {
+	var list []*Project
+	depth := -1
+	for k := range c.parent.Schema.Projects {
+		p := &c.parent.Schema.Projects[k]
+		rel, err := filepath.Rel(p.abs(""), file)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		switch n := len(p.abs("")); {
+		case n > depth:
+			list, depth = []*Project{p}, n
+		case n == depth:
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// Restart the projects of a file, an editor knows the file saved and not the project
+func (c *control) restartFile(ctx echo.Context) error {
+	file := ctx.QueryParam("file")
+	if file == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, "missing file")
+	}
+	file, _ = filepath.Abs(file)
+	owners := c.owners(file)
+	if len(owners) == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "no project of "+file)
+	}
+	list := []ProjectState{}
+	for _, p := range owners {
+		if err := p.send(ActionRestart); err != nil {
+			return echo.NewHTTPError(http.StatusConflict, err.Error())
+		}
+		list = append(list, p.state())
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return ctx.JSON(http.StatusAccepted, list)
+}
@@ -0,0 +1,110 @@
+package realize
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// Stream of the task results of a project, sends don't block after close
+type stream struct {
+	mu     sync.Mutex
+	ch     chan Response
+	done   chan bool
+	closed bool
+}
+
+// NewProject returns a project that runs the go package of a path and watches its go files
+func NewProject(name, path string) Project {
+	return Project{
+		Name:  name,
+		Path:  path,
+		Tools: Tools{Run: Tool{Status: true}},
+		Watcher: Watch{
+			Paths:  []string{"/"},
+			Ignore: []string{".git", ".realize", "vendor"},
+			Exts:   []string{"go"},
+		},
+	}
+}
+
+// Start watches the project until the context is done, or runs it once if its parent runs once.
+// The results of the tasks are sent on the returned channel, it must be drained and it's closed on stop.
+// A project without parent gets its own realize with the default settings
+func (p *Project) Start(ctx context.Context) <-chan Response {
+	if p.parent == nil {
+		p.parent = &Realize{}
+	}
+	s := &stream{ch: make(chan Response, 16), done: make(chan bool)}
+	p.results = s
+	p.exit = make(chan os.Signal, 1)
+	if !p.parent.Once {
+		p.control = make(chan string, 1)
+		p.changes = make(chan pathChange, 1)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	finished := make(chan bool)
+	go func() {
+		if p.parent.Once {
+			p.Once(&wg)
+		} else {
+			p.Watch(&wg)
+		}
+		close(finished)
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.Stop()
+			<-finished
+		case <-finished:
+		}
+		s.close()
+	}()
+	return s.ch
+}
+
+// Stop the project, its after commands are run before exit
+func (p *Project) Stop() {
+	exit := p.exit
+	if exit == nil {
+		return
+	}
+	signal.Stop(exit)
+	// already stopped
+	select {
+	case _, ok := <-exit:
+		if !ok {
+			return
+		}
+	default:
+	}
+	close(exit)
+}
+
+// Send a result, dropped after close
+func (s *stream) send(r Response) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.ch <- r:
+	case <-s.done:
+	}
+}
+
+// Close the stream
+func (s *stream) close() {
+	close(s.done)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	close(s.ch)
+}
@@ -0,0 +1,59 @@
+package realize
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProject_Start(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found")
+	}
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644)
+	p := NewProject("test", dir)
+	p.Tools.Run.Status = false
+	p.Tools.Build.Status = true
+	p.parent = &Realize{Once: true}
+	var results []Response
+	for r := range p.Start(context.Background()) {
+		results = append(results, r)
+	}
+	if len(results) != 1 || results[0].Name != "Build" || results[0].Err != nil {
+		t.Error("Unexpected results", results)
+	}
+}
+
+func TestProject_StartCancel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := NewProject("test", dir)
+	p.Tools.Run.Status = false
+	ctx, cancel := context.WithCancel(context.Background())
+	results := p.Start(ctx)
+	done := make(chan bool)
+	go func() {
+		for range results {
+		}
+		close(done)
+	}()
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("Expected a closed channel")
+	}
+}
@@ -0,0 +1,90 @@
+package realize
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ParseEnv reads the KEY=VALUE lines of an env file, comments and export are allowed
+func parseEnv(r io.Reader) (map[string]string, error) {
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.Index(line, "=")
+		if i <= 0 {
+			continue
+		}
+		key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		switch {
+		case len(value) > 1 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0]:
+			if value[0] == '"' {
+				value = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+			} else {
+				value = value[1 : len(value)-1]
+			}
+		case strings.Contains(value, " #"):
+			value = strings.TrimSpace(value[:strings.Index(value, " #")])
+		}
+		env[key] = value
+	}
+	return env, scanner.Err()
+}
+
+// FilePath is the absolute path of a file, relative to the project
+func (p *Project) filePath(file string) string {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(p.Path, file)
+	}
+	abs, _ := filepath.Abs(file)
+	return abs
+}
+
+// EnvFile checks if a path is one of the env files
+func (p *Project) envFile(path string) bool {
+	if path == "" {
+		return false
+	}
+	abs, _ := filepath.Abs(path)
+	for _, file := range p.EnvFiles {
+		if p.filePath(file) == abs {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadEnv reads the env files, the later ones override the former
+func (p *Project) loadEnv() {
+	env := make(map[string]string)
+	for _, file := range p.EnvFiles {
+		f, err := os.Open(p.filePath(file))
+		if err != nil {
+			p.Err(err)
+			continue
+		}
+		values, err := parseEnv(f)
+		f.Close()
+		if err != nil {
+			p.Err(err)
+		}
+		for k, v := range values {
+			env[k] = v
+		}
+	}
+	p.fileEnv = env
+}
+
+// WatchEnv adds the env files to the watcher
+func (p *Project) watchEnv() {
+	for _, file := range p.EnvFiles {
+		p.watchFile(p.filePath(file))
+	}
+}
@@ -0,0 +1,56 @@
+package realize
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParseEnv(t *testing.T) {
+	env, err := parseEnv(strings.NewReader("# comment\nA=1\nexport B = two\nC=\"a \\\"b\\\"\"\nD='x # y'\nE=z # note\ninvalid\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"A": "1", "B": "two", "C": `a "b"`, "D": "x # y", "E": "z"}
+	if len(env) != len(expected) {
+		t.Error("Unexpected env", env)
+	}
+	for k, v := range expected {
+		if env[k] != v {
+			t.Error("Unexpected value", k, env[k], v)
+		}
+	}
+}
+
+func TestProject_LoadEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, ".env"), []byte("A=1\nB=1\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, ".env.local"), []byte("B=2\nC=2\n"), 0644)
+	r := Realize{}
+	p := Project{parent: &r, Path: dir, EnvFiles: []string{".env", ".env.local"}, Env: map[string]string{"C": "3"}}
+	p.loadEnv()
+	envs := p.buildEnvs()
+	sort.Strings(envs)
+	if strings.Join(envs, " ") != "A=1 B=2 C=3" {
+		t.Error("Unexpected env", envs)
+	}
+	if !p.envFile(filepath.Join(dir, ".env.local")) || p.envFile(filepath.Join(dir, "main.go")) {
+		t.Error("Unexpected env file check")
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	c := Command{Cmd: "sh -c 'echo $A$B'", parent: &p}
+	if res := c.exec(context.Background(), dir); res.Out != "12\n" {
+		t.Error("Unexpected output", res.Out, res.Err)
+	}
+}
@@ -0,0 +1,116 @@
+package realize
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lifecycle events of a project
+const (
+	EventIndexed  = "indexed"
+	EventChanged  = "changed"
+	EventStarted  = "started"
+	EventOutput   = "output"
+	EventFinished = "finished"
+	EventState    = "state"
+)
+
+// Streams of an output line
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// Event is a lifecycle event of a project, written as a json line
+type Event struct {
+	Time     time.Time `json:"time"`
+	Event    string    `json:"event"`
+	Project  string    `json:"project"`
+	Task     string    `json:"task,omitempty"`
+	Run      string    `json:"run,omitempty"`
+	ID       string    `json:"id,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Op       string    `json:"op,omitempty"`
+	Files    int64     `json:"files,omitempty"`
+	Folders  int64     `json:"folders,omitempty"`
+	Stream   string    `json:"stream,omitempty"`
+	Line     string    `json:"line,omitempty"`
+	Status   string    `json:"status,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Code     *int      `json:"code,omitempty"`
+	Duration *float64  `json:"duration,omitempty"`
+}
+
+// Events writes the events of all the projects, one json object per line
+type events struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// EventStream writes the lifecycle events of the projects on a writer as json lines
+func (r *Realize) EventStream(w io.Writer) {
+	r.events = &events{enc: json.NewEncoder(w)}
+}
+
+// Write an event, the encoder ends it with a newline
+func (e *events) write(event Event) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.enc.Encode(event)
+}
+
+// Emit an event of the project if the event stream is enabled
+func (p *Project) emit(e Event) {
+	if p.parent == nil || p.parent.events == nil {
+		return
+	}
+	e.Time, e.Project = time.Now(), p.Name
+	if e.Run == "" {
+		e.Run = runOf(e.ID)
+	}
+	p.parent.events.write(e)
+}
+
+// Started task, its id correlates the started and the finished events
+func (p *Project) started(ctx context.Context, task string) string {
+	id := taskID(ctx)
+	p.emit(Event{Event: EventStarted, Task: task, Run: runID(ctx), ID: id})
+	return id
+}
+
+// Finished task with its captured output, duration and exit code
+func (p *Project) finished(r Response, start time.Time) {
+	if p.parent == nil || p.parent.events == nil {
+		return
+	}
+	for _, line := range lines(r.Out) {
+		p.emit(Event{Event: EventOutput, Task: r.Name, ID: r.ID, Stream: StreamStdout, Line: line})
+	}
+	t := newTaskResult(r, start)
+	e := Event{Event: EventFinished, Task: r.Name, ID: r.ID, Status: t.Status, Error: t.Error, Code: &r.Code, Duration: &t.Duration}
+	if r.Err != nil && r.Code == 0 {
+		// failed without an exit code, e.g. timed out
+		code := 1
+		e.Code = &code
+	}
+	p.emit(e)
+}
+
+// Canceled task, stopped by a reload or by the exit, without an exit code
+func (p *Project) canceled(id, task string, start time.Time) {
+	duration := time.Since(start).Seconds()
+	p.emit(Event{Event: EventFinished, Task: task, ID: id, Status: StatusCanceled, Duration: &duration})
+}
+
+// Lines of an output, without the trailing empty one
+func lines(out string) []string {
+	out = strings.TrimRight(out, "\r\n")
+	if out == "" {
+		return nil
+	}
+	return strings.Split(strings.Replace(out, "\r\n", "\n", -1), "\n")
+}
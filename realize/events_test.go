@@ -0,0 +1,81 @@
+package realize
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func decode(t *testing.T, buf *bytes.Buffer) (events []Event) {
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal("Unexpected line", scanner.Text(), err)
+		}
+		events = append(events, e)
+	}
+	return
+}
+
+func TestProject_Emit(t *testing.T) {
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+	var buf bytes.Buffer
+	r := Realize{Sync: make(chan string, 10)}
+	r.EventStream(&buf)
+	r.Projects = append(r.Projects, Project{Name: "app", parent: &r})
+	p := &r.Projects[0]
+	p.Change(fsnotify.Event{Name: "main.go", Op: fsnotify.Write})
+	ctx := identified(context.Background())
+	id := p.started(ctx, "Build")
+	p.record(Response{Name: "Build", Out: "line 1\nline 2\n", Err: errors.New("exit status 2"), Code: 2, ID: id}, time.Now())
+	p.canceled(p.started(ctx, TaskRun), TaskRun, time.Now())
+	events := decode(t, &buf)
+	if len(events) != 7 {
+		t.Fatal("Unexpected events", events)
+	}
+	if e := events[0]; e.Event != EventChanged || e.Project != "app" || e.Path != "main.go" || e.Op != "WRITE" {
+		t.Error("Unexpected change", e)
+	}
+	if e := events[1]; e.Event != EventStarted || e.Task != "Build" || e.ID != runID(ctx)+".1" || e.Run != runID(ctx) {
+		t.Error("Unexpected start", e)
+	}
+	if e := events[3]; e.Event != EventOutput || e.Line != "line 2" || e.Stream != StreamStdout || e.ID != id {
+		t.Error("Unexpected output", e)
+	}
+	if e := events[4]; e.Event != EventFinished || e.Status != StatusFailure || e.Code == nil || *e.Code != 2 || e.Duration == nil || e.ID != id || e.Run != runID(ctx) {
+		t.Error("Unexpected finish", e)
+	}
+	// the started and the finished events of a task share its id
+	if e := events[6]; e.Status != StatusCanceled || e.Code != nil || e.ID != events[5].ID || e.ID != runID(ctx)+".2" {
+		t.Error("Unexpected cancel", e)
+	}
+}
+
+func TestProject_EmitCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("No true on Windows")
+	}
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+	var buf bytes.Buffer
+	r := Realize{Sync: make(chan string, 10)}
+	r.EventStream(&buf)
+	r.Projects = append(r.Projects, Project{Name: "app", parent: &r, Path: os.TempDir(), Watcher: Watch{Scripts: []Command{{Type: "before", Cmd: "true"}}}})
+	r.Projects[0].cmd(context.Background(), "before", false)
+	events := decode(t, &buf)
+	if len(events) != 2 || events[0].Event != EventStarted || events[0].Task != "true" || events[1].Event != EventFinished || *events[1].Code != 0 || events[0].ID == "" || events[1].ID != events[0].ID {
+		t.Error("Unexpected events", events)
+	}
+}
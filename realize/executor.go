@@ -0,0 +1,49 @@
+package realize
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// TaskExecutor runs the scripts of a custom kind instead of a command, a kubernetes rollout or a lambda deploy.
+// Match reports if it runs a script, Run runs it with the file event of the run, empty without one.
+// The script runs in order with the others, its error fails it as a command would
+type TaskExecutor interface {
+	Match(c Command) bool
+	Run(ctx context.Context, c Command, event fsnotify.Event) error
+}
+
+// AddExecutor registers an executor of custom scripts, the first one matching a script runs it
+func (r *Realize) AddExecutor(e TaskExecutor) {
+	r.executors = append(r.executors, e)
+}
+
+// Executor of a script, nil for a command
+func (p *Project) executor(c *Command) TaskExecutor {
+	if p == nil || p.parent == nil {
+		return nil
+	}
+	for _, e := range p.parent.executors {
+		if e.Match(*c) {
+			return e
+		}
+	}
+	return nil
+}
+
+// Execute a script by its executor, a kind without executor is an error
+func (c *Command) execute(ctx context.Context, e TaskExecutor) (response Response) {
+	response.Name = c.line()
+	if e == nil {
+		response.Err = errors.New("no executor of kind " + c.Kind)
+		return
+	}
+	event, _ := ctx.Value(triggerKey{}).(fsnotify.Event)
+	if err := e.Run(ctx, *c, event); err != nil {
+		response.Err = err
+		response.Code = 1
+	}
+	return
+}
@@ -0,0 +1,57 @@
+package realize
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Executor of the rollout scripts
+type rollout struct {
+	runs  []Command
+	event fsnotify.Event
+	err   error
+}
+
+func (r *rollout) Match(c Command) bool {
+	return c.Kind == "rollout"
+}
+
+func (r *rollout) Run(ctx context.Context, c Command, event fsnotify.Event) error {
+	r.runs = append(r.runs, c)
+	r.event = event
+	return r.err
+}
+
+func TestCommand_Executor(t *testing.T) {
+	e := &rollout{}
+	r := Realize{}
+	r.AddExecutor(e)
+	p := &Project{parent: &r}
+	c := Command{Kind: "rollout", With: map[string]string{"deployment": "api"}, parent: p}
+	if label := c.label(); label != "rollout" {
+		t.Error("Unexpected label", label)
+	}
+	ctx := triggered(context.Background(), fsnotify.Event{Name: "main.go", Op: fsnotify.Write})
+	if res := c.run(ctx, "."); res.Err != nil || res.Name != "rollout" {
+		t.Error("Unexpected response", res)
+	}
+	if len(e.runs) != 1 || e.runs[0].With["deployment"] != "api" || e.event.Name != "main.go" {
+		t.Error("Unexpected run", e.runs, e.event)
+	}
+	e.err = errors.New("rollout failed")
+	if res := c.run(ctx, "."); res.Err != e.err || res.Code != 1 {
+		t.Error("Unexpected response", res)
+	}
+	// a kind without executor fails, a command is run as usual
+	c = Command{Kind: "lambda", parent: p}
+	if res := c.run(ctx, "."); res.Err == nil || res.Err.Error() != "no executor of kind lambda" {
+		t.Error("Unexpected response", res)
+	}
+	c = Command{Cmd: "go version", parent: p}
+	if res := c.run(ctx, "."); res.Err != nil || len(e.runs) != 2 {
+		t.Error("Unexpected response", res, len(e.runs))
+	}
+}
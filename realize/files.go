@@ -0,0 +1,77 @@
+package realize
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchedFile checks if a path is one of the single files watched regardless of the extensions
+func (p *Project) watchedFile(path string) bool {
+	if path == "" {
+		return false
+	}
+	abs, _ := filepath.Abs(path)
+	for _, file := range p.Watcher.Files {
+		if p.filePath(file) == abs {
+			return true
+		}
+	}
+	return false
+}
+
+// Single reports if a path is watched by itself, an env file or one of the files
+func (p *Project) single(path string) bool {
+	return p.envFile(path) || p.watchedFile(path)
+}
+
+// WatchFiles adds the single files to the watcher
+func (p *Project) watchFiles() {
+	for _, file := range p.Watcher.Files {
+		p.watchFile(p.filePath(file))
+	}
+}
+
+// WatchFile adds a file to the watcher, its folder with fsnotify to survive the editors atomic saves
+func (p *Project) watchFile(path string) {
+	if p.watcher == nil {
+		return
+	}
+	if _, ok := p.watcher.(*filePoller); !ok {
+		path = filepath.Dir(path)
+	}
+	if err := p.watcher.Add(path); err != nil {
+		p.Err(err)
+	}
+}
+
+// Recreate waits for a removed single file to come back and watches it again, the poller drops the removed files
+func (p *Project) recreate(ctx context.Context, path string, created chan<- fsnotify.Event) {
+	w, ok := p.watcher.(*filePoller)
+	if !ok {
+		// its folder is watched, the create is an event
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.interval):
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := w.Add(path); err != nil {
+			p.Err(err)
+			return
+		}
+		select {
+		case created <- fsnotify.Event{Name: path, Op: fsnotify.Create}:
+		case <-ctx.Done():
+		}
+		return
+	}
+}
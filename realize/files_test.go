@@ -0,0 +1,69 @@
+package realize
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestProject_WatchedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := Project{parent: &Realize{}, Path: dir, Watcher: Watch{Exts: []string{"go"}, Files: []string{"config.yaml", filepath.Join(dir, "db", "schema.sql")}}}
+	if !p.watchedFile(filepath.Join(dir, "config.yaml")) || !p.watchedFile(filepath.Join(dir, "db", "schema.sql")) {
+		t.Error("Unexpected file check")
+	}
+	if p.watchedFile(filepath.Join(dir, "other.yaml")) || p.watchedFile("") {
+		t.Error("Unexpected file check")
+	}
+	// the extensions don't apply to the single files
+	if p.Validate(filepath.Join(dir, "config.yaml"), false) || !p.single(filepath.Join(dir, "config.yaml")) {
+		t.Error("Unexpected single file")
+	}
+}
+
+func TestProject_WatchFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "config.yaml")
+	ioutil.WriteFile(file, []byte("a: 1"), 0644)
+	w := PollingWatcher(10 * time.Millisecond).(*filePoller)
+	defer w.Close()
+	p := Project{parent: &Realize{}, Path: dir, watcher: w, Watcher: Watch{Files: []string{"config.yaml"}}}
+	p.watchFiles()
+	if _, ok := w.watches[file]; !ok {
+		t.Error("Unexpected watches", w.watches)
+	}
+	// an atomic save, the poller drops the removed file
+	os.Remove(file)
+	w.Remove(file)
+	created := make(chan fsnotify.Event)
+	go p.recreate(context.Background(), file, created)
+	time.Sleep(30 * time.Millisecond)
+	ioutil.WriteFile(file, []byte("a: 2"), 0644)
+	select {
+	case e := <-created:
+		if e.Name != file || e.Op != fsnotify.Create {
+			t.Error("Unexpected event", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Unexpected timeout, the file isn't watched again")
+	}
+	w.mu.Lock()
+	_, ok := w.watches[file]
+	w.mu.Unlock()
+	if !ok {
+		t.Error("Unexpected watches", w.watches)
+	}
+}
@@ -0,0 +1,63 @@
+package realize
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Fresh output of the first run, newer than every watched file
+func (p *Project) fresh(output string) bool {
+	if output == "" {
+		return false
+	}
+	fi, err := os.Stat(workdir(p.Path, output))
+	if err != nil || fi.IsDir() {
+		return false
+	}
+	newest := p.newest()
+	return !newest.IsZero() && fi.ModTime().After(newest)
+}
+
+// Newest modification of the watched files, scanned once
+func (p *Project) newest() time.Time {
+	if p.scanned {
+		return p.latest
+	}
+	p.scanned = true
+	for _, dir := range p.Watcher.Paths {
+		filepath.Walk(p.abs(dir), func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if p.shouldIgnore(path) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !info.IsDir() && p.Validate(path, true) && info.ModTime().After(p.latest) {
+				p.latest = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return p.latest
+}
+
+// Output of the go build, the one written in the project by default
+func (p *Project) buildOutput() string {
+	dir := p.Tools.Build.workdir(p.Path)
+	if out := output(p.Tools.Build.Args); out != "" {
+		return workdir(dir, out)
+	}
+	return workdir(dir, binary(dir))
+}
+
+// Skipped task of the first run, its output is up to date
+func (p *Project) skipped(name string) {
+	msg := fmt.Sprintln(p.pname(p.Name, 5), ":", Green.Bold(name), "up to date")
+	out := BufferOut{Time: time.Now(), Text: name + " up to date"}
+	p.stamp("log", out, msg, "")
+}
@@ -0,0 +1,49 @@
+package realize
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProject_Fresh(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	past, now := time.Now().Add(-time.Hour), time.Now()
+	files := map[string]time.Time{"main.go": past, "api/api.go": past.Add(time.Minute), "bin/app": now, "old.txt": past}
+	for name, mod := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, mod, mod)
+	}
+	r := Realize{}
+	p := Project{parent: &r, Name: "a", Path: dir, Watcher: Watch{Paths: []string{"/"}, Exts: []string{"go"}}}
+	if newest := p.newest(); !newest.Equal(past.Add(time.Minute)) {
+		t.Error("Unexpected newest", newest)
+	}
+	if !p.fresh("bin/app") {
+		t.Error("Expected fresh output")
+	}
+	if p.fresh("old.txt") || p.fresh("missing") || p.fresh("") {
+		t.Error("Unexpected fresh output")
+	}
+	// the scripts creating a fresh output are skipped by the first run only
+	p.Watcher.Scripts = []Command{{Type: "before", Cmd: "echo built", Creates: "bin/app"}}
+	p.first = true
+	if results := p.cmd(context.Background(), "before", false); len(results) != 0 {
+		t.Error("Unexpected results", results)
+	}
+	p.first = false
+	if results := p.cmd(context.Background(), "before", false); len(results) != 1 {
+		t.Error("Unexpected results", results)
+	}
+}
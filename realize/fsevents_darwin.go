@@ -0,0 +1,293 @@
+// +build darwin,cgo
+
+package realize
+
+/*
+#cgo LDFLAGS: -framework CoreServices
+#include <stdlib.h>
+#include <CoreServices/CoreServices.h>
+#include <dispatch/dispatch.h>
+
+extern void realizeEvents(ConstFSEventStreamRef stream, uintptr_t info, size_t n, char **paths, FSEventStreamEventFlags *flags, FSEventStreamEventId *ids);
+
+static void realizeCallback(ConstFSEventStreamRef stream, void *info, size_t n, void *paths, const FSEventStreamEventFlags flags[], const FSEventStreamEventId ids[]) {
+	realizeEvents(stream, (uintptr_t)info, n, (char **)paths, (FSEventStreamEventFlags *)flags, (FSEventStreamEventId *)ids);
+}
+
+static CFMutableArrayRef realizePaths(void) {
+	return CFArrayCreateMutable(NULL, 0, &kCFTypeArrayCallBacks);
+}
+
+static void realizeAppend(CFMutableArrayRef paths, const char *path) {
+	CFStringRef s = CFStringCreateWithCString(NULL, path, kCFStringEncodingUTF8);
+	CFArrayAppendValue(paths, s);
+	CFRelease(s);
+}
+
+static FSEventStreamRef realizeStream(uintptr_t info, CFMutableArrayRef paths, dispatch_queue_t queue) {
+	FSEventStreamContext ctx = {0, (void *)info, NULL, NULL, NULL};
+	FSEventStreamCreateFlags flags = kFSEventStreamCreateFlagFileEvents | kFSEventStreamCreateFlagNoDefer;
+	FSEventStreamRef stream = FSEventStreamCreate(NULL, realizeCallback, &ctx, paths, kFSEventStreamEventIdSinceNow, 0.05, flags);
+	CFRelease(paths);
+	if (stream == NULL) {
+		return NULL;
+	}
+	FSEventStreamSetDispatchQueue(stream, queue);
+	if (!FSEventStreamStart(stream)) {
+		FSEventStreamInvalidate(stream);
+		FSEventStreamRelease(stream);
+		return NULL;
+	}
+	return stream;
+}
+
+static void realizeStop(FSEventStreamRef stream) {
+	FSEventStreamStop(stream);
+	FSEventStreamInvalidate(stream);
+	FSEventStreamRelease(stream);
+}
+
+static dispatch_queue_t realizeQueue(void) {
+	return dispatch_queue_create("realize.fsevents", DISPATCH_QUEUE_SERIAL);
+}
+
+static void realizeRelease(dispatch_queue_t queue) {
+	dispatch_release(queue);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unsafe"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// fsEventsWatcher satisfies the FileWatcher interface with a recursive FSEvents stream of the
+// watched roots, kqueue takes a descriptor for each file instead
+type fsEventsWatcher struct {
+	id     uintptr
+	mu     sync.Mutex
+	paths  map[string]bool
+	roots  map[string]string
+	closed bool
+	// the stream is restarted when the roots change, outside of mu held by the callbacks
+	smu    sync.Mutex
+	stream C.FSEventStreamRef
+	queue  C.dispatch_queue_t
+	events chan fsnotify.Event
+	errors chan error
+	done   chan struct{}
+}
+
+// Streams by id, the callbacks can't get a go pointer
+var streams = struct {
+	sync.Mutex
+	watchers map[uintptr]*fsEventsWatcher
+	next     uintptr
+}{watchers: make(map[uintptr]*fsEventsWatcher)}
+
+// Native watcher of darwin
+func nativeWatcher() (FileWatcher, error) {
+	w := &fsEventsWatcher{
+		paths:  make(map[string]bool),
+		roots:  make(map[string]string),
+		queue:  C.realizeQueue(),
+		events: make(chan fsnotify.Event, 64),
+		errors: make(chan error, 1),
+		done:   make(chan struct{}),
+	}
+	streams.Lock()
+	streams.next++
+	w.id = streams.next
+	streams.watchers[w.id] = w
+	streams.Unlock()
+	return w, nil
+}
+
+// Add a path, the stream is restarted only if it isn't inside a watched root
+func (w *fsEventsWatcher) Add(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return errors.New("watcher is closed")
+	}
+	w.paths[path] = true
+	for _, root := range w.roots {
+		if within(path, root) {
+			w.mu.Unlock()
+			return nil
+		}
+	}
+	w.mu.Unlock()
+	return w.restart()
+}
+
+// Remove a path, the stream is restarted if it was a root
+func (w *fsEventsWatcher) Remove(path string) error {
+	w.mu.Lock()
+	if !w.paths[path] {
+		w.mu.Unlock()
+		return errNoSuchWatch
+	}
+	delete(w.paths, path)
+	root := false
+	for _, r := range w.roots {
+		root = root || r == path
+	}
+	w.mu.Unlock()
+	if !root {
+		return nil
+	}
+	return w.restart()
+}
+
+// Walk fsevents
+func (w *fsEventsWatcher) Walk(path string, init bool) string {
+	if err := w.Add(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// Close stops the stream
+func (w *fsEventsWatcher) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	close(w.done)
+	w.mu.Unlock()
+	w.smu.Lock()
+	if w.stream != nil {
+		C.realizeStop(w.stream)
+		w.stream = nil
+	}
+	C.realizeRelease(w.queue)
+	w.smu.Unlock()
+	streams.Lock()
+	delete(streams.watchers, w.id)
+	streams.Unlock()
+	return nil
+}
+
+// Events returns the events of the watched paths
+func (w *fsEventsWatcher) Events() <-chan fsnotify.Event {
+	return w.events
+}
+
+// Errors returns the errors of the stream
+func (w *fsEventsWatcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Restart the stream on the roots of the watched paths, the ones not inside another one
+func (w *fsEventsWatcher) restart() error {
+	w.smu.Lock()
+	defer w.smu.Unlock()
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	// sorted, a dir comes before the paths inside it
+	paths := make([]string, 0, len(w.paths))
+	for path := range w.paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	roots := make(map[string]string)
+	for _, path := range paths {
+		covered := false
+		for _, root := range roots {
+			covered = covered || within(path, root)
+		}
+		if !covered {
+			// the events have the real paths, e.g. /private/tmp for /tmp
+			real, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				real = path
+			}
+			roots[real] = path
+		}
+	}
+	w.roots = roots
+	w.mu.Unlock()
+	if w.stream != nil {
+		C.realizeStop(w.stream)
+		w.stream = nil
+	}
+	if len(roots) == 0 {
+		return nil
+	}
+	list := C.realizePaths()
+	for real := range roots {
+		cs := C.CString(real)
+		C.realizeAppend(list, cs)
+		C.free(unsafe.Pointer(cs))
+	}
+	w.stream = C.realizeStream(C.uintptr_t(w.id), list, w.queue)
+	if w.stream == nil {
+		return errors.New("fsevents stream not started")
+	}
+	return nil
+}
+
+// Dispatch the events of the watched paths and of the files in the watched dirs, as fsnotify
+func (w *fsEventsWatcher) dispatch(names []string, flags []C.FSEventStreamEventFlags) {
+	var events []fsnotify.Event
+	w.mu.Lock()
+	for i, name := range names {
+		for real, root := range w.roots {
+			if name == real || strings.HasPrefix(name, real+string(os.PathSeparator)) {
+				name = root + name[len(real):]
+				break
+			}
+		}
+		if !w.paths[name] && !w.paths[filepath.Dir(name)] {
+			continue
+		}
+		if op := operation(name, flags[i]); op != 0 {
+			events = append(events, fsnotify.Event{Name: name, Op: op})
+		}
+	}
+	w.mu.Unlock()
+	for _, e := range events {
+		select {
+		case w.events <- e:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// Operation of the coalesced flags of an event, the file is checked because a create and a remove may be merged
+func operation(name string, flags C.FSEventStreamEventFlags) fsnotify.Op {
+	_, err := os.Lstat(name)
+	exists := err == nil
+	switch {
+	case flags&C.kFSEventStreamEventFlagItemRemoved != 0 && !exists:
+		return fsnotify.Remove
+	case flags&C.kFSEventStreamEventFlagItemRenamed != 0 && !exists:
+		return fsnotify.Rename
+	case flags&(C.kFSEventStreamEventFlagItemCreated|C.kFSEventStreamEventFlagItemRenamed) != 0 && exists &&
+		flags&C.kFSEventStreamEventFlagItemModified == 0:
+		return fsnotify.Create
+	case flags&C.kFSEventStreamEventFlagItemModified != 0 && exists:
+		return fsnotify.Write
+	case flags&(C.kFSEventStreamEventFlagItemInodeMetaMod|C.kFSEventStreamEventFlagItemChangeOwner|C.kFSEventStreamEventFlagItemXattrMod) != 0:
+		return fsnotify.Chmod
+	}
+	return 0
+}
@@ -0,0 +1,28 @@
+// +build darwin,cgo
+
+package realize
+
+/*
+#include <CoreServices/CoreServices.h>
+*/
+import "C"
+
+import "unsafe"
+
+//export realizeEvents
+func realizeEvents(stream C.ConstFSEventStreamRef, info C.uintptr_t, n C.size_t, paths **C.char, flags *C.FSEventStreamEventFlags, ids *C.FSEventStreamEventId) {
+	streams.Lock()
+	w := streams.watchers[uintptr(info)]
+	streams.Unlock()
+	if w == nil || n == 0 {
+		return
+	}
+	count := int(n)
+	cpaths := (*[1 << 28]*C.char)(unsafe.Pointer(paths))[:count:count]
+	cflags := (*[1 << 28]C.FSEventStreamEventFlags)(unsafe.Pointer(flags))[:count:count]
+	names := make([]string, count)
+	for i, p := range cpaths {
+		names[i] = C.GoString(p)
+	}
+	w.dispatch(names, append([]C.FSEventStreamEventFlags{}, cflags...))
+}
@@ -0,0 +1,111 @@
+package realize
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// TaskGenerate is the type of the scripts run when their inputs change, their outputs don't trigger a reload
+const TaskGenerate = "generate"
+
+// Generator reports if a command is a generate task
+func (c *Command) generator() bool {
+	return strings.ToLower(c.Type) == TaskGenerate
+}
+
+// Check a generate task, without inputs it would never run again
+func (c *Command) checkGenerate() error {
+	if len(c.Inputs) == 0 {
+		return errors.New("generate without inputs")
+	}
+	for _, list := range [][]string{c.Inputs, c.Outputs} {
+		for _, v := range list {
+			if _, err := filepath.Match(v, ""); err != nil {
+				return errors.New("invalid generate pattern " + v + ": " + err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// Matches reports if a path matches a pattern relative to the dir: inside a folder, a glob of the relative path
+// with a separator, of the name without it
+func matches(dir, path string, patterns []string) bool {
+	path, _ = filepath.Abs(path)
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return false
+	}
+	for _, pattern := range patterns {
+		pattern = filepath.Clean(pattern)
+		if within(rel, pattern) {
+			return true
+		}
+		target := rel
+		if !strings.ContainsRune(pattern, os.PathSeparator) {
+			target = filepath.Base(rel)
+		}
+		if ok, _ := filepath.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Input reports if a path is an input of a generate task, it's watched regardless of the extensions
+func (p *Project) input(path string) bool {
+	for _, c := range p.Watcher.scripts() {
+		if c.generator() && matches(workdir(p.Path, c.Path), path, c.Inputs) {
+			return true
+		}
+	}
+	return false
+}
+
+// Generated reports if a path is an output of a generate task, its changes don't trigger a reload
+func (p *Project) generated(path string) bool {
+	for _, c := range p.Watcher.scripts() {
+		if c.generator() && matches(workdir(p.Path, c.Path), path, c.Outputs) {
+			return true
+		}
+	}
+	return false
+}
+
+// Generate runs the tasks with a changed input, all of them without changed files
+func (p *Project) generate(ctx context.Context, paths []string) (results []Response) {
+	for _, cmd := range p.Watcher.scripts() {
+		if !cmd.generator() {
+			continue
+		}
+		changed := len(paths) == 0
+		for _, path := range paths {
+			changed = changed || matches(workdir(p.Path, cmd.Path), path, cmd.Inputs)
+		}
+		if !changed {
+			continue
+		}
+		cmd.parent = p
+		start := time.Now()
+		id := p.started(ctx, cmd.line())
+		r := p.cached(ctx, cmd)
+		r.ID = id
+		p.finished(r, start)
+		if ctx.Err() != nil {
+			return
+		}
+		p.diagnose(&r)
+		results = append(results, r)
+		p.results.send(r)
+		p.report(TaskGenerate, cmd, r)
+		// abort the remaining tasks
+		if r.Err != nil && p.Watcher.FailFast {
+			return
+		}
+	}
+	return
+}
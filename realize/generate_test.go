@@ -0,0 +1,98 @@
+package realize
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestMatches(t *testing.T) {
+	dir := filepath.Join(os.TempDir(), "project")
+	tests := []struct {
+		path     string
+		patterns []string
+		expected bool
+	}{
+		{filepath.Join(dir, "api", "v1", "user.proto"), []string{"*.proto"}, true},
+		{filepath.Join(dir, "api", "v1", "user.proto"), []string{"api"}, true},
+		{filepath.Join(dir, "api", "v1", "user.proto"), []string{"api/*/*.proto"}, true},
+		{filepath.Join(dir, "api", "v1", "user.proto"), []string{"api/*.proto"}, false},
+		{filepath.Join(dir, "sqlc.yaml"), []string{"sqlc.yaml"}, true},
+		{filepath.Join(dir, "db", "sqlc.yaml"), []string{"./db/"}, true},
+		{filepath.Join(dir, "main.go"), []string{"*.proto", "gen"}, false},
+		{filepath.Join(os.TempDir(), "other", "user.proto"), []string{"*.proto"}, false},
+	}
+	for _, test := range tests {
+		if matches(dir, test.path, test.patterns) != test.expected {
+			t.Error("Unexpected match", test.path, test.patterns, !test.expected)
+		}
+	}
+}
+
+func TestCommand_CheckGenerate(t *testing.T) {
+	c := Command{Type: "Generate", Cmd: "buf generate"}
+	if !c.generator() {
+		t.Error("Unexpected type", c.Type)
+	}
+	if c.checkGenerate() == nil {
+		t.Error("Unexpected generate without inputs")
+	}
+	c.Inputs, c.Outputs = []string{"*.proto"}, []string{"gen["}
+	if c.checkGenerate() == nil {
+		t.Error("Unexpected invalid pattern")
+	}
+	c.Outputs = []string{"gen"}
+	if err := c.checkGenerate(); err != nil {
+		t.Error("Unexpected error", err)
+	}
+}
+
+func TestProject_Generate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh")
+	}
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := Project{parent: &Realize{}, Path: dir, Watcher: Watch{Exts: []string{"go"}, Scripts: []Command{
+		{Type: TaskGenerate, Cmd: "sh -c 'echo proto >> runs'", Inputs: []string{"*.proto"}, Outputs: []string{"gen"}},
+		{Type: TaskGenerate, Cmd: "sh -c 'echo sqlc >> runs'", Path: "db", Inputs: []string{"sqlc.yaml", "queries"}, Outputs: []string{"store"}},
+		{Type: "before", Cmd: "sh -c 'echo before >> runs'"},
+	}}}
+	os.Mkdir(filepath.Join(dir, "db"), 0755)
+	proto, query := filepath.Join(dir, "api", "user.proto"), filepath.Join(dir, "db", "queries", "user.sql")
+	if !p.input(proto) || !p.input(query) || p.input(filepath.Join(dir, "main.go")) {
+		t.Error("Unexpected inputs")
+	}
+	if !p.generated(filepath.Join(dir, "gen", "user.pb.go")) || !p.generated(filepath.Join(dir, "db", "store", "db.go")) || p.generated(filepath.Join(dir, "store", "db.go")) {
+		t.Error("Unexpected outputs")
+	}
+	runs := func() string {
+		var list []string
+		for _, file := range []string{filepath.Join(dir, "runs"), filepath.Join(dir, "db", "runs")} {
+			content, _ := ioutil.ReadFile(file)
+			list = append(list, strings.Fields(string(content))...)
+			os.Remove(file)
+		}
+		return strings.Join(list, ",")
+	}
+	// the tasks of the changed inputs
+	if results := p.generate(context.Background(), []string{proto, filepath.Join(dir, "main.go")}); len(results) != 1 || runs() != "proto" {
+		t.Error("Unexpected tasks", results)
+	}
+	p.generate(context.Background(), []string{query})
+	if r := runs(); r != "sqlc" {
+		t.Error("Unexpected tasks", r)
+	}
+	// all of them without changed files
+	p.generate(context.Background(), nil)
+	if r := runs(); r != "proto,sqlc" {
+		t.Error("Unexpected tasks", r)
+	}
+}
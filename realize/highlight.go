@@ -0,0 +1,123 @@
+package realize
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Highlight rule of the output lines, the first matching one colors the line and may change its level
+type Highlight struct {
+	Pattern string `yaml:"pattern" json:"pattern"`
+	Color   string `yaml:"color,omitempty" json:"color,omitempty"`
+	Level   string `yaml:"level,omitempty" json:"level,omitempty"`
+}
+
+// Colors of the highlight rules by name
+var colors = map[string]colorBase{
+	"red":     Red,
+	"blue":    Blue,
+	"green":   Green,
+	"yellow":  Yellow,
+	"magenta": Magenta,
+}
+
+// Stamp types of the highlight levels
+var levelStamps = map[string]string{
+	"debug": "debug",
+	"info":  "out",
+	"warn":  "warn",
+	"error": "error",
+}
+
+// Compiled patterns, the commands are copied on each run
+var patterns sync.Map
+
+// Pattern compiled once
+func pattern(expr string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(expr, re)
+	return re, nil
+}
+
+// Check the pattern, the color and the level of the rules
+func checkRules(rules []Highlight) error {
+	for _, h := range rules {
+		if _, err := pattern(h.Pattern); err != nil {
+			return errors.New("invalid highlight pattern " + h.Pattern + ": " + err.Error())
+		}
+		if _, ok := colors[strings.ToLower(h.Color)]; h.Color != "" && !ok {
+			return errors.New("unknown highlight color " + h.Color + ", use red, blue, green, yellow or magenta")
+		}
+		if _, ok := levelStamps[strings.ToLower(h.Level)]; h.Level != "" && !ok {
+			return errors.New("unknown highlight level " + h.Level + ", use debug, info, warn or error")
+		}
+	}
+	return nil
+}
+
+// Match the first rule of a line, nil without a match
+func match(rules []Highlight, line string) *Highlight {
+	for i, h := range rules {
+		if re, err := pattern(h.Pattern); err == nil && re.MatchString(line) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// Paint a line with the color of the rule, bold for the errors
+func (h *Highlight) paint(line string) string {
+	c, ok := colors[strings.ToLower(h.Color)]
+	if !ok {
+		return line
+	}
+	if h.error() {
+		return c.Bold(line)
+	}
+	return c.Regular(line)
+}
+
+// Error level, the line is promoted to an error
+func (h *Highlight) error() bool {
+	return strings.EqualFold(h.Level, "error")
+}
+
+// Stamp type of a line, the given one without a level
+func (h *Highlight) stamp(t string) string {
+	if s, ok := levelStamps[strings.ToLower(h.Level)]; ok {
+		return s
+	}
+	return t
+}
+
+// Paint the matching lines of a text
+func paint(rules []Highlight, text string) string {
+	if len(rules) == 0 {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if h := match(rules, line); h != nil {
+			lines[i] = h.paint(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Promoted lines of a text, the ones matching an error rule
+func promoted(rules []Highlight, text string) (list []string) {
+	for _, line := range strings.Split(text, "\n") {
+		if h := match(rules, line); h != nil && h.error() {
+			list = append(list, line)
+		}
+	}
+	return
+}
@@ -0,0 +1,53 @@
+package realize
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestCheckRules(t *testing.T) {
+	if err := checkRules([]Highlight{{Pattern: "^WARN", Color: "Yellow", Level: "warn"}, {Pattern: "panic:"}}); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	for _, h := range []Highlight{{Pattern: "("}, {Pattern: "a", Color: "cyan"}, {Pattern: "a", Level: "fatal"}} {
+		if err := checkRules([]Highlight{h}); err == nil {
+			t.Error("Expected an error", h)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	rules := []Highlight{{Pattern: "^WARN", Level: "warn"}, {Pattern: "panic:", Level: "error"}, {Pattern: "SELECT", Color: "blue"}}
+	if h := match(rules, "WARN disk"); h == nil || h.stamp("out") != "warn" || h.error() {
+		t.Error("Unexpected match", h)
+	}
+	if h := match(rules, "SELECT 1"); h == nil || h.stamp("out") != "out" {
+		t.Error("Unexpected match", h)
+	}
+	if h := match(rules, "listening"); h != nil {
+		t.Error("Unexpected match", h)
+	}
+	if lines := promoted(rules, "ok\npanic: nil map\nWARN disk"); len(lines) != 1 || lines[0] != "panic: nil map" {
+		t.Error("Unexpected promoted lines", lines)
+	}
+	if text := paint(nil, "a\nb"); text != "a\nb" {
+		t.Error("Unexpected text", text)
+	}
+}
+
+func TestCommand_Highlight(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("No sh on Windows")
+	}
+	c := Command{Cmd: "echo 'panic: nil map'", Shell: true, Rules: []Highlight{{Pattern: "^panic:", Level: "error"}}}
+	r := c.exec(context.Background(), os.TempDir())
+	if r.Err == nil || r.Err.Error() != "panic: nil map" || r.Code != 1 {
+		t.Error("Expected a promoted error", r.Err, r.Code)
+	}
+	c.Rules[0].Level = "warn"
+	if r = c.exec(context.Background(), os.TempDir()); r.Err != nil {
+		t.Error("Unexpected error", r.Err)
+	}
+}
@@ -0,0 +1,83 @@
+package realize
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// StatusCanceled is the status of a run superseded by a new change
+const StatusCanceled = "canceled"
+
+// Run of a project, stored in the history file when enabled
+type Run struct {
+	Project  string    `json:"project"`
+	File     string    `json:"file,omitempty"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Duration float64   `json:"duration"`
+	Status   string    `json:"status"`
+	// Tasks are the durations of the tasks of the run, in seconds
+	Tasks map[string]float64 `json:"tasks,omitempty"`
+	// Errors are the failed tasks of the run with the first line of their error
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Remember appends a run to the history file
+func (p *Project) remember(file string, start time.Time, canceled bool) error {
+	if !p.parent.Settings.History {
+		return nil
+	}
+	run := Run{Project: p.Name, File: file, Start: start, End: time.Now(), Status: p.outcome, Tasks: p.timings}
+	run.Duration = run.End.Sub(start).Seconds()
+	for _, r := range p.failures {
+		run.Errors = append(run.Errors, r.Name+": "+failure(r))
+	}
+	switch {
+	case canceled:
+		run.Status = StatusCanceled
+	case run.Status == "":
+		// stopped before the verify, by a fail fast
+		run.Status = StatusFailure
+	}
+	line, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(FileHistory, os.O_APPEND|os.O_WRONLY|os.O_CREATE, Permission)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// History reads the last runs of a history file, only the ones of a project if name isn't empty
+func History(file, name string, limit int) ([]Run, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	var runs []Run
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var run Run
+		if json.Unmarshal(scanner.Bytes(), &run) != nil {
+			continue
+		}
+		if name != "" && run.Project != name {
+			continue
+		}
+		runs = append(runs, run)
+		if limit > 0 && len(runs) > limit {
+			runs = runs[1:]
+		}
+	}
+	return runs, scanner.Err()
+}
@@ -0,0 +1,50 @@
+package realize
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProject_Remember(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+	r := Realize{}
+	p := Project{parent: &r, Name: "a"}
+	// disabled by default
+	p.remember("main.go", time.Now(), false)
+	if _, err := os.Stat(FileHistory); !os.IsNotExist(err) {
+		t.Error("Unexpected history file")
+	}
+	r.Settings.History = true
+	p.outcome = StatusSuccess
+	p.remember("main.go", time.Now(), false)
+	p.remember("main.go", time.Now(), true)
+	p.outcome, p.failures = "", []Response{{Name: "vet", Err: errors.New("main.go:3: unreachable code\nmore")}}
+	p.remember("", time.Now(), false)
+	b := Project{parent: &r, Name: "b", outcome: StatusSuccess}
+	b.remember("", time.Now(), false)
+	runs, err := History(filepath.Join(dir, FileHistory), "a", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].Status != StatusCanceled || runs[1].Status != StatusFailure || strings.Join(runs[1].Errors, ",") != "vet: main.go:3: unreachable code" {
+		t.Error("Unexpected runs", runs)
+	}
+	if runs, _ = History(FileHistory, "", 0); len(runs) != 4 || runs[3].Project != "b" {
+		t.Error("Unexpected runs", runs)
+	}
+	if runs, err = History("missing", "", 0); err != nil || runs != nil {
+		t.Error("Unexpected runs", runs, err)
+	}
+}
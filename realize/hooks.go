@@ -0,0 +1,46 @@
+package realize
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Lifecycle events of a project, each one can run a hook
+const (
+	HookStart   = "start"
+	HookReload  = "reload"
+	HookSuccess = "success"
+	HookError   = "error"
+)
+
+// Hook runs the command of a lifecycle event, the event is described by the REALIZE_ env vars.
+// A failed hook is printed but doesn't change the status of the run.
+func (p *Project) hook(ctx context.Context, c *Command, event, path string, start time.Time) {
+	if c == nil || c.line() == "" && c.WaitFor == nil {
+		return
+	}
+	hook := *c
+	hook.parent = p
+	hook.env = []string{
+		"REALIZE_PROJECT=" + p.Name,
+		"REALIZE_EVENT=" + event,
+		"REALIZE_FILE=" + path,
+		"REALIZE_STATUS=" + p.outcome,
+		"REALIZE_DURATION=" + strconv.FormatFloat(time.Since(start).Seconds(), 'f', 3, 64),
+	}
+	r := hook.run(ctx, p.Path)
+	if ctx.Err() != nil {
+		return
+	}
+	label := "on_" + event
+	msg := fmt.Sprintln(p.pname(p.Name, 5), ":", Green.Bold("Hook"), Green.Bold(label), Green.Bold("\"")+r.Name+Green.Bold("\""))
+	if r.Err != nil {
+		out := BufferOut{Time: time.Now(), Text: r.Err.Error(), Type: label}
+		p.stamp("error", out, msg, p.prefixed(label, r.Err.Error(), true))
+		return
+	}
+	out := BufferOut{Time: time.Now(), Text: r.Out, Type: label}
+	p.stamp("log", out, msg, p.prefixed(label, r.Out, false))
+}
@@ -0,0 +1,51 @@
+package realize
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestProject_Hook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("No sh on Windows")
+	}
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+	script := `echo $REALIZE_EVENT $REALIZE_PROJECT $REALIZE_STATUS $REALIZE_FILE >> hooks.log`
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{
+		parent:    &r,
+		Name:      "test",
+		Path:      dir,
+		OnReload:  &Command{Cmd: script, Shell: true},
+		OnSuccess: &Command{Cmd: script, Shell: true},
+		OnError:   &Command{Cmd: script, Shell: true},
+		Verify:    &Command{Cmd: "true"},
+	})
+	p := &r.Projects[0]
+	p.Reload(context.Background(), "")
+	p.Verify.Cmd = "false"
+	p.Reload(context.Background(), "main.go")
+	content, _ := ioutil.ReadFile(filepath.Join(dir, "hooks.log"))
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	expected := []string{"success test success", "reload test main.go", "error test failure main.go"}
+	if len(lines) != len(expected) {
+		t.Fatal("Unexpected hooks", lines)
+	}
+	for i := range expected {
+		if strings.TrimSpace(lines[i]) != expected[i] {
+			t.Error("Unexpected hook", lines[i], "instead", expected[i])
+		}
+	}
+}
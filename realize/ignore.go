@@ -0,0 +1,201 @@
+package realize
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Folders ignored at any depth by default
+var ignoredDirs = []string{".git", "vendor", "node_modules"}
+
+// Files written by go test and the profiles, the files of the daemon, ignored by default
+var ignoredFiles = []string{"*.test", "*.prof", "*.pprof", FilePid, FileSock, FileDaemon}
+
+// IgnoreDefaults reports if the default ignores apply, they do unless disabled
+func (w *Watch) IgnoreDefaults() bool {
+	return w.Defaults == nil || *w.Defaults
+}
+
+// Ignoring holds the ignore rules of a project resolved once, a walk matches them against all its paths
+type ignoring struct {
+	defaults  bool
+	fold      bool
+	base      string
+	cache     string
+	artifacts []string
+	paths     []string
+	dirs      []string
+}
+
+// Rules of a watching project, the ignore rules resolved once and again when the paths or the go.mod change
+type rules struct {
+	mu       sync.Mutex
+	resolved *ignoring
+}
+
+// Ignoring rules of the project, the resolved ones of a watching project
+func (p *Project) ignoring() ignoring {
+	if p.rules == nil {
+		return p.resolve()
+	}
+	p.rules.mu.Lock()
+	defer p.rules.mu.Unlock()
+	if p.rules.resolved == nil {
+		i := p.resolve()
+		p.rules.resolved = &i
+	}
+	return *p.rules.resolved
+}
+
+// Reignore resolves the rules again on the next use
+func (p *Project) reignore() {
+	if p.rules == nil {
+		return
+	}
+	p.rules.mu.Lock()
+	p.rules.resolved = nil
+	p.rules.mu.Unlock()
+}
+
+// Resolve the ignore rules: the defaults, the cache and the ignored paths
+func (p *Project) resolve() ignoring {
+	i := ignoring{defaults: p.Watcher.IgnoreDefaults(), paths: p.Watcher.Ignore}
+	i.base, _ = filepath.Abs(p.Path)
+	i.cache, _ = filepath.Abs(FileCache)
+	i.fold = folds(i.base)
+	if i.defaults {
+		i.artifacts = p.artifacts()
+	}
+	for _, v := range p.Watcher.Ignore {
+		i.dirs = append(i.dirs, filepath.Join(i.base, filepath.FromSlash(v)))
+	}
+	return i.normalized()
+}
+
+// Normalized rules, their paths are compared as normalized
+func (i ignoring) normalized() ignoring {
+	i.base, i.cache = i.norm(i.base), i.norm(i.cache)
+	artifacts, dirs := make([]string, len(i.artifacts)), make([]string, len(i.dirs))
+	for k, v := range i.artifacts {
+		artifacts[k] = i.norm(v)
+	}
+	for k, v := range i.dirs {
+		dirs[k] = i.norm(v)
+	}
+	i.artifacts, i.dirs = artifacts, dirs
+	return i
+}
+
+// By returns the rule ignoring an absolute path, empty if none
+func (i ignoring) by(path string) string {
+	path = i.norm(path)
+	if rule := i.byDefault(path); rule != "" {
+		return rule
+	}
+	// the cache is written by the cached commands
+	if within(path, i.cache) {
+		return FileCache
+	}
+	for k, dir := range i.dirs {
+		if within(path, dir) {
+			return i.paths[k]
+		}
+	}
+	return ""
+}
+
+// Default rule ignoring a path, applied before the ignored paths, empty if none
+func (i ignoring) byDefault(path string) string {
+	if !i.defaults {
+		return ""
+	}
+	rel, err := filepath.Rel(i.base, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return ""
+	}
+	segments := strings.Split(rel, string(os.PathSeparator))
+	for _, s := range segments {
+		if contains(ignoredDirs, s) {
+			return s
+		}
+	}
+	for _, pattern := range ignoredFiles {
+		if ok, _ := filepath.Match(pattern, segments[len(segments)-1]); ok {
+			return pattern
+		}
+	}
+	// a rebuild of the binary would trigger another one
+	for _, artifact := range i.artifacts {
+		if path == artifact {
+			return rel
+		}
+	}
+	return ""
+}
+
+// Artifacts written in the project by the go build and wasm tasks, the logs of the scripts
+func (p *Project) artifacts() (list []string) {
+	if p.Tools.Build.Status {
+		list = append(list, p.buildOutput())
+	}
+	if p.Tools.Wasm.Status && p.Tools.Wasm.Path != "" {
+		list = append(list, workdir(p.Tools.Wasm.workdir(p.Path), p.Tools.Wasm.Path))
+	}
+	for _, c := range p.Watcher.scripts() {
+		if c.Log != "" {
+			list = append(list, workdir(p.Path, c.Log))
+		}
+	}
+	return
+}
+
+// Output of a go build given by its args, empty by default
+func output(args []string) string {
+	for i, arg := range args {
+		switch {
+		case arg == "-o" && i+1 < len(args):
+			return args[i+1]
+		case strings.HasPrefix(arg, "-o="):
+			return strings.TrimPrefix(arg, "-o=")
+		}
+	}
+	return ""
+}
+
+// Binary written by go build in a dir, named after its module or the dir itself
+func binary(dir string) string {
+	name := filepath.Base(dir)
+	if module := module(dir); module != "" {
+		name = path.Base(module)
+		// the major version suffix isn't part of the name
+		if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" && module != name {
+			name = path.Base(path.Dir(module))
+		}
+	}
+	if runtime.GOOS == "windows" {
+		name += RExtWin
+	}
+	return name
+}
+
+// Module path of the go.mod of a dir, empty without it
+func module(dir string) string {
+	f, err := os.Open(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], "\"`")
+		}
+	}
+	return ""
+}
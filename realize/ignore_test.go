@@ -0,0 +1,73 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestProject_IgnoredByDefault(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app/v2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := Project{Path: dir}
+	p.Tools.Build.Status = true
+	p.Tools.Wasm = Tool{Status: true, Path: "web/app.wasm"}
+	p.Watcher.Scripts = []Command{{Cmd: "npm run watch", Log: "logs/web.log"}}
+	bin := "app"
+	if runtime.GOOS == "windows" {
+		bin += RExtWin
+	}
+	for _, path := range []string{".git/HEAD", "vendor/a/a.go", "web/node_modules/x/index.js", "api/api.test", "cpu.prof", FilePid, FileSock, FileDaemon, bin, "web/app.wasm", "logs/web.log"} {
+		if !p.shouldIgnore(filepath.Join(dir, path)) {
+			t.Error("Expected ignored", path)
+		}
+	}
+	for _, path := range []string{"main.go", "api/vendors.go", "testdata/input.json", "cmd/app"} {
+		if p.shouldIgnore(filepath.Join(dir, path)) {
+			t.Error("Unexpected ignored", path)
+		}
+	}
+	// opt-out
+	defaults := false
+	p.Watcher.Defaults = &defaults
+	if p.shouldIgnore(filepath.Join(dir, ".git", "HEAD")) {
+		t.Error("Unexpected ignored with the defaults disabled")
+	}
+}
+
+func TestOutput(t *testing.T) {
+	if out := output([]string{"-race", "-o", "bin/app"}); out != "bin/app" {
+		t.Error("Unexpected output", out)
+	}
+	if out := output([]string{"-o=bin/app"}); out != "bin/app" {
+		t.Error("Unexpected output", out)
+	}
+	if out := output([]string{"-race"}); out != "" {
+		t.Error("Unexpected output", out)
+	}
+}
+
+func TestProject_Reignore(t *testing.T) {
+	p := Project{Path: ".", rules: &rules{}}
+	p.Watcher.Ignore = []string{"tmp"}
+	if i := p.ignoring(); len(i.dirs) != 1 {
+		t.Error("Unexpected rules", i.dirs)
+	}
+	// resolved once until the paths change
+	p.Watcher.Ignore = append(p.Watcher.Ignore, "gen")
+	if i := p.ignoring(); len(i.dirs) != 1 {
+		t.Error("Unexpected rules", i.dirs)
+	}
+	p.reignore()
+	if i := p.ignoring(); len(i.dirs) != 2 {
+		t.Error("Unexpected rules", i.dirs)
+	}
+}
@@ -0,0 +1,124 @@
+package realize
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"go/build"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Imports returns the directories of the local packages imported by the project,
+// standard library, module cache and already watched paths are excluded
+func (p *Project) imports() ([]string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("go", "list", "-deps", "-f", "{{if not .Standard}}{{.Dir}}{{end}}", "./...")
+	cmd.Dir = p.Path
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return nil, errors.New(strings.TrimSpace(stderr.String()))
+		}
+		return nil, err
+	}
+	var dirs []string
+	seen := make(map[string]bool)
+	cache := modCache()
+	base, _ := filepath.Abs(p.Path)
+L:
+	for _, dir := range strings.Split(stdout.String(), "\n") {
+		dir = strings.TrimSpace(dir)
+		if dir == "" || seen[dir] || inside(dir, cache) {
+			continue
+		}
+		for _, v := range p.Watcher.Paths {
+			if inside(dir, filepath.Join(base, v)) {
+				continue L
+			}
+		}
+		seen[dir] = true
+		dirs = append(dirs, dir)
+	}
+	return dirs, nil
+}
+
+// Watch the local packages imported by the project
+func (p *Project) watchImports() {
+	dirs, err := p.imports()
+	if err != nil {
+		p.Err(err)
+		return
+	}
+	p.importDirs(dirs)
+}
+
+// Reimport lists the imports again during a run, the watch loop applies them as the other path changes
+func (p *Project) reimport(ctx context.Context) {
+	if p.changes == nil {
+		p.watchImports()
+		return
+	}
+	dirs, err := p.imports()
+	if err != nil {
+		p.Err(err)
+		return
+	}
+	select {
+	case p.changes <- pathChange{imports: true, dirs: dirs}:
+	case <-ctx.Done():
+	}
+}
+
+// Watch the dirs of the imported packages, dropped imports are removed
+func (p *Project) importDirs(dirs []string) {
+	current := make(map[string]bool)
+	for _, dir := range dirs {
+		current[dir] = true
+	}
+	previous := make(map[string]bool)
+	for _, dir := range p.paths {
+		previous[dir] = true
+		if !current[dir] && p.watcher != nil {
+			p.watcher.Remove(dir)
+		}
+	}
+	for _, dir := range dirs {
+		if !previous[dir] {
+			// the package dir only, sub packages are listed on their own
+			filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return nil
+				}
+				if info.IsDir() && path != dir {
+					return filepath.SkipDir
+				}
+				return p.walk(path, info, err)
+			})
+		}
+	}
+	p.paths = dirs
+}
+
+// Module cache dir
+func modCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	if list := filepath.SplitList(build.Default.GOPATH); len(list) > 0 {
+		return filepath.Join(list[0], "pkg", "mod")
+	}
+	return ""
+}
+
+// Check if a path is equal to or inside a dir
+func inside(path, dir string) bool {
+	if dir == "" {
+		return false
+	}
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
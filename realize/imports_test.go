@@ -0,0 +1,59 @@
+package realize
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestProject_Imports(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found")
+	}
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.mod":      "module example.com/app\n",
+		"lib/lib.go":  "package lib\n\nfunc Name() string { return \"lib\" }\n",
+		"cmd/main.go": "package main\n\nimport (\n\t\"fmt\"\n\t\"example.com/app/lib\"\n)\n\nfunc main() { fmt.Println(lib.Name()) }\n",
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p := Project{Path: filepath.Join(dir, "cmd"), Watcher: Watch{Paths: []string{"/"}}}
+	dirs, err := p.imports()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib, _ := filepath.EvalSymlinks(filepath.Join(dir, "lib"))
+	if len(dirs) != 1 {
+		t.Fatal("Unexpected imports", dirs)
+	}
+	if found, _ := filepath.EvalSymlinks(dirs[0]); found != lib {
+		t.Error("Unexpected import", dirs[0], lib)
+	}
+	// during a run the imports are sent to the watch loop
+	p.changes = make(chan pathChange, 1)
+	p.reimport(context.Background())
+	if c := <-p.changes; !c.imports || len(c.dirs) != 1 || len(p.paths) != 0 {
+		t.Error("Unexpected change", c, p.paths)
+	}
+}
+
+func TestInside(t *testing.T) {
+	if !inside("/a/b/c", "/a/b") || !inside("/a/b", "/a/b") {
+		t.Error("Unexpected result")
+	}
+	if inside("/a/bc", "/a/b") || inside("/a", "/a/b") || inside("/a", "") {
+		t.Error("Unexpected result")
+	}
+}
@@ -0,0 +1,115 @@
+package realize
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Include merges the configs listed by a config, the including one overrides them.
+// It returns the merged config and the number of included files.
+func include(file string, content []byte, seen map[string]bool) (config map[interface{}]interface{}, n int, err error) {
+	abs, _ := filepath.Abs(file)
+	if seen[abs] {
+		return nil, 0, errors.New("config " + file + " includes itself")
+	}
+	seen[abs] = true
+	defer delete(seen, abs)
+	if err = checkUnits(content); err != nil {
+		return nil, 0, errors.New(file + ": " + err.Error())
+	}
+	if err = yaml.Unmarshal(content, &config); err != nil {
+		return nil, 0, errors.New(file + ": " + err.Error())
+	}
+	var list []interface{}
+	switch v := config["include"].(type) {
+	case nil:
+		return config, 0, nil
+	case string:
+		list = []interface{}{v}
+	case []interface{}:
+		list = v
+	default:
+		return nil, 0, errors.New(file + ": include must be a list of files")
+	}
+	delete(config, "include")
+	base := map[interface{}]interface{}{}
+	for _, v := range list {
+		name, ok := v.(string)
+		if !ok {
+			return nil, 0, errors.New(file + ": include must be a list of files")
+		}
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(filepath.Dir(file), name)
+		}
+		content, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, 0, err
+		}
+		included, m, err := include(name, content, seen)
+		if err != nil {
+			return nil, 0, err
+		}
+		base = merge(base, included).(map[interface{}]interface{})
+		n += m + 1
+	}
+	return merge(base, config).(map[interface{}]interface{}), n, nil
+}
+
+// Merge two config values, maps are merged deeply and the lists of named items by name, the other values are replaced
+func merge(base, over interface{}) interface{} {
+	switch o := over.(type) {
+	case map[interface{}]interface{}:
+		b, ok := base.(map[interface{}]interface{})
+		if !ok {
+			return o
+		}
+		result := make(map[interface{}]interface{}, len(b)+len(o))
+		for k, v := range b {
+			result[k] = v
+		}
+		for k, v := range o {
+			result[k] = merge(b[k], v)
+		}
+		return result
+	case []interface{}:
+		b, ok := base.([]interface{})
+		if !ok || !named(b) || !named(o) {
+			return o
+		}
+		result := append([]interface{}{}, b...)
+	L:
+		for _, v := range o {
+			for i, w := range result {
+				if name(w) == name(v) {
+					result[i] = merge(w, v)
+					continue L
+				}
+			}
+			result = append(result, v)
+		}
+		return result
+	}
+	return over
+}
+
+// Named reports if all the items of a list are maps with a name
+func named(list []interface{}) bool {
+	for _, v := range list {
+		if name(v) == "" {
+			return false
+		}
+	}
+	return len(list) > 0
+}
+
+// Name of a config item, empty if it hasn't one
+func name(v interface{}) string {
+	if m, ok := v.(map[interface{}]interface{}); ok && m["name"] != nil {
+		return fmt.Sprint(m["name"])
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSettings_ReadInclude(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "api"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "base.yaml"), []byte(`
+settings:
+  history: true
+  legacy:
+    force: true
+    interval: 2s
+schema:
+- name: api
+  path: api
+  commands:
+    vet:
+      status: true
+  watcher:
+    extensions: [go]
+    paths: [/]
+    ignored_paths: [vendor]
+- name: worker
+  path: worker
+`), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "api", ".realize.yaml"), []byte(`
+include: [../base.yaml]
+settings:
+  legacy:
+    interval: 5s
+schema:
+- name: api
+  watcher:
+    extensions: [go, tmpl]
+- name: web
+  path: web
+`), 0644)
+	file := RFile
+	defer func() { RFile = file }()
+	RFile = filepath.Join(dir, "api", ".realize.yaml")
+	r := Realize{}
+	if err := r.Settings.Read(&r); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if !r.Settings.History || !r.Settings.Legacy.Force || r.Settings.Legacy.Interval.String() != "5s" {
+		t.Error("Unexpected settings", r.Settings)
+	}
+	if len(r.Projects) != 3 || r.Projects[0].Name != "api" || r.Projects[2].Name != "web" {
+		t.Fatal("Unexpected projects", r.Projects)
+	}
+	api := r.Projects[0]
+	if api.Path != "api" || !api.Tools.Vet.Status || !reflect.DeepEqual(api.Watcher.Exts, []string{"go", "tmpl"}) || !reflect.DeepEqual(api.Watcher.Ignore, []string{"vendor"}) {
+		t.Error("Unexpected project", api)
+	}
+	if r.Settings.Write(r) == nil {
+		t.Error("Expected an error writing a config with includes")
+	}
+	// cycle
+	ioutil.WriteFile(filepath.Join(dir, "base.yaml"), []byte("include: [api/.realize.yaml]"), 0644)
+	if err := r.Settings.Read(&r); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := map[interface{}]interface{}{"a": 1, "b": []interface{}{1, 2}, "c": map[interface{}]interface{}{"d": 1, "e": 2}}
+	over := map[interface{}]interface{}{"b": []interface{}{3}, "c": map[interface{}]interface{}{"e": 3}}
+	expected := map[interface{}]interface{}{"a": 1, "b": []interface{}{3}, "c": map[interface{}]interface{}{"d": 1, "e": 3}}
+	if result := merge(base, over); !reflect.DeepEqual(result, expected) {
+		t.Error("Unexpected merge", result)
+	}
+}
@@ -0,0 +1,201 @@
+package realize
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Entry of an indexed file
+type entry struct {
+	Mod  int64 `json:"mod"`
+	Size int64 `json:"size"`
+}
+
+// Indexed files of a project, stored with its config after each successful run
+type stored struct {
+	Config string           `json:"config"`
+	Files  map[string]entry `json:"files"`
+}
+
+// Index of the files of a project, the stored one is compared during the first walk
+type index struct {
+	mu      sync.Mutex
+	files   map[string]entry
+	stored  map[string]entry
+	changed []string
+	fresh   bool
+	config  string
+}
+
+// The index file is shared by the projects
+var indexMu sync.Mutex
+
+// Load the stored index of the project, the walk then runs the tools on the changed files only
+func (p *Project) loadIndex() {
+	// hashed before the first run, it may enable the install
+	p.index = &index{files: make(map[string]entry), config: p.config()}
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	all, err := readIndex(FileIndex)
+	if err != nil {
+		p.Err(err)
+		return
+	}
+	if s, ok := all[p.Name]; ok && s.Config == p.index.config {
+		p.index.stored = s.Files
+	}
+}
+
+// Indexed file, true when its tools are deferred after the walk
+func (p *Project) indexed(path string, info os.FileInfo) bool {
+	if p.index == nil {
+		return false
+	}
+	p.index.mu.Lock()
+	defer p.index.mu.Unlock()
+	if info.IsDir() {
+		return p.index.stored != nil
+	}
+	e := entry{Mod: info.ModTime().UnixNano(), Size: info.Size()}
+	p.index.files[path] = e
+	if p.index.stored == nil {
+		return false
+	}
+	if old, ok := p.index.stored[path]; !ok || old != e {
+		p.index.changed = append(p.index.changed, path)
+	}
+	return true
+}
+
+// Reindex runs the deferred tools on the changed files and their dirs, the first build is skipped without changes
+func (p *Project) reindex() {
+	if p.index == nil || p.index.stored == nil {
+		return
+	}
+	p.index.mu.Lock()
+	changed, removed := p.index.changed, 0
+	for path := range p.index.stored {
+		if _, ok := p.index.files[path]; !ok {
+			removed++
+		}
+	}
+	fresh := len(changed) == 0 && removed == 0
+	p.index.stored, p.index.changed, p.index.fresh = nil, nil, fresh
+	p.index.mu.Unlock()
+	dirs := make(map[string]bool)
+	for _, path := range changed {
+		if fi, err := os.Stat(path); err == nil {
+			p.tools(p.context(), path, fi)
+		}
+		dir := filepath.Dir(path)
+		if fi, err := os.Stat(dir); err == nil && !dirs[dir] {
+			dirs[dir] = true
+			p.tools(p.context(), dir, fi)
+		}
+	}
+	text := fmt.Sprint(len(changed), " changed and ", removed, " removed files since the last run")
+	if fresh {
+		text = "no changes since the last run"
+	}
+	msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Blue.Bold("Index"), text)
+	out := BufferOut{Time: time.Now(), Text: "Index " + text}
+	p.stamp("log", out, msg, "")
+}
+
+// Unchanged since the last successful run, only reported to the first run
+func (p *Project) unchanged() bool {
+	if p.index == nil {
+		return false
+	}
+	p.index.mu.Lock()
+	defer p.index.mu.Unlock()
+	fresh := p.index.fresh
+	p.index.fresh = false
+	return fresh
+}
+
+// Store the index after a successful run, the files of its batch are stated again, the changed ones updated and the removed ones dropped
+func (p *Project) storeIndex(paths ...string) error {
+	if p.index == nil {
+		return nil
+	}
+	// stated out of the lock, nil if removed
+	stats := make(map[string]*entry)
+	for _, path := range paths {
+		if path == "" || !p.Validate(path, false) {
+			continue
+		}
+		stats[path] = nil
+		if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+			stats[path] = &entry{Mod: fi.ModTime().UnixNano(), Size: fi.Size()}
+		}
+	}
+	p.index.mu.Lock()
+	for path, e := range stats {
+		if e == nil {
+			delete(p.index.files, path)
+			continue
+		}
+		p.index.files[path] = *e
+	}
+	config, files := p.index.config, make(map[string]entry, len(p.index.files))
+	for file, e := range p.index.files {
+		files[file] = e
+	}
+	p.index.mu.Unlock()
+	indexMu.Lock()
+	defer indexMu.Unlock()
+	all, err := readIndex(FileIndex)
+	if err != nil {
+		all = make(map[string]stored)
+	}
+	all[p.Name] = stored{Config: config, Files: files}
+	content, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(FileIndex, content, 0644)
+}
+
+// Config hash of a project, a changed config runs everything again
+func (p *Project) config() string {
+	content, _ := yaml.Marshal(p)
+	// the start overrides change the builds too
+	content = append(content, fmt.Sprint(p.Tools.Build.extra)...)
+	// and the named commands used by the scripts
+	resolved, _ := yaml.Marshal(p.Watcher.resolved)
+	content = append(content, resolved...)
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Read an index file, empty if it doesn't exist
+func readIndex(file string) (map[string]stored, error) {
+	all := make(map[string]stored)
+	content, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(content, &all); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+	return all, nil
+}
+
+// Exists reports if a file exists
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,88 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestProject_Index(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+	file := filepath.Join(dir, "main.go")
+	if err := ioutil.WriteFile(file, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := Realize{}
+	r.Settings.Index = true
+	// walk a new project, the tools are deferred with a stored index
+	walk := func(deferred bool) *Project {
+		p := &Project{parent: &r, Name: "a", Path: dir}
+		p.loadIndex()
+		info, _ := os.Stat(file)
+		if p.indexed(file, info) != deferred {
+			t.Error("Unexpected deferred tools", !deferred)
+		}
+		p.reindex()
+		return p
+	}
+	p := walk(false)
+	if p.unchanged() {
+		t.Error("Unexpected unchanged without a stored index")
+	}
+	if err := p.storeIndex(""); err != nil {
+		t.Fatal(err)
+	}
+	p = walk(true)
+	if !p.unchanged() {
+		t.Error("Expected unchanged")
+	}
+	if p.unchanged() {
+		t.Error("Unexpected unchanged, only the first run skips the build")
+	}
+	// a changed file
+	future := time.Now().Add(time.Hour)
+	os.Chtimes(file, future, future)
+	if p = walk(true); p.unchanged() {
+		t.Error("Unexpected unchanged after a change")
+	}
+	// only the files of the batch are stated again
+	p.Watcher.Exts = []string{"go"}
+	added := filepath.Join(dir, "util.go")
+	ioutil.WriteFile(added, []byte("package main\n"), 0644)
+	os.Remove(file)
+	p.storeIndex(added)
+	if _, ok := p.index.files[added]; !ok {
+		t.Error("Expected the added file indexed")
+	}
+	if _, ok := p.index.files[file]; !ok {
+		t.Error("Unexpected dropped file out of the batch")
+	}
+	p.storeIndex(file)
+	if _, ok := p.index.files[file]; ok {
+		t.Error("Unexpected removed file indexed")
+	}
+	ioutil.WriteFile(file, []byte("package main\n"), 0644)
+	os.Remove(added)
+	p.Watcher.Exts = nil
+	// a changed config
+	p.storeIndex("")
+	p = &Project{parent: &r, Name: "a", Path: dir, Args: []string{"--flag"}}
+	p.loadIndex()
+	if p.index.stored != nil {
+		t.Error("Unexpected stored index of another config")
+	}
+	// its own file isn't validated
+	p.Watcher.Exts = []string{"json"}
+	if p.Validate(filepath.Join(dir, FileIndex), false) {
+		t.Error("Unexpected validated index file")
+	}
+}
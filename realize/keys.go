@@ -0,0 +1,86 @@
+package realize
+
+import (
+	"fmt"
+	"log"
+	"os"
+)
+
+// Keys pressed while watching without the terminal ui
+type keys struct {
+	parent  *Realize
+	restore func()
+	done    chan bool
+}
+
+// Start reading the keys, only from a terminal not connected to a project
+func (k *keys) start() error {
+	restore, err := makeCbreak(os.Stdin.Fd())
+	if err != nil {
+		return err
+	}
+	k.restore = restore
+	k.done = make(chan bool)
+	log.Println(k.parent.Prefix("Keys: " + Magenta.Bold("r") + " restart, " + Magenta.Bold("c") + " clear, " + Magenta.Bold("p") + " pause, " + Magenta.Bold("q") + " quit"))
+	go k.read()
+	return nil
+}
+
+// Stop reading the keys and restore the terminal
+func (k *keys) stop() {
+	if k == nil || k.done == nil {
+		return
+	}
+	close(k.done)
+	k.restore()
+}
+
+// Read the keys until stop
+func (k *keys) read() {
+	buf := make([]byte, 1)
+	for {
+		if _, err := os.Stdin.Read(buf); err != nil {
+			return
+		}
+		select {
+		case <-k.done:
+			return
+		default:
+		}
+		k.key(buf[0])
+	}
+}
+
+// Key pressed: r restarts the projects, c clears the screen, p pauses or resumes the watching, q quits running the after commands
+func (k *keys) key(b byte) {
+	switch b {
+	case 'r':
+		for i := range k.parent.Schema.Projects {
+			p := &k.parent.Schema.Projects[i]
+			if err := p.send(ActionRestart); err != nil {
+				p.Err(err)
+			}
+		}
+	case 'c':
+		fmt.Fprint(os.Stdout, "\x1b[H\x1b[2J")
+	case 'p':
+		k.parent.toggle()
+	case 'q':
+		go k.parent.Stop()
+	}
+}
+
+// Stdin connected to a project, the keys aren't read
+func (r *Realize) stdin() bool {
+	for _, p := range r.Schema.Projects {
+		if p.Tools.Run.Stdin {
+			return true
+		}
+		for _, c := range p.Watcher.scripts() {
+			if c.Stdin {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package realize
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestKeys_Key(t *testing.T) {
+	r := Realize{}
+	r.Schema.Projects = []Project{{Name: "a", control: make(chan string, 1), exit: make(chan os.Signal, 1)}}
+	k := keys{parent: &r}
+	k.key('r')
+	if action := <-r.Schema.Projects[0].control; action != ActionRestart {
+		t.Error("Unexpected action", action)
+	}
+	k.key('p')
+	if action := <-r.Schema.Projects[0].control; action != ActionPause {
+		t.Error("Unexpected action", action)
+	}
+	k.key('q')
+	select {
+	case <-r.Schema.Projects[0].exit:
+	case <-time.After(time.Second):
+		t.Error("Expected the project stopped")
+	}
+}
+
+func TestRealize_Stdin(t *testing.T) {
+	r := Realize{}
+	r.Schema.Projects = []Project{{Name: "a"}}
+	if r.stdin() {
+		t.Error("Unexpected stdin")
+	}
+	r.Schema.Projects[0].Watcher.Scripts = []Command{{Cmd: "cat", Stdin: true}}
+	if !r.stdin() {
+		t.Error("Expected stdin")
+	}
+}
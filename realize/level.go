@@ -0,0 +1,62 @@
+package realize
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level of a message, messages under the verbosity aren't printed
+type Level int
+
+// Levels, the prefix colors map on them: magenta changes are debug, red are errors, the others are info
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// Names of the levels
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+// Levels of the stamp types
+var stampLevels = map[string]Level{
+	"debug": LevelDebug,
+	"log":   LevelInfo,
+	"out":   LevelInfo,
+	"warn":  LevelWarn,
+	"error": LevelError,
+	"state": LevelInfo,
+}
+
+// ParseLevel returns the level of a name, an empty name is debug
+func ParseLevel(name string) (Level, error) {
+	if name == "" {
+		return LevelDebug, nil
+	}
+	for i, v := range levelNames {
+		if strings.EqualFold(v, name) {
+			return Level(i), nil
+		}
+	}
+	return LevelDebug, fmt.Errorf("unknown verbosity %q, use %s", name, strings.Join(levelNames, ", "))
+}
+
+// String name of the level
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return "unknown"
+	}
+	return levelNames[l]
+}
+
+// MarshalText encodes the level name
+func (l Level) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// Verbose checks if a message of a level is printed
+func (s *Settings) verbose(l Level) bool {
+	v, _ := ParseLevel(s.Verbosity)
+	return l >= v
+}
@@ -0,0 +1,41 @@
+package realize
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLevel(t *testing.T) {
+	for name, expected := range map[string]Level{"": LevelDebug, "debug": LevelDebug, "INFO": LevelInfo, "warn": LevelWarn, "error": LevelError} {
+		if l, err := ParseLevel(name); err != nil || l != expected {
+			t.Error("Unexpected level", name, l, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected an error")
+	}
+	if LevelWarn.String() != "warn" {
+		t.Error("Unexpected name", LevelWarn.String())
+	}
+}
+
+func TestProject_StampVerbosity(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	r := Realize{Sync: make(chan string, 10)}
+	r.Settings.Verbosity = "warn"
+	r.Projects = append(r.Projects, Project{parent: &r})
+	p := &r.Projects[0]
+	p.stamp("debug", BufferOut{Time: time.Now(), Text: "changed"}, "changed\n", "")
+	p.stamp("log", BufferOut{Time: time.Now(), Text: "started"}, "started\n", "")
+	p.stamp("error", BufferOut{Time: time.Now(), Text: "failed"}, "failed\n", "")
+	if strings.Contains(buf.String(), "changed") || strings.Contains(buf.String(), "started") || !strings.Contains(buf.String(), "failed") {
+		t.Error("Unexpected output", buf.String())
+	}
+	if len(p.Buffer.StdLog) != 2 || len(p.Buffer.StdErr) != 1 {
+		t.Error("Expected all the messages in the buffer")
+	}
+}
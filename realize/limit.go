@@ -0,0 +1,51 @@
+package realize
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Kernel limit of the inotify watches of a user
+const maxWatches = "/proc/sys/fs/inotify/max_user_watches"
+
+// walkError is implemented by the watchers keeping the error of their last walk
+type walkError interface {
+	walked() error
+}
+
+// Unwatched path, the folders over the inotify limit are counted and reported after the walk, the other errors recorded in the watch set
+func (p *Project) unwatched(path string, info os.FileInfo) {
+	w, ok := p.watcher.(walkError)
+	switch {
+	case !ok || w.walked() == nil:
+	case errors.Is(w.walked(), syscall.ENOSPC):
+		if info != nil && info.IsDir() {
+			p.exhausted++
+		}
+	default:
+		p.set.fail(path, w.walked())
+	}
+}
+
+// Exhaust reports the folders not watched since the last report, with the limit and the remediation
+func (p *Project) exhaust() {
+	if p.exhausted == p.reported {
+		return
+	}
+	p.reported = p.exhausted
+	limit := "fs.inotify.max_user_watches"
+	if b, err := ioutil.ReadFile(maxWatches); err == nil {
+		limit += " is " + strings.TrimSpace(string(b))
+	}
+	text := fmt.Sprintf("inotify watch limit reached, %d of %d folders aren't watched: %s, raise it with "+
+		"sudo sysctl fs.inotify.max_user_watches=524288 or narrow the watched paths with ignored_paths and max_depth",
+		p.exhausted, p.folders+p.exhausted, limit)
+	msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Regular(text))
+	out := BufferOut{Time: time.Now(), Text: text}
+	p.stamp("error", out, msg, "")
+}
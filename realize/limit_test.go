@@ -0,0 +1,49 @@
+package realize
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+type limitWatcher struct {
+	FileWatcher
+	err error
+}
+
+func (w *limitWatcher) walked() error {
+	return w.err
+}
+
+func TestProject_Exhaust(t *testing.T) {
+	dir, _ := os.Getwd()
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &limitWatcher{}
+	p := Project{parent: &Realize{}, Name: "a", watcher: w, folders: 2}
+	p.unwatched(dir, info)
+	w.err = syscall.EMFILE
+	p.unwatched(dir, info)
+	p.exhaust()
+	if p.exhausted != 0 || len(p.Buffer.StdErr) != 0 {
+		t.Error("Unexpected report", p.Buffer.StdErr)
+	}
+	w.err = syscall.ENOSPC
+	p.unwatched(dir, info)
+	p.exhaust()
+	if len(p.Buffer.StdErr) != 1 {
+		t.Fatal("Expected a report")
+	}
+	text := p.Buffer.StdErr[0].Text
+	if !strings.Contains(text, "1 of 3 folders") || !strings.Contains(text, "fs.inotify.max_user_watches") {
+		t.Error("Unexpected report", text)
+	}
+	// once per walk
+	p.exhaust()
+	if len(p.Buffer.StdErr) != 1 {
+		t.Error("Unexpected report", p.Buffer.StdErr)
+	}
+}
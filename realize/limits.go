@@ -0,0 +1,65 @@
+package realize
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits of a command, a lower priority and the resources it may use.
+// They're applied to the pid of the started command, not before its exec, so they bind its children only once applied.
+type Limits struct {
+	Nice   int           `yaml:"nice,omitempty" json:"nice,omitempty"`
+	IOnice string        `yaml:"ionice,omitempty" json:"ionice,omitempty"`
+	Memory string        `yaml:"memory,omitempty" json:"memory,omitempty"`
+	CPU    time.Duration `yaml:"cpu,omitempty" json:"cpu,omitempty"`
+}
+
+// IO scheduling classes of ionice
+const (
+	ioRealtime = iota + 1
+	ioBestEffort
+	ioIdle
+)
+
+// Check the limits, they are applied when the command starts
+func (l *Limits) check() error {
+	if l.Nice < -20 || l.Nice > 19 {
+		return errors.New("nice " + strconv.Itoa(l.Nice) + " out of range -20 - 19")
+	}
+	if _, _, err := ioClass(l.IOnice); err != nil {
+		return err
+	}
+	if _, err := size(l.Memory); err != nil {
+		return err
+	}
+	if l.CPU < 0 {
+		return errors.New("negative cpu limit " + l.CPU.String())
+	}
+	return nil
+}
+
+// IO class and level of ionice: idle, best-effort or best-effort:0-7
+func ioClass(value string) (class, level int, err error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	name, data := value, ""
+	if i := strings.Index(value, ":"); i >= 0 {
+		name, data = value[:i], value[i+1:]
+	}
+	switch name {
+	case "":
+		return 0, 0, nil
+	case "idle":
+		return ioIdle, 0, nil
+	case "best-effort":
+		level = 4
+		if data != "" {
+			if level, err = strconv.Atoi(data); err != nil || level < 0 || level > 7 {
+				return 0, 0, errors.New("invalid ionice level " + data + ", use 0-7")
+			}
+		}
+		return ioBestEffort, level, nil
+	}
+	return 0, 0, errors.New("unknown ionice class " + value + ", use idle or best-effort:0-7")
+}
@@ -0,0 +1,45 @@
+// +build linux
+
+package realize
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Apply the limits to a started process, only its pid is changed: the children it forks later inherit them,
+// the ones forked before keep the limits of realize
+func (l *Limits) apply(pid int) error {
+	if l.Nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, l.Nice); err != nil {
+			return os.NewSyscallError("setpriority", err)
+		}
+	}
+	if class, level, _ := ioClass(l.IOnice); class != 0 {
+		// ioprio_set(IOPRIO_WHO_PROCESS, pid, class << IOPRIO_CLASS_SHIFT | level)
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, 1, uintptr(pid), uintptr(class<<13|level)); errno != 0 {
+			return os.NewSyscallError("ioprio_set", errno)
+		}
+	}
+	if memory, _ := size(l.Memory); memory > 0 {
+		if err := prlimit(pid, syscall.RLIMIT_AS, memory); err != nil {
+			return err
+		}
+	}
+	if l.CPU > 0 {
+		if err := prlimit(pid, syscall.RLIMIT_CPU, uint64(l.CPU.Seconds())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Prlimit sets a resource limit of another process
+func prlimit(pid int, resource int, value uint64) error {
+	lim := struct{ cur, max uint64 }{value, value}
+	if _, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), uintptr(resource), uintptr(unsafe.Pointer(&lim)), 0, 0, 0); errno != 0 {
+		return os.NewSyscallError("prlimit", errno)
+	}
+	return nil
+}
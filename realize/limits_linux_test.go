@@ -0,0 +1,23 @@
+// +build linux
+
+package realize
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCommand_Limits(t *testing.T) {
+	c := Command{
+		Cmd:    "sleep 0.2; cut -d' ' -f19 /proc/$$/stat; grep 'Max address space' /proc/$$/limits",
+		Shell:  true,
+		Limits: &Limits{Nice: 5, Memory: "1G"},
+	}
+	r := c.exec(context.Background(), os.TempDir())
+	lines := strings.Split(strings.TrimSpace(r.Out), "\n")
+	if r.Err != nil || len(lines) != 2 || lines[0] != "5" || !strings.Contains(lines[1], "1073741824") {
+		t.Error("Unexpected limits", r.Out, r.Err)
+	}
+}
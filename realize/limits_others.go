@@ -0,0 +1,23 @@
+// +build !linux,!windows
+
+package realize
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// Apply the limits to a started process, only nice is supported
+func (l *Limits) apply(pid int) error {
+	if l.IOnice != "" || l.Memory != "" || l.CPU > 0 {
+		return errors.New("ionice, memory and cpu limits aren't supported on " + runtime.GOOS)
+	}
+	if l.Nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, l.Nice); err != nil {
+			return os.NewSyscallError("setpriority", err)
+		}
+	}
+	return nil
+}
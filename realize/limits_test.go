@@ -0,0 +1,32 @@
+package realize
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimits_Check(t *testing.T) {
+	l := Limits{Nice: 10, IOnice: "best-effort:7", Memory: "512M", CPU: time.Minute}
+	if err := l.check(); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	for _, l := range []Limits{{Nice: 20}, {IOnice: "realtime"}, {IOnice: "best-effort:8"}, {Memory: "2T"}, {CPU: -time.Second}} {
+		if err := l.check(); err == nil {
+			t.Error("Expected an error", l)
+		}
+	}
+}
+
+func TestSize(t *testing.T) {
+	for value, n := range map[string]uint64{"": 0, "1024": 1024, "4k": 4 << 10, "512M": 512 << 20, " 2G": 2 << 30} {
+		if s, err := size(value); err != nil || s != n {
+			t.Error("Unexpected size", value, s, err)
+		}
+	}
+	if class, level, err := ioClass("Idle"); err != nil || class != ioIdle || level != 0 {
+		t.Error("Unexpected io class", class, level, err)
+	}
+	if class, level, err := ioClass("best-effort"); err != nil || class != ioBestEffort || level != 4 {
+		t.Error("Unexpected io class", class, level, err)
+	}
+}
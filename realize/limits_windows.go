@@ -0,0 +1,10 @@
+// +build windows
+
+package realize
+
+import "errors"
+
+// Apply the limits to a started process, not supported
+func (l *Limits) apply(pid int) error {
+	return errors.New("limits aren't supported on windows")
+}
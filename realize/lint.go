@@ -0,0 +1,41 @@
+package realize
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Lint scopes, the packages of the changed dirs or all the packages
+const (
+	ScopeChanged = "changed"
+	ScopeAll     = "all"
+)
+
+// Linter command, golangci-lint when it's installed
+func linter(gocmd string) []string {
+	if _, err := exec.LookPath("golangci-lint"); err == nil {
+		return []string{"golangci-lint", "run"}
+	}
+	return []string{gocmd, "vet"}
+}
+
+// Lint the package of a dir, or all of them, from the project path so the positions are relative to it
+func (t *Tool) lintDir(ctx context.Context, dir string) Response {
+	base := t.workdir(t.parent.Path)
+	pkg := "./..."
+	if t.Scope != ScopeAll {
+		abs, _ := filepath.Abs(dir)
+		rel, err := filepath.Rel(base, abs)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			pkg = abs
+		} else {
+			pkg = "./" + filepath.ToSlash(rel)
+		}
+	} else if !t.parent.init {
+		// all the packages are linted on changes, not for each indexed dir
+		return Response{}
+	}
+	return t.exec(ctx, base, append(append([]string{}, t.Args...), pkg))
+}
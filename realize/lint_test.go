@@ -0,0 +1,71 @@
+package realize
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTool_Lint(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found")
+	}
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dir, _ = filepath.EvalSymlinks(dir)
+	files := map[string]string{
+		"go.mod":       "module example.com/app\n",
+		"api/api.go":   "package api\n\nimport \"fmt\"\n\nfunc Name() string { return fmt.Sprintf(\"%d\", \"api\") }\n",
+		"other/lib.go": "package other\n\nimport \"fmt\"\n\nfunc Name() string { return fmt.Sprintf(\"%d\", \"other\") }\n",
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{Path: dir, parent: &r})
+	p := &r.Projects[0]
+	p.Tools.Lint = Tool{Status: true, Method: "go vet"}
+	p.Tools.Setup()
+	p.Tools.bind(p)
+	response := p.Tools.Lint.Exec(context.Background(), filepath.Join(dir, "api", "api.go"))
+	p.diagnose(&response)
+	// only the changed dir is linted
+	if response.Err == nil || len(response.Diagnostics) != 1 {
+		t.Fatal("Unexpected response", response.Err, response.Diagnostics)
+	}
+	d := response.Diagnostics[0]
+	if d.File != filepath.Join(dir, "api", "api.go") || d.Line != 5 || d.Severity != SeverityWarning {
+		t.Error("Unexpected diagnostic", d)
+	}
+	// all the packages are linted on changes only
+	p.Tools.Lint.Scope = ScopeAll
+	if response = p.Tools.Lint.Exec(context.Background(), filepath.Join(dir, "api")); response.Err != nil {
+		t.Error("Unexpected lint while indexing", response.Err)
+	}
+	p.init = true
+	response = p.Tools.Lint.Exec(context.Background(), filepath.Join(dir, "api"))
+	if response.Err == nil || !strings.Contains(response.Err.Error(), "other") {
+		t.Error("Unexpected response", response.Err)
+	}
+}
+
+func TestLinter(t *testing.T) {
+	cmd := linter("go")
+	if _, err := exec.LookPath("golangci-lint"); err == nil {
+		if strings.Join(cmd, " ") != "golangci-lint run" {
+			t.Error("Unexpected linter", cmd)
+		}
+	} else if strings.Join(cmd, " ") != "go vet" {
+		t.Error("Unexpected linter", cmd)
+	}
+}
@@ -0,0 +1,11 @@
+// +build !darwin !cgo
+// +build !windows
+
+package realize
+
+import "errors"
+
+// Native watcher, only darwin and windows have one
+func nativeWatcher() (FileWatcher, error) {
+	return nil, errors.New("no native watcher")
+}
@@ -34,6 +34,8 @@ type (
 	// fsNotifyWatcher wraps the fsnotify package to satisfy the FileNotifier interface
 	fsNotifyWatcher struct {
 		*fsnotify.Watcher
+		// err of the last walk
+		err error
 	}
 	// filePoller is used to poll files for changes, especially in cases where fsnotify
 	// can't be run (e.g. when inotify handles are exhausted)
@@ -76,8 +78,11 @@ func NewFileWatcher(l Legacy) (FileWatcher, error) {
 	return PollingWatcher(l.Interval), nil
 }
 
-// EventWatcher returns an fs-event based file watcher
+// EventWatcher returns an fs-event based file watcher, the native one of the platform if any
 func EventWatcher() (FileWatcher, error) {
+	if w, err := nativeWatcher(); err == nil {
+		return w, nil
+	}
 	w, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, err
@@ -97,12 +102,17 @@ func (w *fsNotifyWatcher) Events() <-chan fsnotify.Event {
 
 // Walk fsnotify
 func (w *fsNotifyWatcher) Walk(path string, init bool) string {
-	if err := w.Add(path); err != nil {
+	if w.err = w.Add(path); w.err != nil {
 		return ""
 	}
 	return path
 }
 
+// Error of the last walk
+func (w *fsNotifyWatcher) walked() error {
+	return w.err
+}
+
 // Close closes the poller
 // All watches are stopped, removed, and the poller cannot be added to
 func (w *filePoller) Close() error {
@@ -0,0 +1,192 @@
+package realize
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// notification status
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+type (
+	// Notifier is implemented by each notification backend
+	Notifier interface {
+		Notify(Notification) error
+	}
+
+	// Notification sent to the backends
+	Notification struct {
+		Project string    `json:"project"`
+		Status  string    `json:"status"`
+		Text    string    `json:"text"`
+		Time    time.Time `json:"time"`
+	}
+
+	// Notify defines the notification backends and the policy applied to all of them
+	Notify struct {
+		Policy    Policy    `yaml:"policy,omitempty" json:"policy,omitempty"`
+		Webhooks  []Webhook `yaml:"webhooks,omitempty" json:"webhooks,omitempty"`
+		notifiers []Notifier
+		state     *notifyState
+		ready     bool
+	}
+
+	// Policy limits the notifications sent by the backends
+	Policy struct {
+		MaxPerHour  int   `yaml:"max_per_hour,omitempty" json:"max_per_hour,omitempty"`
+		Quiet       Quiet `yaml:"quiet,omitempty" json:"quiet,omitempty"`
+		Transitions bool  `yaml:"transitions,omitempty" json:"transitions,omitempty"`
+	}
+
+	// Quiet hours, notifications are muted between from and to (hh:mm)
+	Quiet struct {
+		From string `yaml:"from,omitempty" json:"from,omitempty"`
+		To   string `yaml:"to,omitempty" json:"to,omitempty"`
+	}
+
+	// background sends the notifications of a backend without waiting it, a slow url doesn't hold the run
+	background struct {
+		Notifier
+	}
+
+	// notifyState keeps track of sent notifications
+	notifyState struct {
+		mu   sync.Mutex
+		sent []time.Time
+		last map[string]string
+	}
+)
+
+// Add a notification backend
+func (n *Notify) Add(notifier Notifier) {
+	n.notifiers = append(n.notifiers, notifier)
+}
+
+// Setup the configured backends once, the webhooks post in background within their timeout
+func (n *Notify) setup() {
+	if n.ready {
+		return
+	}
+	n.ready = true
+	if n.state == nil {
+		n.state = &notifyState{}
+	}
+	for i := range n.Webhooks {
+		if err := n.Webhooks[i].validate(); err != nil {
+			log.Println(Red.Bold("Notification error:"), err)
+			continue
+		}
+		n.Add(background{&n.Webhooks[i]})
+	}
+	// a notify set without a valid backend would be silent
+	if len(n.notifiers) == 0 && (len(n.Webhooks) > 0 || n.Policy != Policy{}) {
+		log.Println(Red.Bold("Notification error:"), "notify is set but no backend is valid, the notifications are disabled")
+	}
+}
+
+// Notify in background, the errors are logged
+func (b background) Notify(notification Notification) error {
+	go func() {
+		if err := b.Notifier.Notify(notification); err != nil {
+			log.Println(Red.Bold("Notification error:"), err)
+		}
+	}()
+	return nil
+}
+
+// Send a notification to all the backends if allowed by the policy
+func (n *Notify) Send(notification Notification) {
+	if len(n.notifiers) == 0 {
+		return
+	}
+	if notification.Time.IsZero() {
+		notification.Time = time.Now()
+	}
+	if !n.allow(notification) {
+		return
+	}
+	for _, notifier := range n.notifiers {
+		if err := notifier.Notify(notification); err != nil {
+			log.Println(Red.Bold("Notification error:"), err)
+		}
+	}
+}
+
+// Allow checks a notification against the policy
+func (n *Notify) allow(notification Notification) bool {
+	if n.state == nil {
+		n.state = &notifyState{}
+	}
+	s := n.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// state transitions
+	if s.last == nil {
+		s.last = make(map[string]string)
+	}
+	last, exist := s.last[notification.Project]
+	s.last[notification.Project] = notification.Status
+	if n.Policy.Transitions && exist && last == notification.Status {
+		return false
+	}
+	// quiet hours
+	if n.Policy.Quiet.mute(notification.Time) {
+		return false
+	}
+	// max per hour
+	if n.Policy.MaxPerHour > 0 {
+		hour := notification.Time.Add(-time.Hour)
+		sent := s.sent[:0]
+		for _, t := range s.sent {
+			if t.After(hour) {
+				sent = append(sent, t)
+			}
+		}
+		s.sent = sent
+		if len(s.sent) >= n.Policy.MaxPerHour {
+			return false
+		}
+	}
+	s.sent = append(s.sent, notification.Time)
+	return true
+}
+
+// Mute checks if a time is within the quiet hours
+func (q Quiet) mute(t time.Time) bool {
+	from, err := clock(q.From)
+	if err != nil {
+		return false
+	}
+	to, err := clock(q.To)
+	if err != nil {
+		return false
+	}
+	now := t.Hour()*60 + t.Minute()
+	if from <= to {
+		return now >= from && now < to
+	}
+	// over midnight
+	return now >= from || now < to
+}
+
+// Clock converts a hh:mm string in minutes
+func clock(value string) (int, error) {
+	parts := strings.SplitN(value, ":", 2)
+	h, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, err
+	}
+	m := 0
+	if len(parts) > 1 {
+		if m, err = strconv.Atoi(parts[1]); err != nil {
+			return 0, err
+		}
+	}
+	return h*60 + m, nil
+}
@@ -0,0 +1,117 @@
+package realize
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type mockNotifier struct {
+	sent []Notification
+}
+
+func (m *mockNotifier) Notify(n Notification) error {
+	m.sent = append(m.sent, n)
+	return nil
+}
+
+func TestNotify_Send(t *testing.T) {
+	m := mockNotifier{}
+	n := Notify{Policy: Policy{MaxPerHour: 2}}
+	n.Add(&m)
+	for i := 0; i < 3; i++ {
+		n.Send(Notification{Project: "test", Status: StatusFailure})
+	}
+	if len(m.sent) != 2 {
+		t.Error("Expected 2 notifications instead", len(m.sent))
+	}
+}
+
+func TestNotify_Transitions(t *testing.T) {
+	m := mockNotifier{}
+	n := Notify{Policy: Policy{Transitions: true}}
+	n.Add(&m)
+	n.Send(Notification{Project: "test", Status: StatusFailure})
+	n.Send(Notification{Project: "test", Status: StatusFailure})
+	n.Send(Notification{Project: "test", Status: StatusSuccess})
+	n.Send(Notification{Project: "other", Status: StatusSuccess})
+	if len(m.sent) != 3 {
+		t.Error("Expected 3 notifications instead", len(m.sent))
+	}
+}
+
+func TestQuiet_Mute(t *testing.T) {
+	data := map[string]bool{
+		"23:30": true,
+		"07:59": true,
+		"08:00": false,
+		"12:00": false,
+	}
+	q := Quiet{From: "22:00", To: "08:00"}
+	for i, v := range data {
+		now, _ := time.Parse("15:04", i)
+		if q.mute(now) != v {
+			t.Error("Unexpected result", i, "expected", v)
+		}
+	}
+	if (Quiet{}).mute(time.Now()) {
+		t.Error("Unexpected mute without quiet hours")
+	}
+}
+
+func TestNotify_Setup(t *testing.T) {
+	received := make(chan bool, 4)
+	release := make(chan bool)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- true
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+	n := Notify{Webhooks: []Webhook{{URL: ts.URL}}}
+	n.setup()
+	n.setup()
+	if len(n.notifiers) != 1 {
+		t.Error("Unexpected notifiers", len(n.notifiers))
+	}
+	// a slow webhook doesn't hold the sender
+	done := make(chan bool)
+	go func() {
+		n.Send(Notification{Project: "test", Status: StatusFailure})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Expected a notification in background")
+	}
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Error("Expected a webhook request")
+	}
+}
+
+func TestNotify_Invalid(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	n := Notify{Webhooks: []Webhook{{URL: "hooks.local/notify"}, {URL: "http://hooks.local", Template: "{{"}}}
+	n.setup()
+	if len(n.notifiers) != 0 {
+		t.Error("Unexpected notifiers", len(n.notifiers))
+	}
+	if !strings.Contains(buf.String(), "no backend is valid") {
+		t.Error("Expected a warning without a valid backend", buf.String())
+	}
+	buf.Reset()
+	(&Notify{}).setup()
+	if buf.Len() > 0 {
+		t.Error("Unexpected warning without notify", buf.String())
+	}
+}
@@ -0,0 +1,37 @@
+package realize
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Ops of the file events by name
+var ops = map[string]fsnotify.Op{
+	"create": fsnotify.Create,
+	"write":  fsnotify.Write,
+	"rename": fsnotify.Rename,
+	"remove": fsnotify.Remove,
+	"chmod":  fsnotify.Chmod,
+}
+
+// Mask of the ops that trigger a reload, all of them but chmod if the list is empty
+func mask(names []string) (fsnotify.Op, error) {
+	if len(names) == 0 {
+		return fsnotify.Create | fsnotify.Write | fsnotify.Rename | fsnotify.Remove, nil
+	}
+	var m fsnotify.Op
+	var unknown []string
+	for _, name := range names {
+		if op, ok := ops[strings.ToLower(strings.TrimSpace(name))]; ok {
+			m |= op
+		} else {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return m, errors.New("unknown watch ops " + strings.Join(unknown, ", ") + ", use create, write, rename, remove or chmod")
+	}
+	return m, nil
+}
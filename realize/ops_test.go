@@ -0,0 +1,22 @@
+package realize
+
+import (
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestMask(t *testing.T) {
+	m, err := mask(nil)
+	if err != nil || m&fsnotify.Chmod != 0 || m&fsnotify.Write == 0 {
+		t.Error("Unexpected default mask", m, err)
+	}
+	m, err = mask([]string{"write", " Create"})
+	if err != nil || m != fsnotify.Write|fsnotify.Create {
+		t.Error("Unexpected mask", m, err)
+	}
+	m, err = mask([]string{"write", "touch"})
+	if err == nil || m != fsnotify.Write {
+		t.Error("Expected an error", m, err)
+	}
+}
@@ -0,0 +1,98 @@
+package realize
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OutputMode of a command, the failures are always recorded and notified
+type OutputMode string
+
+// Output modes: every line, the failures only, a line for each run or nothing
+const (
+	OutputAll     OutputMode = "all"
+	OutputErrors  OutputMode = "errors"
+	OutputSummary OutputMode = "summary"
+	OutputNone    OutputMode = "none"
+)
+
+// Parse an output mode, true and false as all and errors for the old configs
+func (m *OutputMode) parse(value interface{}) error {
+	switch v := value.(type) {
+	case bool:
+		*m = OutputErrors
+		if v {
+			*m = OutputAll
+		}
+		return nil
+	case string:
+		switch mode := OutputMode(strings.ToLower(strings.TrimSpace(v))); mode {
+		case "", OutputAll, OutputErrors, OutputSummary, OutputNone:
+			*m = mode
+			return nil
+		}
+		return errors.New("unknown output mode " + v + ", use all, errors, summary or none")
+	}
+	return fmt.Errorf("invalid output mode %v", value)
+}
+
+// UnmarshalYAML of a mode or a bool
+func (m *OutputMode) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value interface{}
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	return m.parse(value)
+}
+
+// UnmarshalJSON of a mode or a bool
+func (m *OutputMode) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	return m.parse(value)
+}
+
+// Shows the output lines of a run, all by default
+func (m OutputMode) shows(failed bool) bool {
+	switch m {
+	case OutputNone:
+		return false
+	case OutputErrors, OutputSummary:
+		return failed
+	}
+	return true
+}
+
+// Report the result of a command by its output mode, its output lines are highlighted
+func (p *Project) report(flag string, c Command, r Response) {
+	label := c.label()
+	msg := fmt.Sprintln(p.pname(p.Name, 5), ":", Green.Bold("Command"), Green.Bold("\"")+r.Name+Green.Bold("\""))
+	if r.Err != nil {
+		out := BufferOut{Time: time.Now(), Text: r.Err.Error(), Type: flag, ID: r.ID}
+		p.fail(r)
+		if c.Output == OutputNone {
+			// recorded without printing
+			m := p.message("error", out, "", "")
+			m.retained = true
+			p.publish(m)
+			return
+		}
+		p.stamp("error", out, msg, p.prefixed(label, r.Err.Error(), true))
+		return
+	}
+	out := BufferOut{Time: time.Now(), Text: r.Out, Type: flag, ID: r.ID}
+	switch c.Output {
+	case OutputNone, OutputErrors:
+	case OutputSummary:
+		n := len(lines(r.Out))
+		msg = fmt.Sprintln(p.pname(p.Name, 5), ":", Green.Bold("Command"), Green.Bold("\"")+r.Name+Green.Bold("\""), Magenta.Regular(n), "lines")
+		p.stamp("log", out, msg, "")
+	default:
+		p.stamp("log", out, msg, p.prefixed(label, paint(c.Rules, r.Out), false))
+	}
+}
@@ -0,0 +1,53 @@
+package realize
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestOutputMode_Unmarshal(t *testing.T) {
+	for value, mode := range map[string]OutputMode{"true": OutputAll, "false": OutputErrors, "summary": OutputSummary, "None": OutputNone} {
+		var c Command
+		if err := yaml.Unmarshal([]byte("output: "+value), &c); err != nil || c.Output != mode {
+			t.Error("Unexpected mode", value, c.Output, err)
+		}
+	}
+	var c Command
+	if err := yaml.Unmarshal([]byte("output: quiet"), &c); err == nil {
+		t.Error("Expected an error")
+	}
+	if err := json.Unmarshal([]byte(`{"output":true}`), &c); err != nil || c.Output != OutputAll {
+		t.Error("Unexpected mode", c.Output, err)
+	}
+	if err := json.Unmarshal([]byte(`{"output":"errors"}`), &c); err != nil || c.Output != OutputErrors {
+		t.Error("Unexpected mode", c.Output, err)
+	}
+}
+
+func TestProject_Report(t *testing.T) {
+	r := Realize{}
+	p := Project{parent: &r, Name: "test"}
+	ok := Response{Name: "webpack", Out: "a\nb\n"}
+	failed := Response{Name: "webpack", Err: errors.New("failed")}
+	for mode, n := range map[OutputMode]int{OutputAll: 1, OutputSummary: 1, OutputErrors: 0, OutputNone: 0} {
+		p.Buffer.StdLog = nil
+		p.report("after", Command{Cmd: "webpack", Output: mode}, ok)
+		if len(p.Buffer.StdLog) != n {
+			t.Error("Unexpected messages", mode, p.Buffer.StdLog)
+		}
+	}
+	// the failures are always recorded
+	for _, mode := range []OutputMode{OutputAll, OutputNone} {
+		p.Buffer.StdErr, p.outcome = nil, ""
+		p.report("after", Command{Cmd: "webpack", Output: mode}, failed)
+		if len(p.Buffer.StdErr) != 1 || p.outcome != StatusFailure {
+			t.Error("Expected a failure", mode, p.Buffer.StdErr)
+		}
+	}
+	if OutputNone.shows(true) || !OutputErrors.shows(true) || OutputSummary.shows(false) || !OutputMode("").shows(false) {
+		t.Error("Unexpected shown output")
+	}
+}
@@ -0,0 +1,104 @@
+package realize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Overlap policies of a change during a run, cancel stops the run and reloads once it returned,
+// queue reloads once more after the run and ignore skips the change
+const (
+	OverlapCancel = "cancel"
+	OverlapQueue  = "queue"
+	OverlapIgnore = "ignore"
+)
+
+// Overlap policy of the watcher, unknown policies cancel
+func (w *Watch) overlap() string {
+	switch policy := strings.ToLower(strings.TrimSpace(w.Overlap)); policy {
+	case OverlapQueue, OverlapIgnore:
+		return policy
+	}
+	return OverlapCancel
+}
+
+// Overlap checks the policy
+func overlap(policy string) error {
+	switch strings.ToLower(strings.TrimSpace(policy)) {
+	case "", OverlapCancel, OverlapQueue, OverlapIgnore:
+		return nil
+	}
+	return errors.New("unknown overlap policy " + policy + ", use cancel, queue or ignore")
+}
+
+// Reloading is a reload waiting the end of the run in progress
+type reloading struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	path   string
+}
+
+// Reload with a new context, a run in progress is canceled, waited or kept as the overlap policy, a forced reload always cancels it.
+// The reloads never overlap, the next one begins once the run returned and the changes waiting are merged in a single one.
+func (p *Project) reload(ctx context.Context, cancel context.CancelFunc, path string, forced bool) {
+	next := &reloading{ctx: ctx, cancel: cancel, path: path}
+	if p.busy == nil {
+		if p.cancel != nil {
+			p.cancel()
+		}
+		p.begin(next)
+		return
+	}
+	policy := p.Watcher.overlap()
+	if forced {
+		policy = OverlapCancel
+	}
+	switch policy {
+	case OverlapIgnore:
+		cancel()
+		msg := fmt.Sprintln(p.pname(p.Name, 4), ":", Magenta.Bold("Run in progress"), "change ignored", Magenta.Bold(path))
+		out := BufferOut{Time: time.Now(), Text: "Run in progress, change ignored " + path}
+		p.stamp("debug", out, msg, "")
+		return
+	case OverlapCancel:
+		p.cancel()
+	}
+	if p.queued != nil {
+		var changed pending
+		for _, file := range append(batch(p.queued.ctx, p.queued.path), batch(ctx, path)...) {
+			changed = changed.add(file)
+		}
+		next.ctx = context.WithValue(ctx, batchKey{}, []string(changed))
+		if next.path == "" {
+			next.path = p.queued.path
+		}
+		p.queued.cancel()
+	}
+	p.queued = next
+}
+
+// Begin a reload, its context is the current one
+func (p *Project) begin(r *reloading) {
+	p.ctx, p.cancel = r.ctx, r.cancel
+	done := make(chan bool)
+	p.busy = done
+	go func() {
+		p.Reload(r.ctx, r.path)
+		close(done)
+	}()
+}
+
+// Ended run, its context is released and the reload waiting begins
+func (p *Project) ended() {
+	p.busy = nil
+	if next := p.queued; next != nil {
+		p.queued = nil
+		if p.cancel != nil {
+			p.cancel()
+		}
+		p.begin(next)
+	}
+}
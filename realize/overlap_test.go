@@ -0,0 +1,109 @@
+package realize
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOverlap(t *testing.T) {
+	for _, policy := range []string{"", "cancel", " Queue", "ignore"} {
+		if err := overlap(policy); err != nil {
+			t.Error("Unexpected error", policy, err)
+		}
+	}
+	if err := overlap("restart"); err == nil {
+		t.Error("Expected an error")
+	}
+	w := Watch{Overlap: "Queue"}
+	if w.overlap() != OverlapQueue {
+		t.Error("Unexpected overlap policy", w.overlap())
+	}
+	w = Watch{Overlap: "restart"}
+	if w.overlap() != OverlapCancel {
+		t.Error("Unexpected overlap policy", w.overlap())
+	}
+}
+
+func TestProject_Overlap(t *testing.T) {
+	reloaded := make(chan Context, 4)
+	release := make(chan bool)
+	r := Realize{Reload: func(c Context) {
+		reloaded <- c
+		<-release
+	}}
+	r.Projects = append(r.Projects, Project{parent: &r})
+	p := &r.Projects[0]
+	next := func() Context {
+		select {
+		case c := <-reloaded:
+			return c
+		case <-time.After(time.Second):
+			t.Fatal("Expected a reload")
+		}
+		return Context{}
+	}
+	overlapped := func() {
+		select {
+		case c := <-reloaded:
+			t.Error("Unexpected overlapping reload", c.Path)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	end := func() {
+		release <- true
+		<-p.busy
+		p.ended()
+	}
+	// the changes during the run are merged in a reload after it
+	p.Watcher.Overlap = OverlapQueue
+	p.restart("a.go")
+	first := next()
+	p.restart("b.go")
+	p.restart("c.go")
+	overlapped()
+	if first.Ctx.Err() != nil {
+		t.Error("Unexpected canceled run")
+	}
+	end()
+	if first.Ctx.Err() == nil {
+		t.Error("Expected the context of the ended run released")
+	}
+	second := next()
+	if second.Path != "c.go" || !reflect.DeepEqual(batch(second.Ctx, second.Path), []string{"b.go", "c.go"}) {
+		t.Error("Unexpected queued reload", second.Path, batch(second.Ctx, second.Path))
+	}
+	// the run is canceled, the reload begins once it returned
+	p.Watcher.Overlap = OverlapCancel
+	p.restart("d.go")
+	if second.Ctx.Err() == nil {
+		t.Error("Expected a canceled run")
+	}
+	overlapped()
+	end()
+	if third := next(); third.Path != "d.go" || third.Ctx.Err() != nil {
+		t.Error("Unexpected reload", third.Path)
+	}
+	// the change is skipped, a forced reload cancels the run anyway
+	p.Watcher.Overlap = OverlapIgnore
+	p.restart("e.go")
+	if p.queued != nil || p.ctx.Err() != nil {
+		t.Error("Unexpected queued reload")
+	}
+	running := p.ctx
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.reload(ctx, cancel, "", true)
+	if running.Err() == nil || p.queued == nil {
+		t.Error("Expected a forced reload")
+	}
+	end()
+	if c := next(); c.Ctx != ctx || c.Ctx.Err() != nil {
+		t.Error("Unexpected forced reload", c.Path)
+	}
+	end()
+	if p.busy != nil || p.queued != nil {
+		t.Error("Unexpected run in progress")
+	}
+}
@@ -0,0 +1,53 @@
+package realize
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode"
+)
+
+// Folds reports if the filesystem of a dir is case-insensitive, the dir is found again with its case swapped.
+// A path without letters can't be probed, windows and macOS are case-insensitive by default.
+func folds(dir string) bool {
+	swapped := strings.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, dir)
+	if swapped == dir {
+		return runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+	}
+	a, err := os.Stat(dir)
+	if err != nil {
+		return runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+	}
+	b, err := os.Stat(swapped)
+	return err == nil && os.SameFile(a, b)
+}
+
+// Norm of a path compared by the ignore rules, with the separators of the os and lower case on a case-insensitive filesystem
+func (i ignoring) norm(path string) string {
+	path = filepath.Clean(filepath.FromSlash(path))
+	if i.fold {
+		path = strings.ToLower(path)
+	}
+	return path
+}
+
+// Same reports if two paths or names are equal on the filesystem of the project
+func (i ignoring) same(a, b string) bool {
+	return i.norm(a) == i.norm(b)
+}
+
+// Has reports if an extension is in a list, without case on a case-insensitive filesystem
+func (i ignoring) has(list []string, ext string) bool {
+	for _, v := range list {
+		if v == ext || i.fold && strings.EqualFold(v, ext) {
+			return true
+		}
+	}
+	return false
+}
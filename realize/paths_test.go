@@ -0,0 +1,67 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFolds(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// the probe finds the dir again on a case-insensitive filesystem
+	_, err = os.Stat(strings.ToUpper(dir))
+	if folds(dir) != (err == nil) {
+		t.Error("Unexpected folding of", dir)
+	}
+	if runtime.GOOS == "linux" && folds(dir) {
+		t.Error("Unexpected case-insensitive", dir)
+	}
+	if folds(filepath.Join(dir, "missing")) != (runtime.GOOS == "windows" || runtime.GOOS == "darwin") {
+		t.Error("Unexpected folding of a missing dir")
+	}
+}
+
+func TestIgnoring_Fold(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := Project{Path: dir, Watcher: Watch{Exts: []string{"go"}, Ignore: []string{"Build/Gen", "api"}}}
+	p.Tools.Build.Status = true
+	i := p.ignoring()
+	i.fold = true
+	i = i.normalized()
+	upper := strings.ToUpper(dir)
+	for _, path := range []string{"build/gen/a.go", "BUILD/GEN/a.go", "Api/main.go", "Vendor/lib/lib.go", "A.Test", binary(dir)} {
+		if i.by(filepath.Join(upper, path)) == "" {
+			t.Error("Expected ignored", path)
+		}
+	}
+	if rule := i.by(upper + "/api/x/main.go"); rule != "api" {
+		t.Error("Unexpected rule of a slashed path", rule)
+	}
+	for _, path := range []string{"apis/main.go", "build/generated/a.go"} {
+		if rule := i.by(filepath.Join(upper, path)); rule != "" {
+			t.Error("Unexpected ignored", path, rule)
+		}
+	}
+	if reason := p.rejected(filepath.Join(dir, "Main.GO"), i); reason != "" {
+		t.Error("Unexpected rejected", reason)
+	}
+	// a case-sensitive filesystem keeps the case
+	i.fold = false
+	if reason := p.rejected(filepath.Join(dir, "Main.GO"), i); reason != "extension GO not watched" {
+		t.Error("Unexpected reason", reason)
+	}
+	if !i.same(dir+"/a/../b", filepath.Join(dir, "b")) || i.same(filepath.Join(dir, "B"), filepath.Join(dir, "b")) {
+		t.Error("Unexpected same paths")
+	}
+}
@@ -0,0 +1,104 @@
+package realize
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Pipe is a command of a pipeline, its stdin is the stdout of the previous one
+type Pipe struct {
+	Cmd string `yaml:"cmd" json:"cmd"`
+}
+
+// Line of the command, the commands of a pipe joined by |, the kind of a custom script
+func (c *Command) line() string {
+	if c.Cmd != "" || len(c.Pipe) == 0 && c.Kind == "" {
+		return c.Cmd
+	}
+	if len(c.Pipe) == 0 {
+		return c.Kind
+	}
+	cmds := make([]string, len(c.Pipe))
+	for i, p := range c.Pipe {
+		cmds[i] = p.Cmd
+	}
+	return strings.Join(cmds, " | ")
+}
+
+// Check a pipe, its commands are split in words and connected without a shell
+func (c *Command) checkPipe() error {
+	switch {
+	case c.Cmd != "":
+		return errors.New("pipe with a command, set only one of them")
+	case c.Shell:
+		return errors.New("pipe through the shell, use a command")
+	case c.Pty:
+		return errors.New("pipe in a pty")
+	}
+	for _, p := range c.Pipe {
+		if _, err := words(p.Cmd); err != nil {
+			return err
+		}
+		if strings.TrimSpace(p.Cmd) == "" {
+			return errors.New("empty command in pipe")
+		}
+	}
+	return nil
+}
+
+// Commands of the process, the ones of a pipe in order
+func (c *Command) commands() ([]*exec.Cmd, error) {
+	if len(c.Pipe) == 0 {
+		ex, err := c.command()
+		if err != nil {
+			return nil, err
+		}
+		return []*exec.Cmd{ex}, nil
+	}
+	var cmds []*exec.Cmd
+	for _, p := range c.Pipe {
+		args, err := words(p.Cmd)
+		if err != nil {
+			return nil, err
+		}
+		if len(args) == 0 {
+			return nil, errors.New("Empty command in pipe")
+		}
+		cmds = append(cmds, exec.Command(args[0], args[1:]...))
+	}
+	return cmds, nil
+}
+
+// Connect the commands of a pipe, the stdout of each one to the stdin of the next.
+// The files are closed once the commands are started.
+func connect(cmds []*exec.Cmd) ([]*os.File, error) {
+	var files []*os.File
+	for i := 0; i < len(cmds)-1; i++ {
+		r, w, err := os.Pipe()
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			return nil, err
+		}
+		cmds[i].Stdout, cmds[i+1].Stdin = w, r
+		files = append(files, r, w)
+	}
+	return files, nil
+}
+
+// Locked writer, the stderr of the commands of a pipe is copied concurrently
+type locked struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *locked) Write(b []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(b)
+}
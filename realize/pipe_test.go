@@ -0,0 +1,44 @@
+package realize
+
+import (
+	"context"
+	"runtime"
+	"testing"
+)
+
+func TestCommand_Pipe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("tr not available")
+	}
+	c := Command{Pipe: []Pipe{{Cmd: "echo hello world"}, {Cmd: "tr a-z A-Z"}, {Cmd: "tr ' ' _"}}}
+	r := c.exec(context.Background(), ".")
+	if r.Err != nil || r.Out != "HELLO_WORLD\n" || r.Name != "echo hello world | tr a-z A-Z | tr ' ' _" {
+		t.Error("Unexpected response", r.Name, r.Out, r.Err)
+	}
+	if label := c.label(); label != "echo" {
+		t.Error("Unexpected label", label)
+	}
+	// a pipe fails with any of its commands
+	c = Command{Pipe: []Pipe{{Cmd: "false"}, {Cmd: "cat"}}}
+	if r := c.exec(context.Background(), "."); r.Err == nil || r.Code != 1 {
+		t.Error("Expected an error", r.Out, r.Code)
+	}
+}
+
+func TestCommand_CheckPipe(t *testing.T) {
+	pipe := []Pipe{{Cmd: "go test -json ./..."}, {Cmd: "tparse"}}
+	if err := (&Command{Pipe: pipe}).checkPipe(); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	for _, c := range []Command{
+		{Cmd: "go test", Pipe: pipe},
+		{Shell: true, Pipe: pipe},
+		{Pty: true, Pipe: pipe},
+		{Pipe: []Pipe{{Cmd: "go test"}, {Cmd: " "}}},
+		{Pipe: []Pipe{{Cmd: "echo 'unclosed"}}},
+	} {
+		if err := c.checkPipe(); err == nil {
+			t.Error("Expected an error", c)
+		}
+	}
+}
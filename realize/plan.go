@@ -0,0 +1,134 @@
+package realize
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Plan prints the commands that would run for each project, nothing is executed
+func (r *Realize) Plan(w io.Writer) {
+	if err := r.resolve(); err != nil {
+		fmt.Fprintln(w, r.Prefix(Red.Regular(err.Error())))
+		return
+	}
+	for k := range r.Schema.Projects {
+		p := &r.Schema.Projects[k]
+		p.parent = r
+		fmt.Fprintln(w, p.pname(p.Name, 1), ":", Magenta.Bold(p.Path))
+		for _, line := range p.plan() {
+			fmt.Fprintln(w, "  "+line)
+		}
+	}
+}
+
+// Plan of a project, one line for each step in order of execution
+func (p *Project) plan() (lines []string) {
+	if hasGoMod(Wdir()) {
+		p.Tools.vgo = true
+	}
+	p.Tools.Setup()
+	step := func(name string, values ...string) {
+		lines = append(lines, fmt.Sprint(Green.Bold(name), " ", strings.Join(values, " ")))
+	}
+	scripts := func(flag string, global bool) {
+		for _, c := range p.Watcher.scripts() {
+			if strings.ToLower(c.Type) == flag && c.Global == global && c.Schedule == "" {
+				name := flag
+				if global {
+					name += " (global)"
+				}
+				if c.WaitFor != nil {
+					step(name, c.WaitFor.String(), c.line())
+				} else {
+					step(name, c.line())
+				}
+			}
+		}
+	}
+	env := p.buildEnvs()
+	sort.Strings(env)
+	if len(env) > 0 {
+		step("env", env...)
+	}
+	scripts("before", true)
+	scripts(TaskGenerate, false)
+	// tools run for each changed file or directory
+	v := reflect.ValueOf(p.Tools)
+	for i := 0; i < v.NumField()-1; i++ {
+		tool := v.Field(i).Interface().(Tool)
+		if tool.Status && tool.isTool {
+			target := "<file>"
+			if tool.dir {
+				target = "<dir>"
+			}
+			step(strings.ToLower(tool.name)+" on change", append(append(append([]string{}, tool.cmd...), tool.Args...), target)...)
+		}
+	}
+	scripts("before", false)
+	if p.Remote != nil {
+		for _, cmd := range p.Remote.copy("", p) {
+			step("sync", cmd...)
+		}
+		for _, c := range p.Remote.Commands {
+			step("sync", p.Remote.exec(c)...)
+		}
+	}
+	// go build commands with their flags, tags and ldflags
+	compile := func(t Tool, params ...string) []string {
+		return append(append(append(append([]string{}, t.cmd...), t.flags(t.Ldflags)...), params...), t.Args...)
+	}
+	if p.Tools.Wasm.Status {
+		step("wasm", append([]string{"GOOS=js", "GOARCH=wasm"}, compile(p.Tools.Wasm)...)...)
+	}
+	if p.Tools.Cross.Status {
+		for _, value := range p.Tools.Cross.Targets {
+			if goos, goarch, err := target(value); err == nil {
+				step("cross", append([]string{"GOOS=" + goos, "GOARCH=" + goarch}, compile(p.Tools.Cross, "-o", "<temp>")...)...)
+			}
+		}
+	}
+	switch {
+	case p.Docker != nil:
+		if cmd := p.Docker.build(); cmd != nil {
+			step("docker", cmd...)
+		}
+		if p.Docker.Service == "" {
+			step("docker", p.Docker.stop(p)...)
+		}
+		step("docker", p.Docker.start(p)...)
+		step("docker", p.Docker.logs(p, time.Now())...)
+	case p.Tools.Run.Status && p.Tools.Run.Managed:
+		step("build", compile(p.Tools.Run, "-o", "<temp>")...)
+		step("run", append([]string{"<temp>"}, p.args()...)...)
+	default:
+		if p.Tools.Install.Status || p.Tools.Run.Status && p.Tools.Run.Command == "" && !p.Tools.Build.Status {
+			step("install", compile(p.Tools.Install)...)
+		}
+		if p.Tools.Build.Status {
+			step("build", compile(p.Tools.Build)...)
+		}
+		if p.Tools.Run.Status && p.Tools.Run.Command != "" {
+			step("run", p.Tools.Run.Command)
+		} else if p.Tools.Run.Status {
+			step("run", append([]string{p.binPath(p.Path)}, p.args()...)...)
+		}
+	}
+	if p.Verify != nil && p.Verify.Cmd != "" {
+		step("verify", p.Verify.Cmd)
+	}
+	scripts("after", false)
+	scripts("after", true)
+	for _, c := range p.Watcher.scripts() {
+		if c.Schedule != "" {
+			step("schedule "+c.Schedule, c.line())
+		}
+	}
+	for _, r := range p.Watcher.Polls {
+		step("poll every "+r.interval().String(), r.URL)
+	}
+	return
+}
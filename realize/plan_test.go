@@ -0,0 +1,39 @@
+package realize
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRealize_Plan(t *testing.T) {
+	var buf bytes.Buffer
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{
+		Name: "test",
+		Path: "/tmp/test",
+		Tools: Tools{
+			Fmt:   Tool{Status: true},
+			Build: Tool{Status: true},
+			Cross: Tool{Status: true, Targets: []string{"windows/arm64"}},
+			Run:   Tool{Status: true},
+		},
+		Args: []string{"--flag"},
+		Watcher: Watch{
+			Scripts: []Command{
+				{Type: "before", Cmd: "echo first"},
+				{Type: "after", Cmd: "echo last", Global: true},
+			},
+		},
+	})
+	r.Plan(&buf)
+	out := buf.String()
+	for _, s := range []string{"gofmt", "echo first", "build", "GOOS=windows GOARCH=arm64", "--flag", "echo last"} {
+		if !strings.Contains(out, s) {
+			t.Error("Unexpected error", s, out)
+		}
+	}
+	if strings.Index(out, "echo first") > strings.Index(out, "echo last") {
+		t.Error("Unexpected order", out)
+	}
+}
@@ -0,0 +1,145 @@
+package realize
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Poll is an url polled as a trigger, a change of its etag, last modified date or content reloads the project
+type Poll struct {
+	URL      string        `yaml:"url" json:"url"`
+	Interval time.Duration `yaml:"interval,omitempty" json:"interval,omitempty"`
+}
+
+// Interval between the polls of an url, 30s by default
+func (r *Poll) interval() time.Duration {
+	if r.Interval <= 0 {
+		return 30 * time.Second
+	}
+	return r.Interval
+}
+
+// Check the url of a poll, only http and https are polled
+func (r *Poll) check() error {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return errors.New("poll " + r.URL + " isn't an http url")
+	}
+	return nil
+}
+
+// Poller of an url, the validators and the version of its last response
+type poller struct {
+	url      string
+	client   *http.Client
+	etag     string
+	modified string
+	version  string
+	seen     bool
+}
+
+// Poll the url, it reports if its version changed since the previous poll, the first one never does
+func (pl *poller) poll(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pl.url, nil)
+	if err != nil {
+		return false, err
+	}
+	if pl.etag != "" {
+		req.Header.Set("If-None-Match", pl.etag)
+	}
+	if pl.modified != "" {
+		req.Header.Set("If-Modified-Since", pl.modified)
+	}
+	resp, err := pl.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return false, nil
+	}
+	if resp.StatusCode >= 300 {
+		return false, fmt.Errorf("url %s responded %s", pl.url, resp.Status)
+	}
+	pl.etag, pl.modified = resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	// the content is hashed without validators
+	version := pl.etag
+	if version == "" {
+		version = pl.modified
+	}
+	if version == "" {
+		h := sha256.New()
+		if _, err := io.Copy(h, resp.Body); err != nil {
+			return false, err
+		}
+		version = hex.EncodeToString(h.Sum(nil))
+	}
+	changed := pl.seen && version != pl.version
+	pl.seen, pl.version = true, version
+	return changed, nil
+}
+
+// Polls start a poller for each url, the index of a changed one is sent on the channel
+func (p *Project) polls(ctx context.Context) <-chan int {
+	changed := make(chan int)
+	for i, r := range p.Watcher.Polls {
+		if err := r.check(); err != nil {
+			p.Err(err)
+			continue
+		}
+		go func(i int, interval time.Duration, pl *poller) {
+			var failed string
+			for {
+				ok, err := pl.poll(ctx)
+				if ctx.Err() != nil {
+					return
+				}
+				// a failure is logged once until it changes
+				if err != nil && err.Error() != failed {
+					p.Err(err)
+				}
+				failed = ""
+				if err != nil {
+					failed = err.Error()
+				}
+				if ok {
+					select {
+					case <-ctx.Done():
+						return
+					case changed <- i:
+					}
+				}
+				timer := time.NewTimer(interval)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+			}
+		}(i, r.interval(), &poller{url: r.URL, client: &http.Client{Timeout: 10 * time.Second}})
+	}
+	return changed
+}
+
+// Polled url changed, its url is the file of the event
+func (p *Project) polled(i int) fsnotify.Event {
+	event := fsnotify.Event{Name: p.Watcher.Polls[i].URL, Op: fsnotify.Write}
+	p.emit(Event{Event: EventChanged, Path: event.Name, Op: event.Op.String()})
+	msg := fmt.Sprintln(p.pname(p.Name, 4), ":", Magenta.Bold("URL"), "changed", Magenta.Bold(event.Name))
+	out := BufferOut{Time: time.Now(), Text: "URL changed " + event.Name}
+	p.stamp("debug", out, msg, "")
+	return event
+}
@@ -0,0 +1,92 @@
+package realize
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPoll_Check(t *testing.T) {
+	for _, u := range []string{"http://localhost:8080/openapi.yaml", "https://example.com/spec"} {
+		if err := (&Poll{URL: u}).check(); err != nil {
+			t.Error("Unexpected error", u, err)
+		}
+	}
+	for _, u := range []string{"", "openapi.yaml", "ftp://example.com/spec", "http://"} {
+		if err := (&Poll{URL: u}).check(); err == nil {
+			t.Error("Expected an error", u)
+		}
+	}
+	if d := (&Poll{}).interval(); d != 30*time.Second {
+		t.Error("Unexpected interval", d)
+	}
+}
+
+func TestPoller(t *testing.T) {
+	var version, conditional int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		etag := fmt.Sprintf(`"v%d"`, atomic.LoadInt32(&version))
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(&conditional, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if r.URL.Path == "/etag" {
+			w.Header().Set("ETag", etag)
+		}
+		fmt.Fprint(w, "spec ", atomic.LoadInt32(&version))
+	}))
+	defer srv.Close()
+	for _, path := range []string{"/etag", "/content"} {
+		atomic.StoreInt32(&version, 0)
+		pl := &poller{url: srv.URL + path, client: srv.Client()}
+		for i, expected := range []bool{false, false, true, false} {
+			if i == 2 {
+				atomic.StoreInt32(&version, 1)
+			}
+			if changed, err := pl.poll(context.Background()); err != nil || changed != expected {
+				t.Error("Unexpected poll", path, i, changed, err)
+			}
+		}
+	}
+	if atomic.LoadInt32(&conditional) != 2 {
+		t.Error("Unexpected conditional requests", conditional)
+	}
+	pl := &poller{url: srv.URL + "/missing", client: srv.Client()}
+	if _, err := pl.poll(context.Background()); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestProject_Polls(t *testing.T) {
+	var version int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "spec ", atomic.LoadInt32(&version))
+	}))
+	defer srv.Close()
+	p := Project{parent: &Realize{}, Name: "app", Watcher: Watch{Polls: []Poll{{URL: "invalid"}, {URL: srv.URL, Interval: 20 * time.Millisecond}}}}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changed := p.polls(ctx)
+	time.Sleep(100 * time.Millisecond)
+	atomic.StoreInt32(&version, 1)
+	select {
+	case i := <-changed:
+		if i != 1 {
+			t.Error("Unexpected poll", i)
+		}
+		if e := p.polled(i); e.Name != srv.URL {
+			t.Error("Unexpected event", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("Expected a change")
+	}
+}
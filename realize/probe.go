@@ -0,0 +1,193 @@
+package realize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default healthcheck timings
+const (
+	probeTimeout  = 30 * time.Second
+	probeInterval = 500 * time.Millisecond
+)
+
+// Healthcheck polled after the start of the project, it's running only once the check passes
+type Healthcheck struct {
+	URL      string        `yaml:"url,omitempty" json:"url,omitempty"`
+	Port     int           `yaml:"port,omitempty" json:"port,omitempty"`
+	Timeout  time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Interval time.Duration `yaml:"interval,omitempty" json:"interval,omitempty"`
+}
+
+// Check once, a tcp dial or an http request with a status lower than 400
+func (h *Healthcheck) check(ctx context.Context) error {
+	switch {
+	case h.URL != "":
+		return httpCheck(ctx, h.URL, func(code int) bool { return code < http.StatusBadRequest })
+	case h.Port > 0:
+		return dialCheck(ctx, "localhost:"+strconv.Itoa(h.Port))
+	}
+	return errors.New("Healthcheck requires an url or a port")
+}
+
+// Poll the check until it passes, the timeout or the cancel of the context
+func (h *Healthcheck) poll(ctx context.Context) error {
+	return poll(ctx, h.Timeout, h.Interval, h.check)
+}
+
+// Poll a check until it passes, the last error is returned on timeout
+func poll(ctx context.Context, timeout, interval time.Duration, check func(context.Context) error) error {
+	if timeout <= 0 {
+		timeout = probeTimeout
+	}
+	if interval <= 0 {
+		interval = probeInterval
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		err := check(ctx)
+		if err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("not ready after %s: %s", timeout, err)
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Dial check of a tcp address
+func dialCheck(ctx context.Context, addr string) error {
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// Http check of an url, the status code is validated by ok
+func httpCheck(ctx context.Context, url string, ok func(int) bool) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if !ok(resp.StatusCode) {
+		return errors.New(url + " returned " + resp.Status)
+	}
+	return nil
+}
+
+// Healthy waits the healthcheck of the run, the project is running once it passes
+func (p *Project) healthy(ctx context.Context, h *Healthcheck) (r Response) {
+	r.Name = "Healthcheck"
+	start := time.Now()
+	r.ID = p.started(ctx, r.Name)
+	if r.Err = h.poll(ctx); ctx.Err() != nil {
+		return
+	}
+	if r.Err != nil {
+		r.print(start, p)
+		return
+	}
+	p.finished(r, start)
+	p.running()
+	return
+}
+
+// Running logs the start of the project
+func (p *Project) running() {
+	log.Println(p.pname(p.Name, 1), ":", "Running..")
+	p.transition(StateRunning)
+}
+
+// Wait error of conditions not met, the next scripts don't run
+type waitError struct {
+	error
+}
+
+// WaitFor conditions of a script, its command and the next scripts run once they are met
+type WaitFor struct {
+	Port     int           `yaml:"port,omitempty" json:"port,omitempty"`
+	File     string        `yaml:"file,omitempty" json:"file,omitempty"`
+	URL      string        `yaml:"url,omitempty" json:"url,omitempty"`
+	Delay    time.Duration `yaml:"delay,omitempty" json:"delay,omitempty"`
+	Timeout  time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Interval time.Duration `yaml:"interval,omitempty" json:"interval,omitempty"`
+}
+
+// String describes the conditions
+func (w *WaitFor) String() string {
+	var conds []string
+	if w.Delay > 0 {
+		conds = append(conds, w.Delay.String())
+	}
+	if w.Port > 0 {
+		conds = append(conds, "port "+strconv.Itoa(w.Port))
+	}
+	if w.File != "" {
+		conds = append(conds, "file "+w.File)
+	}
+	if w.URL != "" {
+		conds = append(conds, w.URL)
+	}
+	return "wait for " + strings.Join(conds, ", ")
+}
+
+// Check once all the conditions, files are relative to base
+func (w *WaitFor) check(base string) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if w.Port > 0 {
+			if err := dialCheck(ctx, "localhost:"+strconv.Itoa(w.Port)); err != nil {
+				return err
+			}
+		}
+		if w.File != "" {
+			file := w.File
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(base, file)
+			}
+			if _, err := os.Stat(file); err != nil {
+				return err
+			}
+		}
+		if w.URL != "" {
+			return httpCheck(ctx, w.URL, func(code int) bool { return code == http.StatusOK })
+		}
+		return nil
+	}
+}
+
+// Wait the delay, then poll the conditions
+func (w *WaitFor) wait(ctx context.Context, base string) error {
+	if w.Delay > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(w.Delay):
+		}
+	}
+	if w.Port == 0 && w.File == "" && w.URL == "" {
+		return nil
+	}
+	return poll(ctx, w.Timeout, w.Interval, w.check(base))
+}
@@ -0,0 +1,91 @@
+package realize
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHealthcheck_Poll(t *testing.T) {
+	ready := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ready {
+			ready = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+	h := Healthcheck{URL: ts.URL, Interval: 10 * time.Millisecond, Timeout: time.Second}
+	if err := h.poll(context.Background()); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	h = Healthcheck{Port: port, Interval: 10 * time.Millisecond, Timeout: 100 * time.Millisecond}
+	if err := h.poll(context.Background()); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	l.Close()
+	if err := h.poll(context.Background()); err == nil {
+		t.Error("Expected an error on", strconv.Itoa(port))
+	}
+	if err := (&Healthcheck{}).check(context.Background()); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestProject_Healthy(t *testing.T) {
+	r := Realize{}
+	p := Project{parent: &r, Name: "test", lifecycle: &lifecycle{state: StateBuilding}}
+	p.Tools.Run.Health = &Healthcheck{Port: 1, Interval: 10 * time.Millisecond, Timeout: 50 * time.Millisecond}
+	if res := p.healthy(context.Background(), p.Tools.Run.Health); res.Err == nil || p.current() == StateRunning {
+		t.Error("Expected a failed healthcheck", res)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	p.Tools.Run.Health = &Healthcheck{URL: ts.URL}
+	if res := p.healthy(context.Background(), p.Tools.Run.Health); res.Err != nil || p.current() != StateRunning {
+		t.Error("Unexpected error", res.Err)
+	}
+}
+
+func TestCommand_WaitFor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		ioutil.WriteFile(filepath.Join(dir, "ready"), nil, 0644)
+	}()
+	c := Command{WaitFor: &WaitFor{File: "ready", Delay: 10 * time.Millisecond, Interval: 10 * time.Millisecond, Timeout: time.Second}}
+	r := c.run(context.Background(), dir)
+	if r.Err != nil || r.Name != "wait for 10ms, file ready" {
+		t.Error("Unexpected response", r.Name, r.Err)
+	}
+	if c.label() != "wait" {
+		t.Error("Expected wait instead", c.label())
+	}
+	c.WaitFor.File = "missing"
+	c.WaitFor.Timeout = 50 * time.Millisecond
+	if r = c.run(context.Background(), dir); r.Err == nil {
+		t.Error("Expected an error")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.WaitFor = &WaitFor{Delay: time.Hour}
+	if r = c.run(ctx, dir); r.Err == nil {
+		t.Error("Expected a canceled wait")
+	}
+}
@@ -3,8 +3,11 @@ package realize
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"log"
 	"math/big"
 	"os"
@@ -20,18 +23,28 @@ import (
 	"github.com/fsnotify/fsnotify"
 )
 
-var (
-	msg string
-	out BufferOut
-)
-
 // Watch info
 type Watch struct {
-	Exts    []string  `yaml:"extensions" json:"extensions"`
-	Paths   []string  `yaml:"paths" json:"paths"`
-	Scripts []Command `yaml:"scripts,omitempty" json:"scripts,omitempty"`
-	Hidden  bool      `yaml:"hidden,omitempty" json:"hidden,omitempty"`
-	Ignore  []string  `yaml:"ignored_paths,omitempty" json:"ignored_paths,omitempty"`
+	Exts         []string      `yaml:"extensions" json:"extensions"`
+	Paths        []string      `yaml:"paths" json:"paths"`
+	Files        []string      `yaml:"files,omitempty" json:"files,omitempty"`
+	Scripts      []Command     `yaml:"scripts,omitempty" json:"scripts,omitempty"`
+	FailFast     bool          `yaml:"fail_fast,omitempty" json:"fail_fast,omitempty"`
+	Imports      bool          `yaml:"imports,omitempty" json:"imports,omitempty"`
+	Hidden       bool          `yaml:"hidden,omitempty" json:"hidden,omitempty"`
+	HiddenExcept []string      `yaml:"hidden_except,omitempty" json:"hidden_except,omitempty"`
+	Ignore       []string      `yaml:"ignored_paths,omitempty" json:"ignored_paths,omitempty"`
+	Defaults     *bool         `yaml:"ignore_defaults,omitempty" json:"ignore_defaults,omitempty"`
+	Ops          []string      `yaml:"ops,omitempty" json:"ops,omitempty"`
+	MaxDepth     int           `yaml:"max_depth,omitempty" json:"max_depth,omitempty"`
+	MaxDirs      int           `yaml:"max_dirs,omitempty" json:"max_dirs,omitempty"`
+	Fresh        bool          `yaml:"fresh,omitempty" json:"fresh,omitempty"`
+	DebounceMode string        `yaml:"debounce_mode,omitempty" json:"debounce_mode,omitempty"`
+	Debounce     time.Duration `yaml:"debounce,omitempty" json:"debounce,omitempty"`
+	Overlap      string        `yaml:"overlap,omitempty" json:"overlap,omitempty"`
+	Changes      *Changes      `yaml:"changes,omitempty" json:"changes,omitempty"`
+	Polls        []Poll        `yaml:"polls,omitempty" json:"polls,omitempty"`
+	resolved     []Command
 }
 
 type Ignore struct {
@@ -41,45 +54,146 @@ type Ignore struct {
 
 // Command fields
 type Command struct {
-	Cmd    string `yaml:"command" json:"command"`
-	Type   string `yaml:"type" json:"type"`
-	Path   string `yaml:"path,omitempty" json:"path,omitempty"`
-	Global bool   `yaml:"global,omitempty" json:"global,omitempty"`
-	Output bool   `yaml:"output,omitempty" json:"output,omitempty"`
+	Name     string            `yaml:"name,omitempty" json:"name,omitempty"`
+	Cmd      string            `yaml:"command" json:"command"`
+	Type     string            `yaml:"type" json:"type"`
+	Path     string            `yaml:"path,omitempty" json:"path,omitempty"`
+	Global   bool              `yaml:"global,omitempty" json:"global,omitempty"`
+	Output   OutputMode        `yaml:"output,omitempty" json:"output,omitempty"`
+	Signals  []string          `yaml:"signals,omitempty" json:"signals,omitempty"`
+	Stdin    bool              `yaml:"stdin,omitempty" json:"stdin,omitempty"`
+	Pty      bool              `yaml:"pty,omitempty" json:"pty,omitempty"`
+	Shell    bool              `yaml:"shell,omitempty" json:"shell,omitempty"`
+	Schedule string            `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+	WaitFor  *WaitFor          `yaml:"wait_for,omitempty" json:"wait_for,omitempty"`
+	Creates  string            `yaml:"creates,omitempty" json:"creates,omitempty"`
+	Alive    bool              `yaml:"keep_alive,omitempty" json:"keep_alive,omitempty"`
+	Log      string            `yaml:"log_file,omitempty" json:"log_file,omitempty"`
+	Rules    []Highlight       `yaml:"highlight,omitempty" json:"highlight,omitempty"`
+	Limits   *Limits           `yaml:"limits,omitempty" json:"limits,omitempty"`
+	Cache    *Cache            `yaml:"cache,omitempty" json:"cache,omitempty"`
+	Inputs   []string          `yaml:"inputs,omitempty" json:"inputs,omitempty"`
+	Outputs  []string          `yaml:"outputs,omitempty" json:"outputs,omitempty"`
+	Use      string            `yaml:"use,omitempty" json:"use,omitempty"`
+	User     string            `yaml:"user,omitempty" json:"user,omitempty"`
+	Group    string            `yaml:"group,omitempty" json:"group,omitempty"`
+	Throttle *Throttle         `yaml:"throttle,omitempty" json:"throttle,omitempty"`
+	Pipe     []Pipe            `yaml:"pipe,omitempty" json:"pipe,omitempty"`
+	Kind     string            `yaml:"kind,omitempty" json:"kind,omitempty"`
+	With     map[string]string `yaml:"with,omitempty" json:"with,omitempty"`
+	parent   *Project
+	env      []string
 }
 
 // Project info
 type Project struct {
 	parent     *Realize
 	watcher    FileWatcher
-	stop       chan bool
+	ctx        context.Context
+	cancel     context.CancelFunc
+	busy       chan bool
+	queued     *reloading
 	exit       chan os.Signal
+	control    chan string
+	changes    chan pathChange
+	dirs       map[string]bool
+	admitted   map[string]bool
+	awaited    map[string]bool
+	crowded    bool
+	exhausted  int64
+	reported   int64
+	ran        bool
 	paths      []string
 	last       last
+	managed    *managed
+	runs       *tracker
+	broker     *broker
+	set        *recorder
+	delta      *delta
+	shutdown   []Response
+	tasks      []TaskResult
+	status     string
+	reason     string
 	files      int64
 	folders    int64
 	init       bool
+	paused     bool
+	view       *view
+	lifecycle  *lifecycle
+	records    *records
+	reaper     *reaper
+	pump       *pump
+	rules      *rules
+	sinks      []Sink
+	problems   []Diagnostic
+	failures   []Response
+	outcome    string
+	timings    map[string]float64
+	durations  map[string][]float64
+	fileEnv    map[string]string
+	results    *stream
+	index      *index
+	first      bool
+	life       context.Context
+	scanned    bool
+	latest     time.Time
 	Name       string            `yaml:"name" json:"name"`
 	Path       string            `yaml:"path" json:"path"`
+	Tags       []string          `yaml:"tags,omitempty" json:"tags,omitempty"`
 	Env        map[string]string `yaml:"env,omitempty" json:"env,omitempty"`
+	EnvFiles   []string          `yaml:"env_files,omitempty" json:"env_files,omitempty"`
 	Args       []string          `yaml:"args,omitempty" json:"args,omitempty"`
 	Tools      Tools             `yaml:"commands" json:"commands"`
 	Watcher    Watch             `yaml:"watcher" json:"watcher"`
+	Verify     *Command          `yaml:"verify,omitempty" json:"verify,omitempty"`
+	Docker     *Docker           `yaml:"docker,omitempty" json:"docker,omitempty"`
+	Remote     *Remote           `yaml:"sync,omitempty" json:"sync,omitempty"`
+	LiveReload bool              `yaml:"livereload,omitempty" json:"livereload,omitempty"`
+	Proxy      *Proxy            `yaml:"proxy,omitempty" json:"proxy,omitempty"`
+	Sinks      Sinks             `yaml:"sinks,omitempty" json:"sinks,omitempty"`
+	OnStart    *Command          `yaml:"on_start,omitempty" json:"on_start,omitempty"`
+	OnReload   *Command          `yaml:"on_reload,omitempty" json:"on_reload,omitempty"`
+	OnSuccess  *Command          `yaml:"on_success,omitempty" json:"on_success,omitempty"`
+	OnError    *Command          `yaml:"on_error,omitempty" json:"on_error,omitempty"`
 	Buffer     Buffer            `yaml:"-" json:"buffer"`
 	ErrPattern string            `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+	MaxRuntime time.Duration     `yaml:"max_runtime,omitempty" json:"max_runtime,omitempty"`
 }
 
+// TaskRun is the label of the project output
+const TaskRun = "run"
+
+// Colors of the output prefixes, picked by project name
+var palette = []colorBase{Blue, Magenta, Green, Yellow}
+
 // Last is used to save info about last file changed
 type last struct {
 	file string
 	time time.Time
 }
 
+// Managed binary started by the managed run mode, or the followed container logs
+type managed struct {
+	bin    string
+	cancel context.CancelFunc
+	done   chan bool
+	port   int
+}
+
+// Managed binaries of the projects, handed over by the runs and killed by the watch loop
+var managedMu sync.Mutex
+
 // Response exec
 type Response struct {
-	Name string
-	Out  string
-	Err  error
+	Name        string
+	Out         string
+	Err         error
+	Code        int
+	Diagnostics []Diagnostic
+	// Stream of a line of the run, stdout or stderr
+	Stream string
+	// ID of the task, the id of its run and its sequence number
+	ID string
 }
 
 // Buffer define an array buffer for each log files
@@ -97,6 +211,8 @@ type BufferOut struct {
 	Type   string    `json:"type"`
 	Stream string    `json:"stream"`
 	Errors []string  `json:"errors"`
+	Run    string    `json:"run,omitempty"`
+	ID     string    `json:"id,omitempty"`
 }
 
 // After stop watcher
@@ -105,7 +221,23 @@ func (p *Project) After() {
 		p.parent.After(Context{Project: p})
 		return
 	}
-	p.cmd(nil, "after", true)
+	// bounded time budget on exit
+	ctx, cancel := context.WithTimeout(context.Background(), p.parent.Settings.timeout())
+	defer cancel()
+	p.shutdown = p.cmd(ctx, "after", true)
+	var pending []Command
+	for _, c := range p.Watcher.scripts() {
+		if strings.ToLower(c.Type) == "after" && c.Global {
+			pending = append(pending, c)
+		}
+	}
+	for _, c := range pending[len(p.shutdown):] {
+		r := Response{Name: c.line(), Err: errors.New("timed out")}
+		p.shutdown = append(p.shutdown, r)
+		msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold("Command"), Red.Bold("\"")+r.Name+Red.Bold("\""), Red.Regular(r.Err.Error()))
+		out := BufferOut{Time: time.Now(), Text: r.Err.Error(), Type: "after"}
+		p.stamp("error", out, msg, "")
+	}
 }
 
 // Before start watcher
@@ -121,22 +253,101 @@ func (p *Project) Before() {
 
 	// setup go tools
 	p.Tools.Setup()
-	// global commands before
-	p.cmd(p.stop, "before", true)
-	// indexing files and dirs
-	for _, dir := range p.Watcher.Paths {
-		base, _ := filepath.Abs(p.Path)
-		base = filepath.Join(base, dir)
-		if _, err := os.Stat(base); err == nil {
-			if err := filepath.Walk(base, p.walk); err != nil {
+	p.Tools.bind(p)
+	// the build outputs are ignored once the tools are set up
+	p.reignore()
+	// unknown ops are skipped
+	if _, err := mask(p.Watcher.Ops); err != nil {
+		p.Err(err)
+	}
+	// unknown debounce modes are leading
+	if err := debounce(p.Watcher.DebounceMode); err != nil {
+		p.Err(err)
+	}
+	// unknown overlap policies cancel
+	if err := overlap(p.Watcher.Overlap); err != nil {
+		p.Err(err)
+	}
+	// a blue-green run without its requirements stops the previous binary
+	if err := p.checkStrategy(); err != nil {
+		p.Err(err)
+		p.Tools.Run.Strategy = ""
+	}
+	// invalid highlight rules don't match, invalid limits aren't applied
+	if err := checkRules(p.Tools.Run.Rules); err != nil {
+		p.Err(err)
+	}
+	for _, c := range p.Watcher.scripts() {
+		if err := checkRules(c.Rules); err != nil {
+			p.Err(err)
+		}
+		if c.Limits != nil {
+			if err := c.Limits.check(); err != nil {
 				p.Err(err)
 			}
 		}
+		if c.Cache != nil {
+			if err := c.Cache.check(); err != nil {
+				p.Err(errors.New(c.label() + ": " + err.Error()))
+			}
+		}
+		if c.generator() {
+			if err := c.checkGenerate(); err != nil {
+				p.Err(errors.New(c.label() + ": " + err.Error()))
+			}
+		}
+		if c.Kind != "" && p.executor(&c) == nil {
+			p.Err(errors.New(c.label() + ": no executor of kind " + c.Kind))
+		}
+		if len(c.Pipe) > 0 {
+			if err := c.checkPipe(); err != nil {
+				p.Err(errors.New(c.label() + ": " + err.Error()))
+			}
+		}
+		if err := checkUser(c.User, c.Group); err != nil {
+			p.Err(errors.New(c.label() + ": " + err.Error()))
+		}
+	}
+	for _, t := range p.Tools.all() {
+		if err := checkUser(t.User, t.Group); err != nil {
+			p.Err(errors.New(t.name + ": " + err.Error()))
+		}
+	}
+	// env files values
+	if len(p.EnvFiles) > 0 {
+		p.loadEnv()
+		p.watchEnv()
+	}
+	// single files, watched regardless of the extensions
+	p.watchFiles()
+	// global commands before
+	p.cmd(p.context(), "before", true)
+	// the stored index defers the tools of the walk to the changed files
+	if p.parent.Settings.Index {
+		p.loadIndex()
+	}
+	// the content of the files is kept for the diffs of the changes
+	if p.Watcher.Changes != nil {
+		p.delta = newDelta(p.Watcher.Changes, p.abs(""))
+	}
+	// indexing files and dirs
+	p.set = &recorder{}
+	for _, dir := range p.Watcher.Paths {
+		p.indexPath(dir)
+	}
+	p.reindex()
+	p.exhaust()
+	// local packages imported
+	if p.Watcher.Imports {
+		p.watchImports()
 	}
 	// start message
-	msg = fmt.Sprintln(p.pname(p.Name, 1), ":", Blue.Bold("Watching"), Magenta.Bold(p.files), "file/s", Magenta.Bold(p.folders), "folder/s")
-	out = BufferOut{Time: time.Now(), Text: "Watching " + strconv.FormatInt(p.files, 10) + " files/s " + strconv.FormatInt(p.folders, 10) + " folder/s"}
+	msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Blue.Bold("Watching"), Magenta.Bold(p.files), "file/s", Magenta.Bold(p.folders), "folder/s")
+	out := BufferOut{Time: time.Now(), Text: "Watching " + strconv.FormatInt(p.files, 10) + " files/s " + strconv.FormatInt(p.folders, 10) + " folder/s"}
 	p.stamp("log", out, msg, "")
+	p.summary()
+	p.emit(Event{Event: EventIndexed, Files: p.files, Folders: p.folders})
+	p.hook(p.context(), p.OnStart, HookStart, "", time.Now())
 }
 
 // Err occurred
@@ -146,14 +357,17 @@ func (p *Project) Err(err error) {
 		return
 	}
 	if err != nil {
-		msg = fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Regular(err.Error()))
-		out = BufferOut{Time: time.Now(), Text: err.Error()}
+		msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Regular(err.Error()))
+		out := BufferOut{Time: time.Now(), Text: err.Error()}
 		p.stamp("error", out, msg, "")
 	}
 }
 
 // Change event message
 func (p *Project) Change(event fsnotify.Event) {
+	// the changes since the last successful run are listed by the next reload
+	p.delta.change(event)
+	p.emit(Event{Event: EventChanged, Path: event.Name, Op: event.Op.String()})
 	if p.parent.Change != nil {
 		p.parent.Change(Context{Project: p, Event: event})
 		return
@@ -164,38 +378,95 @@ func (p *Project) Change(event fsnotify.Event) {
 		ext = "DIR"
 	}
 	// change message
-	msg = fmt.Sprintln(p.pname(p.Name, 4), ":", Magenta.Bold(strings.ToUpper(ext)), "changed", Magenta.Bold(event.Name))
-	out = BufferOut{Time: time.Now(), Text: ext + " changed " + event.Name}
-	p.stamp("log", out, msg, "")
+	msg := fmt.Sprintln(p.pname(p.Name, 4), ":", Magenta.Bold(strings.ToUpper(ext)), "changed", Magenta.Bold(event.Name))
+	out := BufferOut{Time: time.Now(), Text: ext + " changed " + event.Name}
+	p.stamp("debug", out, msg, "")
 }
 
 // Reload launches the toolchain run, build, install
-func (p *Project) Reload(path string, stop <-chan bool) {
+func (p *Project) Reload(ctx context.Context, path string) {
 	if p.parent.Reload != nil {
-		p.parent.Reload(Context{Project: p, Watcher: p.watcher, Path: path, Stop: stop})
+		p.parent.Reload(Context{Project: p, Watcher: p.watcher, Path: path, Ctx: ctx, Stop: ctx.Done()})
 		return
 	}
-	var done bool
-	var install, build Response
-	go func() {
-		for {
-			select {
-			case <-stop:
-				done = true
-				return
+	var install, build, wasm, cross, health Response
+	if ctx.Err() != nil {
+		return
+	}
+	// the commands of the run share its id
+	ctx = identified(ctx)
+	p.runs.begin(runID(ctx))
+	p.transition(StateBuilding)
+	// refreshed env, the run and the commands restart with the new values
+	for _, file := range batch(ctx, path) {
+		if p.envFile(file) {
+			p.loadEnv()
+			break
+		}
+	}
+	// diagnostics of the run are listed at its end, the run is stored in the history
+	p.problems, p.failures, p.outcome, p.timings = nil, nil, "", nil
+	start := time.Now()
+	// every run but the first one is a reload
+	if p.ran {
+		p.hook(ctx, p.OnReload, HookReload, path, start)
+	}
+	p.first, p.ran = !p.ran, true
+	// why the reload happened
+	listed := p.explain()
+	// nothing changed since the last successful run, its artifacts are still there
+	unchanged := path == "" && p.unchanged()
+	// make-like, the outputs newer than every watched file aren't built again
+	fresh := func(task string, output string) bool {
+		if !p.first || !p.Watcher.Fresh || !p.fresh(output) {
+			return false
+		}
+		p.skipped(task)
+		return true
+	}
+	defer func() {
+		if ctx.Err() == nil {
+			p.diagnosed(runID(ctx))
+			p.summarize()
+			p.recap()
+			switch p.outcome {
+			case StatusSuccess:
+				if err := p.storeIndex(batch(ctx, path)...); err != nil {
+					p.Err(err)
+				}
+				p.delta.commit(listed)
+				p.hook(ctx, p.OnSuccess, HookSuccess, path, start)
+			case StatusFailure:
+				p.hook(ctx, p.OnError, HookError, path, start)
 			}
 		}
+		if err := p.remember(path, start, ctx.Err() != nil); err != nil {
+			p.Err(err)
+		}
 	}()
-	if done {
+	// requests to the proxy wait the end of the build, then the dial is retried until the project listens
+	if p.Proxy != nil && !p.Tools.Run.Managed {
+		p.Proxy.hold()
+		defer func() {
+			if ctx.Err() == nil {
+				p.Proxy.release(p.status == StatusFailure)
+			}
+		}()
+	}
+	// generate tasks of the changed inputs, before the commands and the tools using their outputs
+	if failed(p.generate(ctx, batch(ctx, path))) && p.Watcher.FailFast {
 		return
 	}
 	// before command
-	p.cmd(stop, "before", false)
-	if done {
+	if failed(p.cmd(ctx, "before", false)) && p.Watcher.FailFast {
 		return
 	}
-	// Go supported tools
-	if len(path) > 0 {
+	if ctx.Err() != nil {
+		return
+	}
+	// Go supported tools, on each file batched by the trailing debounce
+	imports := false
+	for _, path := range batch(ctx, path) {
 		fi, err := os.Stat(path)
 		if filepath.Ext(path) == "" {
 			fi, err = os.Stat(path)
@@ -203,186 +474,655 @@ func (p *Project) Reload(path string, stop <-chan bool) {
 		if err != nil {
 			p.Err(err)
 		}
-		p.tools(stop, path, fi)
+		p.tools(ctx, path, fi)
+		imports = imports || ext(path) == "go"
+	}
+	// imports may be changed
+	if p.Watcher.Imports && imports {
+		p.reimport(ctx)
 	}
 	// Prevent fake events on polling startup
 	p.init = true
+	if ctx.Err() != nil {
+		return
+	}
+	// copy to the remote host and run the remote commands
+	if p.Remote != nil && failed(p.remote(ctx, path)) && p.Watcher.FailFast {
+		return
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	// webassembly artifact
+	if p.Tools.Wasm.Status && !unchanged && !fresh(p.Tools.Wasm.name, p.Tools.Wasm.Path) {
+		msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Green.Regular(p.Tools.Wasm.name), "started")
+		out := BufferOut{Time: time.Now(), Text: p.Tools.Wasm.name + " started"}
+		p.stamp("log", out, msg, "")
+		id := p.started(ctx, p.Tools.Wasm.name)
+		start := time.Now()
+		wasm = p.Tools.Wasm.Compile(ctx, p.Path)
+		wasm.ID = id
+		wasm.print(start, p)
+		if wasm.Err == nil {
+			// reload the browsers
+			p.parent.Server.Reload(p.Name)
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	// go build of each target
+	if p.Tools.Cross.Status && !unchanged {
+		cross = p.cross(ctx)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	// containers replace the go build and run
+	if p.Docker != nil {
+		docker := p.docker(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		p.verify(ctx, wasm, cross, docker)
+		p.cmd(ctx, "after", false)
+		return
+	}
+	// build a temp binary and swap the running one
+	if p.Tools.Run.Status && p.Tools.Run.Managed {
+		msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Green.Regular(p.Tools.Run.name), "started")
+		out := BufferOut{Time: time.Now(), Text: p.Tools.Run.name + " started"}
+		p.stamp("log", out, msg, "")
+		id := p.started(ctx, p.Tools.Run.name)
+		start := time.Now()
+		var bin string
+		bin, build = p.Tools.Run.Swap(ctx, p.Path)
+		if ctx.Err() != nil {
+			os.Remove(bin)
+			return
+		}
+		build.ID = id
+		build.print(start, p)
+		if build.Err != nil {
+			os.Remove(bin)
+		} else if p.Tools.Run.blueGreen() && !p.parent.Once {
+			health = p.switchover(ctx, bin)
+		} else {
+			p.swap(bin)
+			if p.Tools.Run.Health != nil && !p.parent.Once {
+				health = p.healthy(ctx, p.Tools.Run.Health)
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		p.verify(ctx, wasm, cross, build, health)
+		if health.Err == nil {
+			p.cmd(ctx, "after", false)
+		}
+		return
+	}
 	// prevent errors using realize without config with only run flag
-	if p.Tools.Run.Status && !p.Tools.Install.Status && !p.Tools.Build.Status {
+	if p.Tools.Run.Status && p.Tools.Run.Command == "" && !p.Tools.Install.Status && !p.Tools.Build.Status {
 		p.Tools.Install.Status = true
 	}
-	if done {
+	if ctx.Err() != nil {
 		return
 	}
-	if p.Tools.Install.Status {
-		msg = fmt.Sprintln(p.pname(p.Name, 1), ":", Green.Regular(p.Tools.Install.name), "started")
-		out = BufferOut{Time: time.Now(), Text: p.Tools.Install.name + " started"}
+	if p.Tools.Install.Status && !(unchanged && exists(p.binPath(p.Path))) && !fresh(p.Tools.Install.name, p.binPath(p.Path)) {
+		msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Green.Regular(p.Tools.Install.name), "started")
+		out := BufferOut{Time: time.Now(), Text: p.Tools.Install.name + " started"}
 		p.stamp("log", out, msg, "")
+		id := p.started(ctx, p.Tools.Install.name)
 		start := time.Now()
-		install = p.Tools.Install.Compile(p.Path, stop)
+		install = p.Tools.Install.Compile(ctx, p.Path)
+		install.ID = id
 		install.print(start, p)
 	}
-	if done {
+	if ctx.Err() != nil {
 		return
 	}
-	if p.Tools.Build.Status {
-		msg = fmt.Sprintln(p.pname(p.Name, 1), ":", Green.Regular(p.Tools.Build.name), "started")
-		out = BufferOut{Time: time.Now(), Text: p.Tools.Build.name + " started"}
+	if p.Tools.Build.Status && !unchanged && !fresh(p.Tools.Build.name, p.buildOutput()) {
+		msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Green.Regular(p.Tools.Build.name), "started")
+		out := BufferOut{Time: time.Now(), Text: p.Tools.Build.name + " started"}
 		p.stamp("log", out, msg, "")
+		id := p.started(ctx, p.Tools.Build.name)
 		start := time.Now()
-		build = p.Tools.Build.Compile(p.Path, stop)
+		build = p.Tools.Build.Compile(ctx, p.Path)
+		build.ID = id
 		build.print(start, p)
 	}
-	if done {
+	if ctx.Err() != nil {
 		return
 	}
 	if install.Err == nil && build.Err == nil && p.Tools.Run.Status {
-		result := make(chan Response)
-		go func() {
-			for {
-				select {
-				case <-stop:
-					return
-				case r := <-result:
-					if r.Err != nil {
-						msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Regular(r.Err))
-						out := BufferOut{Time: time.Now(), Text: r.Err.Error(), Type: "Go Run"}
-						p.stamp("error", out, msg, "")
+		if p.parent.Once {
+			p.launch(ctx, p.Path)
+		} else {
+			go p.launch(ctx, p.Path)
+			if p.Tools.Run.Health != nil {
+				health = p.healthy(ctx, p.Tools.Run.Health)
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	p.verify(ctx, wasm, cross, install, build, health)
+	// after commands depend on a healthy project
+	if health.Err == nil {
+		p.cmd(ctx, "after", false)
+	}
+}
+
+// Verify runs the success criteria command, its result is notified
+func (p *Project) verify(ctx context.Context, results ...Response) {
+	for _, r := range results {
+		if r.Err != nil {
+			p.notify(StatusFailure, r.Name+" failed: "+r.Err.Error())
+			return
+		}
+	}
+	if p.Verify == nil || p.Verify.Cmd == "" {
+		p.notify(StatusSuccess, p.Name+" completed")
+		return
+	}
+	p.Verify.parent = p
+	start := time.Now()
+	id := p.started(ctx, "Verify")
+	r := p.Verify.exec(ctx, p.Path)
+	if ctx.Err() != nil {
+		return
+	}
+	p.record(Response{Name: "Verify", Err: r.Err, Code: r.Code, ID: id}, start)
+	if r.Err != nil {
+		msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold("Verify"), Red.Regular("failed"), Red.Bold("\"")+r.Name+Red.Bold("\""))
+		out := BufferOut{Time: time.Now(), Text: "verify failed", Type: "verify", Stream: r.Err.Error(), ID: id}
+		p.stamp("error", out, msg, p.prefixed("verify", r.Err.Error(), true))
+		p.notify(StatusFailure, "verify failed: "+r.Err.Error())
+		p.fail(r)
+		return
+	}
+	msg := fmt.Sprintln(p.pname(p.Name, 5), ":", Green.Bold("Verify"), "passed", Green.Bold("\"")+r.Name+Green.Bold("\""))
+	out := BufferOut{Time: time.Now(), Text: "verify passed", Type: "verify"}
+	p.stamp("log", out, msg, "")
+	p.notify(StatusSuccess, p.Name+" verified")
+}
+
+// Fail keeps the exit code and the failed task of the run, realize is stopped when exit on error is enabled
+func (p *Project) fail(r Response) {
+	p.outcome = StatusFailure
+	p.failures = append(p.failures, r)
+	if p.parent.Settings.ExitOnError || p.parent.Once {
+		p.parent.fail(r)
+	}
+}
+
+// Recap lists the failed tasks at the end of a run
+func (p *Project) recap() {
+	if len(p.failures) == 0 {
+		return
+	}
+	var names, list []string
+	for _, r := range p.failures {
+		names = append(names, r.Name)
+		list = append(list, r.Name+": "+failure(r))
+	}
+	text := fmt.Sprint("Run failed, ", len(p.failures), " task/s: ", strings.Join(names, ", "))
+	msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold(text))
+	out := BufferOut{Time: time.Now(), Text: text, Type: "run", Stream: strings.Join(list, "\n")}
+	p.stamp("error", out, msg, "")
+}
+
+// Failure of a task, the first line of its error
+func failure(r Response) string {
+	if l := lines(r.Err.Error()); len(l) > 0 {
+		return l[0]
+	}
+	return r.Err.Error()
+}
+
+// Notify the status of the project
+func (p *Project) notify(status string, text string) {
+	unlock := p.locked()
+	p.status = status
+	unlock()
+	if p.outcome != StatusFailure {
+		p.outcome = status
+	}
+	if status == StatusFailure {
+		p.transition(StateFailed)
+	} else {
+		p.transition(StateIdle, StateBuilding)
+	}
+	// refresh the browsers after a successful run
+	if status == StatusSuccess && p.LiveReload {
+		p.parent.Server.Reload(p.Name)
+	}
+	p.parent.Notify.Send(Notification{Project: p.Name, Status: status, Text: text})
+}
+
+// Launch the project and print its output until the context is done
+func (p *Project) launch(ctx context.Context, path string) {
+	start := time.Now()
+	id := p.started(ctx, TaskRun)
+	result := make(chan Response)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case r := <-result:
+				if r.Err != nil {
+					line, t := Red.Regular(r.Err), "warn"
+					// highlighted, colored and at the level of the rule
+					if h := match(p.Tools.Run.Rules, r.Err.Error()); h != nil {
+						line, t = h.paint(r.Err.Error()), h.stamp(t)
 					}
-					if r.Out != "" {
-						msg := fmt.Sprintln(p.pname(p.Name, 3), ":", Blue.Regular(r.Out))
-						out := BufferOut{Time: time.Now(), Text: r.Out, Type: "Go Run"}
-						p.stamp("out", out, msg, "")
+					msg := fmt.Sprintln(p.prefix(TaskRun), ":", line)
+					out := BufferOut{Time: time.Now(), Text: r.Err.Error(), Type: "Go Run", ID: id}
+					p.stamp(t, out, msg, "")
+				}
+				if r.Err != nil {
+					p.emit(Event{Event: EventOutput, Task: TaskRun, ID: id, Stream: r.Stream, Line: r.Err.Error()})
+				}
+				if r.Out != "" {
+					p.emit(Event{Event: EventOutput, Task: TaskRun, ID: id, Stream: r.Stream, Line: r.Out})
+					line, t := r.Out, "out"
+					if h := match(p.Tools.Run.Rules, r.Out); h != nil {
+						line, t = h.paint(r.Out), h.stamp(t)
 					}
+					msg := fmt.Sprintln(p.prefix(TaskRun), ":", line)
+					out := BufferOut{Time: time.Now(), Text: r.Out, Type: "Go Run", ID: id}
+					p.stamp(t, out, msg, "")
 				}
 			}
-		}()
-		go func() {
-			log.Println(p.pname(p.Name, 1), ":", "Running..")
-			err := p.run(p.Path, result, stop)
-			if err != nil {
-				msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Regular(err))
-				out := BufferOut{Time: time.Now(), Text: err.Error(), Type: "Go Run"}
-				p.stamp("error", out, msg, "")
-			}
-		}()
+		}
+	}()
+	// with a healthcheck the project is running once it passes, a blue-green binary is always checked
+	if p.Tools.Run.Health == nil && port(ctx) == 0 || p.parent.Once {
+		p.running()
 	}
-	if done {
-		return
+	err := p.supervise(ctx, path, result)
+	if ctx.Err() == nil {
+		if err != nil {
+			p.transition(StateFailed)
+		} else {
+			p.transition(StateIdle)
+		}
+		p.finished(Response{Name: TaskRun, Err: err, Code: exitCode(err), ID: id}, start)
+	} else {
+		p.canceled(id, TaskRun, start)
+	}
+	if err != nil {
+		msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Regular(err))
+		out := BufferOut{Time: time.Now(), Text: err.Error(), Type: "Go Run", ID: id}
+		p.stamp("error", out, msg, "")
+	}
+}
+
+// Swap the managed binary, the previous one is stopped before the new one starts
+func (p *Project) swap(bin string) {
+	p.kill()
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &managed{bin: bin, cancel: cancel, done: make(chan bool)}
+	// a binary swapped meanwhile by another run is stopped too
+	if prev := p.handover(m); prev != nil {
+		prev.stop()
+	}
+	go func() {
+		p.launch(ctx, bin)
+		close(m.done)
+	}()
+	if p.parent.Once {
+		<-m.done
+	}
+}
+
+// Kill the managed binary and remove it
+func (p *Project) kill() {
+	if m := p.handover(nil); m != nil {
+		m.stop()
+	}
+}
+
+// Handover replaces the managed binary, the previous one is returned
+func (p *Project) handover(m *managed) (prev *managed) {
+	managedMu.Lock()
+	defer managedMu.Unlock()
+	prev, p.managed = p.managed, m
+	return
+}
+
+// Serving managed binary, nil if none
+func (p *Project) serving() *managed {
+	managedMu.Lock()
+	defer managedMu.Unlock()
+	return p.managed
+}
+
+// Stop a managed binary and remove it
+func (m *managed) stop() {
+	m.cancel()
+	<-m.done
+	if m.bin != "" {
+		os.Remove(m.bin)
 	}
-	p.cmd(stop, "after", false)
 }
 
 // Watch a project
 func (p *Project) Watch(wg *sync.WaitGroup) {
 	var err error
-	// change channel
-	p.stop = make(chan bool)
+	// context of the current run
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	p.runs = &tracker{}
+	p.reaper = &reaper{}
+	p.pump = &pump{}
+	p.rules = &rules{}
+	p.view = &view{}
+	if p.lifecycle == nil {
+		p.lifecycle = &lifecycle{}
+	}
+	if p.records == nil {
+		p.records = &records{}
+	}
 	// init a new watcher
-	p.watcher, err = NewFileWatcher(p.parent.Settings.Legacy)
+	p.watcher, err = p.parent.watcher()
 	if err != nil {
 		log.Fatal(err)
 	}
+	// done after the cleanup, the managed binary and the containers are stopped
+	defer wg.Done()
+	// the messages of the cleanup are written before the exit
+	p.broker = newBroker(p.write)
+	defer p.Sinks.close()
+	defer p.broker.close()
+	if p.Proxy != nil {
+		p.proxy()
+	}
 	defer func() {
-		close(p.stop)
+		p.cancel()
+		if p.queued != nil {
+			p.queued.cancel()
+		}
 		p.watcher.Close()
+		if p.Proxy != nil {
+			p.Proxy.stop()
+		}
+		p.kill()
+		if p.Docker != nil && p.Docker.validate() == nil {
+			execute(context.Background(), p.Path, p.Docker.stop(p))
+		}
+		p.reap()
+		p.transition(StateStopped)
+	}()
+	// scheduled and global keep alive commands live as long as the watcher
+	var end context.CancelFunc
+	p.life, end = context.WithCancel(context.Background())
+	defer end()
+	// before start checks, an exit stops them and runs the after commands
+	ready := make(chan bool)
+	go func() {
+		p.transition(StateIndexing)
+		p.Before()
+		close(ready)
 	}()
-	// before start checks
-	p.Before()
+	select {
+	case <-ready:
+	case sig, ok := <-p.exit:
+		p.cancel()
+		<-ready
+		p.exited(sig, ok)
+		p.After()
+		return
+	}
+	due := p.schedules(p.life)
+	polled := p.polls(p.life)
 	// start watcher
-	go p.Reload("", p.stop)
+	p.begin(&reloading{ctx: p.ctx, cancel: p.cancel})
+	// trailing debounce, the changes are batched until the quiet period ends
+	var changed pending
+	var last fsnotify.Event
+	var settled <-chan time.Time
+	trailing := p.Watcher.trailing()
+	// single files removed from the poller are sent back once recreated
+	recreated := make(chan fsnotify.Event)
+	reload := func(path string, event fsnotify.Event) {
+		if !trailing {
+			p.restart(path, event)
+			return
+		}
+		changed, last = changed.add(path), event
+		settled = time.After(p.Watcher.quiet())
+	}
 L:
 	for {
+		p.show()
 		select {
+		case <-settled:
+			p.settle(changed, last)
+			changed, settled = nil, nil
+		case <-p.busy:
+			p.ended()
+		case event := <-recreated:
+			if !p.paused {
+				p.Change(event)
+				reload(event.Name, event)
+			}
 		case event := <-p.watcher.Events():
+			// the module path names the build output
+			if filepath.Base(event.Name) == "go.mod" {
+				p.reignore()
+			}
+			// missing paths created are watched, the other events of their folders skipped
+			if p.pickup(event) {
+				continue
+			}
+			// directories renamed, removed or created are watched again, even if paused
+			if p.rearm(event) || p.paused {
+				continue
+			}
+			// the outputs of the generate tasks would reload again
+			if p.generated(event.Name) {
+				continue
+			}
 			if p.parent.Settings.Recovery.Events {
 				log.Println("File:", event.Name, "LastFile:", p.last.file, "Time:", time.Now(), "LastTime:", p.last.time)
 			}
-			if time.Now().Truncate(time.Second).After(p.last.time) {
-				// switch event type
-				switch event.Op {
-				case fsnotify.Chmod:
+			// removed files aren't watched anymore, the single ones again once recreated
+			if event.Op == fsnotify.Remove {
+				p.watcher.Remove(event.Name)
+				if p.single(event.Name) {
+					go p.recreate(p.life, event.Name, recreated)
+				}
+			}
+			if trailing || time.Now().Truncate(time.Second).After(p.last.time) {
+				// switch event type, the ops that aren't watched are skipped
+				m, _ := mask(p.Watcher.Ops)
+				switch event.Op & m {
+				case 0:
 				case fsnotify.Remove:
-					p.watcher.Remove(event.Name)
-					if p.Validate(event.Name, false) && ext(event.Name) != "" {
+					// an atomic save of a single file, its create follows
+					if p.single(event.Name) {
+						continue
+					}
+					if p.Validate(event.Name, false) && ext(event.Name) != "" || p.input(event.Name) {
 						// stop and restart
-						close(p.stop)
-						p.stop = make(chan bool)
 						p.Change(event)
-						go p.Reload("", p.stop)
+						reload("", event)
 					}
 				default:
+					if p.single(event.Name) || p.input(event.Name) {
+						// renamed away by an atomic save, its create follows
+						if fi, err := os.Stat(event.Name); err != nil || fi.IsDir() {
+							continue
+						}
+						p.Change(event)
+						reload(event.Name, event)
+						p.last.time = time.Now().Truncate(time.Second)
+						p.last.file = event.Name
+						continue
+					}
 					if p.Validate(event.Name, true) {
 						fi, err := os.Stat(event.Name)
-						if err != nil {
+						if err != nil || fi.IsDir() {
 							continue
 						}
-						if fi.IsDir() {
-							filepath.Walk(event.Name, p.walk)
-						} else {
-							// stop and restart
-							close(p.stop)
-							p.stop = make(chan bool)
-							p.Change(event)
-							go p.Reload(event.Name, p.stop)
-							p.last.time = time.Now().Truncate(time.Second)
-							p.last.file = event.Name
-						}
+						// stop and restart
+						p.Change(event)
+						reload(event.Name, event)
+						p.last.time = time.Now().Truncate(time.Second)
+						p.last.file = event.Name
 					}
 				}
 			}
 		case err := <-p.watcher.Errors():
 			p.Err(err)
-		case <-p.exit:
+		case i := <-due:
+			if !p.paused {
+				go p.scheduled(p.ctx, i)
+			}
+		case i := <-polled:
+			if !p.paused {
+				reload("", p.polled(i))
+			}
+		case action := <-p.control:
+			switch action {
+			case ActionRestart:
+				ctx, cancel := context.WithCancel(context.Background())
+				p.reload(ctx, cancel, "", true)
+			case ActionPause, ActionResume:
+				p.paused = action == ActionPause
+				msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Blue.Bold("Watching"), Magenta.Bold(action+"d"))
+				out := BufferOut{Time: time.Now(), Text: "Watching " + action + "d"}
+				p.stamp("log", out, msg, "")
+			}
+		case c := <-p.changes:
+			p.reignore()
+			switch {
+			case c.imports:
+				p.importDirs(c.dirs)
+			case c.add:
+				p.watchPath(c.path)
+			default:
+				p.unwatchPath(c.path)
+			}
+		case sig, ok := <-p.exit:
+			p.exited(sig, ok)
 			p.After()
 			break L
 		}
 	}
+}
+
+// Restart reloads with a new context as the overlap policy, the file event is its trigger
+func (p *Project) restart(path string, events ...fsnotify.Event) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.reload(triggered(ctx, events...), cancel, path, false)
+}
+
+// Context of the current run, a background context before the first one
+func (p *Project) context() context.Context {
+	if p.ctx == nil {
+		return context.Background()
+	}
+	return p.ctx
+}
+
+// Once runs the project tasks a single time without watching
+func (p *Project) Once(wg *sync.WaitGroup) {
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	p.runs = &tracker{}
+	p.reaper = &reaper{}
+	p.pump = &pump{}
+	p.rules = &rules{}
+	if p.lifecycle == nil {
+		p.lifecycle = &lifecycle{}
+	}
+	if p.records == nil {
+		p.records = &records{}
+	}
+	p.broker = newBroker(p.write)
+	done := make(chan bool)
+	go func() {
+		p.transition(StateIndexing)
+		p.Before()
+		p.Reload(p.ctx, "")
+		close(done)
+	}()
+	select {
+	case <-done:
+		p.reason = "completed"
+	case sig, ok := <-p.exit:
+		p.exited(sig, ok)
+	}
+	p.cancel()
+	p.After()
+	p.kill()
+	p.reap()
+	p.transition(StateStopped)
+	// the messages are written before the exit
+	p.broker.close()
+	p.Sinks.close()
 	wg.Done()
 }
 
 // Validate a file path
 func (p *Project) Validate(path string, fcheck bool) bool {
-	if len(path) == 0 {
+	return p.validate(path, fcheck, p.ignoring())
+}
+
+// Validate a path with the ignore rules resolved
+func (p *Project) validate(path string, fcheck bool, ignoring ignoring) bool {
+	if len(path) == 0 || p.rejected(path, ignoring) != "" {
 		return false
 	}
+	// file check
+	if fcheck {
+		fi, err := os.Stat(path)
+		if err != nil || fi.Mode()&os.ModeSymlink != 0 || !fi.IsDir() && ext(path) == "" || fi.Size() <= 0 {
+			return false
+		}
+	}
+	return true
+
+}
+
+// Rejected returns why a path doesn't trigger a reload regardless of its file, empty if it does
+func (p *Project) rejected(path string, ignoring ignoring) string {
 	// check if skip hidden
-	if p.Watcher.Hidden && isHidden(path) {
-		return false
+	if p.Watcher.Hidden && isHidden(path, p.Watcher.HiddenExcept) {
+		return "hidden"
 	}
 	// check for a valid ext or path
 	if e := ext(path); e != "" {
 		if len(p.Watcher.Exts) == 0 {
-			return false
+			return "no extensions watched"
 		}
 		// check ignored
-		for _, v := range p.Watcher.Ignore {
-			if v == e {
-				return false
-			}
+		if ignoring.has(p.Watcher.Ignore, e) {
+			return "extension " + e + " ignored"
 		}
 		// supported extensions
-		for index, v := range p.Watcher.Exts {
-			if e == v {
-				break
-			}
-			if index == len(p.Watcher.Exts)-1 {
-				return false
-			}
+		if !ignoring.has(p.Watcher.Exts, e) {
+			return "extension " + e + " not watched"
 		}
 	}
-	if p.shouldIgnore(path) {
-		return false
+	abs, _ := filepath.Abs(filepath.FromSlash(path))
+	if rule := ignoring.by(abs); rule != "" {
+		return "ignored by " + rule
 	}
-	// file check
-	if fcheck {
-		fi, err := os.Stat(path)
-		if err != nil || fi.Mode()&os.ModeSymlink != 0 || !fi.IsDir() && ext(path) == "" || fi.Size() <= 0 {
-			return false
+	// the coverage report is written by each test run
+	if p.Tools.Test.Cover {
+		if html, profile := p.coverage(); ignoring.same(abs, html) || ignoring.same(abs, profile) {
+			return "coverage report"
 		}
 	}
-	return true
-
+	// the index is written by each successful run
+	if p.index != nil && ignoring.same(filepath.Base(abs), FileIndex) {
+		return "index of the runs"
+	}
+	return ""
 }
 
 // Defines the colors scheme for the project name
@@ -407,8 +1147,120 @@ func (p *Project) pname(name string, color int) string {
 	return name
 }
 
-//  Tool logs the result of a go command
-func (p *Project) tools(stop <-chan bool, path string, fi os.FileInfo) {
+// Prefix of the output lines of a task, aligned to the longest label and colored by project name
+func (p *Project) prefix(task string) string {
+	label := strings.ToUpper(p.Name) + "|" + task
+	h := fnv.New32a()
+	h.Write([]byte(p.Name))
+	c := palette[h.Sum32()%uint32(len(palette))]
+	pad := ""
+	if p.parent != nil && p.parent.labels > len(label) {
+		pad = strings.Repeat(" ", p.parent.labels-len(label))
+	}
+	return Yellow.Regular("[") + c.Bold(label) + Yellow.Regular("]") + pad
+}
+
+// Prefixed lines of a task output, error lines are red
+func (p *Project) prefixed(task string, text string, err bool) string {
+	text = strings.TrimRight(text, "\r\n")
+	if text == "" {
+		return ""
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if err {
+			line = Red.Regular(line)
+		}
+		lines[i] = p.prefix(task) + " " + line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Tasks of the project that print an output
+func (p *Project) labels() []string {
+	tasks := []string{TaskRun, "verify"}
+	if p.Docker != nil {
+		tasks = append(tasks, TaskDocker)
+	}
+	if p.Remote != nil {
+		tasks = append(tasks, TaskSync)
+	}
+	v := reflect.ValueOf(p.Tools)
+	for i := 0; i < v.NumField()-1; i++ {
+		if tool := v.Field(i).Interface().(Tool); tool.Status {
+			tasks = append(tasks, strings.ToLower(v.Type().Field(i).Name))
+		}
+	}
+	for _, c := range p.Watcher.scripts() {
+		tasks = append(tasks, c.label())
+	}
+	return tasks
+}
+
+// Label of a command, its name or the executable
+func (c *Command) label() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	if fields, _ := words(c.line()); len(fields) > 0 {
+		return filepath.Base(fields[0])
+	}
+	if c.WaitFor != nil {
+		return "wait"
+	}
+	return "command"
+}
+
+// Run the command once its wait conditions are met, a command without cmd only waits
+func (c *Command) run(ctx context.Context, base string) Response {
+	if c.WaitFor != nil {
+		dir := base
+		if c.Path != "" && !filepath.IsAbs(c.Path) {
+			dir = filepath.Join(base, c.Path)
+		}
+		if err := c.WaitFor.wait(ctx, dir); err != nil {
+			return Response{Name: c.WaitFor.String(), Err: waitError{err}}
+		}
+		if c.line() == "" {
+			return Response{Name: c.WaitFor.String()}
+		}
+	}
+	// a custom kind is run by its executor
+	if e := c.parent.executor(c); e != nil || c.Kind != "" {
+		return c.execute(ctx, e)
+	}
+	return c.exec(ctx, base)
+}
+
+// Log file of the command, appended and relative to the project
+func (c *Command) logFile(base string) (*os.File, error) {
+	path := c.Log
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(base, path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+}
+
+// Command build the process, through the shell or splitting the line in words
+func (c *Command) command() (*exec.Cmd, error) {
+	if c.Shell {
+		return shell(c.Cmd), nil
+	}
+	args, err := words(c.Cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, errors.New("Empty command")
+	}
+	return exec.Command(args[0], args[1:]...), nil
+}
+
+// Tool logs the result of a go command
+func (p *Project) tools(ctx context.Context, path string, fi os.FileInfo) {
 	done := make(chan bool)
 	result := make(chan Response)
 	v := reflect.ValueOf(p.Tools)
@@ -416,14 +1268,13 @@ func (p *Project) tools(stop <-chan bool, path string, fi os.FileInfo) {
 		for i := 0; i < v.NumField()-1; i++ {
 			tool := v.Field(i).Interface().(Tool)
 			tool.parent = p
-			if tool.Status && tool.isTool {
-				if fi.IsDir() {
-					if tool.dir {
-						result <- tool.Exec(path, stop)
-					}
-				} else if !tool.dir {
-					result <- tool.Exec(path, stop)
-				}
+			if tool.Status && tool.isTool && fi.IsDir() == tool.dir {
+				start := time.Now()
+				id := p.started(ctx, tool.name)
+				r := tool.Exec(ctx, path)
+				r.ID = id
+				p.finished(r, start)
+				result <- r
 			}
 		}
 		close(done)
@@ -432,154 +1283,396 @@ func (p *Project) tools(stop <-chan bool, path string, fi os.FileInfo) {
 		select {
 		case <-done:
 			return
-		case <-stop:
+		case <-ctx.Done():
 			return
 		case r := <-result:
+			p.diagnose(&r)
+			p.results.send(r)
 			if r.Err != nil {
 				if fi.IsDir() {
 					path, _ = filepath.Abs(fi.Name())
 				}
-				msg = fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold(r.Name), Red.Regular("there are some errors in"), ":", Magenta.Bold(path))
-				buff := BufferOut{Time: time.Now(), Text: "there are some errors in", Path: path, Type: r.Name, Stream: r.Err.Error()}
-				p.stamp("error", buff, msg, r.Err.Error())
+				msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold(r.Name), Red.Regular("there are some errors in"), ":", Magenta.Bold(path))
+				buff := BufferOut{Time: time.Now(), Text: "there are some errors in", Path: path, Type: r.Name, Stream: r.Err.Error(), ID: r.ID}
+				stream := p.prefixed(strings.ToLower(r.Name), r.Err.Error(), false)
+				// the linters output is replaced by its diagnostics
+				if strings.EqualFold(r.Name, "lint") && len(r.Diagnostics) > 0 {
+					stream = strings.Join(p.describe(r.Diagnostics), "\n")
+				}
+				p.stamp("error", buff, msg, stream)
+				p.fail(r)
 			} else if r.Out != "" {
-				msg = fmt.Sprintln(p.pname(p.Name, 3), ":", Red.Bold(r.Name), Red.Regular("outputs"), ":", Blue.Bold(path))
-				buff := BufferOut{Time: time.Now(), Text: "outputs", Path: path, Type: r.Name, Stream: r.Out}
-				p.stamp("out", buff, msg, r.Out)
+				msg := fmt.Sprintln(p.pname(p.Name, 3), ":", Red.Bold(r.Name), Red.Regular("outputs"), ":", Blue.Bold(path))
+				buff := BufferOut{Time: time.Now(), Text: "outputs", Path: path, Type: r.Name, Stream: r.Out, ID: r.ID}
+				p.stamp("out", buff, msg, p.prefixed(strings.ToLower(r.Name), r.Out, false))
 			}
 		}
 	}
 }
 
 // Cmd after/before
-func (p *Project) cmd(stop <-chan bool, flag string, global bool) {
+func (p *Project) cmd(ctx context.Context, flag string, global bool) (results []Response) {
+	type labeled struct {
+		Response
+		cmd Command
+	}
 	done := make(chan bool)
-	result := make(chan Response)
+	result := make(chan labeled)
 	// commands sequence
 	go func() {
-		for _, cmd := range p.Watcher.Scripts {
-			if strings.ToLower(cmd.Type) == flag && cmd.Global == global {
-				result <- cmd.exec(p.Path, stop)
+		for _, cmd := range p.Watcher.scripts() {
+			if strings.ToLower(cmd.Type) == flag && cmd.Global == global && cmd.Schedule == "" {
+				// on startup only, the global before commands or the commands of the first run
+				if cmd.Creates != "" && (global && flag == "before" || !global && p.first) && p.fresh(cmd.Creates) {
+					p.skipped(cmd.label())
+					continue
+				}
+				cmd.parent = p
+				// in the background, the global ones as long as the watcher
+				if cmd.Alive {
+					alive := ctx
+					if global {
+						alive = p.lifetime()
+					}
+					go p.keep(alive, flag, cmd)
+					continue
+				}
+				start := time.Now()
+				id := p.started(ctx, cmd.line())
+				r := p.cached(ctx, cmd)
+				r.ID = id
+				p.finished(r, start)
+				// the results aren't read anymore once the context is done
+				select {
+				case result <- labeled{r, cmd}:
+				case <-ctx.Done():
+					return
+				}
+				// abort the remaining commands, always when a wait failed
+				if _, waited := r.Err.(waitError); r.Err != nil && p.Watcher.FailFast || waited {
+					break
+				}
 			}
 		}
 		close(done)
 	}()
 	for {
 		select {
-		case <-stop:
+		case <-ctx.Done():
 			return
 		case <-done:
 			return
-		case r := <-result:
-			msg = fmt.Sprintln(p.pname(p.Name, 5), ":", Green.Bold("Command"), Green.Bold("\"")+r.Name+Green.Bold("\""))
-			if r.Err != nil {
-				out = BufferOut{Time: time.Now(), Text: r.Err.Error(), Type: flag}
-				p.stamp("error", out, msg, fmt.Sprint(Red.Regular(r.Err.Error())))
-			} else {
-				out = BufferOut{Time: time.Now(), Text: r.Out, Type: flag}
-				p.stamp("log", out, msg, fmt.Sprint(r.Out))
-			}
+		case l := <-result:
+			p.diagnose(&l.Response)
+			results = append(results, l.Response)
+			p.results.send(l.Response)
+			p.report(flag, l.cmd, l.Response)
 		}
 	}
 }
 
 // Watch the files tree of a project
 func (p *Project) walk(path string, info os.FileInfo, err error) error {
-	if p.shouldIgnore(path) {
+	if err != nil {
+		p.set.fail(path, err)
+		return nil
+	}
+	if !p.admit(p.check(p.ignoring(), path, info)) {
 		return filepath.SkipDir
 	}
+	return nil
+}
 
-	if p.Validate(path, true) {
-		result := p.watcher.Walk(path, p.init)
+// Admit a checked path to the watcher, it reports if its folder is walked
+func (p *Project) admit(s scanned) bool {
+	if s.rule != "" {
+		p.set.ignore(s.rule)
+		return false
+	}
+	if s.deep {
+		return false
+	}
+	path, info := s.path, s.info
+	if s.valid {
+		// no watcher running once
+		result := path
+		if p.watcher != nil {
+			result = p.watcher.Walk(path, p.init)
+		}
 		if result != "" {
 			if p.parent.Settings.Recovery.Index {
 				log.Println("Indexing", path)
 			}
-			p.tools(p.stop, path, info)
+			if !p.indexed(path, info) {
+				p.tools(p.context(), path, info)
+			}
 			if info.IsDir() {
 				// tools dir
 				p.folders++
+				if p.dirs == nil {
+					p.dirs = make(map[string]bool)
+				}
+				p.dirs[path] = true
+				p.crowd()
 			} else {
 				// tools files
-				p.files++
+				if p.admitted == nil {
+					p.admitted = make(map[string]bool)
+				}
+				if !p.admitted[path] {
+					p.admitted[path] = true
+					p.files++
+				}
+				p.delta.keep(path, info)
 			}
+		} else {
+			p.unwatched(path, info)
 		}
 	}
-	return nil
+	return true
 }
 
-func (p *Project) shouldIgnore(path string) bool {
-	separator := string(os.PathSeparator)
-	// supported paths
-	for _, v := range p.Watcher.Ignore {
-		s := append([]string{p.Path}, strings.Split(v, separator)...)
-		abs, _ := filepath.Abs(filepath.Join(s...))
-		if path == abs || strings.HasPrefix(path, abs+separator) {
-			return true
+// WatchPath adds a path of the project to the watcher and indexes its files
+func (p *Project) watchPath(path string) {
+	dir := p.abs(path)
+	covered := p.watched(dir)
+	p.Watcher.Paths = append(p.Watcher.Paths, path)
+	if !covered {
+		p.indexPath(path)
+		p.exhaust()
+	}
+	msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Blue.Bold("Watching"), Magenta.Bold(path))
+	out := BufferOut{Time: time.Now(), Text: "Watching " + path}
+	p.stamp("log", out, msg, "")
+}
+
+// UnwatchPath removes a path of the project from the watcher, the files still inside another path are kept
+func (p *Project) unwatchPath(path string) {
+	for i, v := range p.Watcher.Paths {
+		if filepath.Clean(v) == path {
+			p.Watcher.Paths = append(p.Watcher.Paths[:i], p.Watcher.Paths[i+1:]...)
+			break
 		}
 	}
-	return false
+	p.set.drop(path)
+	p.release()
+	// the counted files and folders, even if already removed from the disk
+	dir := p.abs(path)
+	for name := range p.dirs {
+		if within(name, dir) && !p.watched(name) {
+			p.watcher.Remove(name)
+			delete(p.dirs, name)
+			p.folders--
+		}
+	}
+	for name := range p.admitted {
+		if within(name, dir) && !p.watched(name) {
+			p.watcher.Remove(name)
+			delete(p.admitted, name)
+			p.files--
+		}
+	}
+	msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Blue.Bold("Unwatched"), Magenta.Bold(path))
+	out := BufferOut{Time: time.Now(), Text: "Unwatched " + path}
+	p.stamp("log", out, msg, "")
 }
 
-// Print on files, cli, ws
-func (p *Project) stamp(t string, o BufferOut, msg string, stream string) {
-	ctime := time.Now()
-	content := []string{ctime.Format("2006-01-02 15:04:05"), strings.ToUpper(p.Name), ":", o.Text, "\r\n", stream}
-	switch t {
-	case "out":
-		p.Buffer.StdOut = append(p.Buffer.StdOut, o)
-		if p.parent.Settings.Files.Outputs.Status {
-			f := p.parent.Settings.Create(p.Path, p.parent.Settings.Files.Outputs.Name)
-			if _, err := f.WriteString(strings.Join(content, " ")); err != nil {
-				p.parent.Settings.Fatal(err, "")
+// Rearm the watches of the directories renamed, removed or created, it reports if the event is about a directory.
+// The parent is walked again, a directory renamed inside the watched paths or already recreated is watched with its files.
+func (p *Project) rearm(event fsnotify.Event) bool {
+	switch {
+	case event.Op&(fsnotify.Rename|fsnotify.Remove) != 0 && p.dirs[event.Name]:
+		for dir := range p.dirs {
+			if within(dir, event.Name) {
+				p.watcher.Remove(dir)
+				delete(p.dirs, dir)
+				p.folders--
 			}
 		}
-	case "log":
-		p.Buffer.StdLog = append(p.Buffer.StdLog, o)
-		if p.parent.Settings.Files.Logs.Status {
-			f := p.parent.Settings.Create(p.Path, p.parent.Settings.Files.Logs.Name)
-			if _, err := f.WriteString(strings.Join(content, " ")); err != nil {
-				p.parent.Settings.Fatal(err, "")
-			}
+	case event.Op&fsnotify.Create != 0:
+		if fi, err := os.Stat(event.Name); err != nil || !fi.IsDir() {
+			return false
 		}
-	case "error":
-		p.Buffer.StdErr = append(p.Buffer.StdErr, o)
-		if p.parent.Settings.Files.Errors.Status {
-			f := p.parent.Settings.Create(p.Path, p.parent.Settings.Files.Errors.Name)
-			if _, err := f.WriteString(strings.Join(content, " ")); err != nil {
-				p.parent.Settings.Fatal(err, "")
-			}
+	default:
+		return false
+	}
+	filepath.Walk(filepath.Dir(event.Name), func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() || p.dirs[path] || !p.watched(path) {
+			return nil
+		}
+		if p.shouldIgnore(path) {
+			return filepath.SkipDir
+		}
+		filepath.Walk(path, p.walk)
+		return filepath.SkipDir
+	})
+	p.exhaust()
+	return true
+}
+
+// Depth of a path below the nearest watched path containing it, -1 if outside
+func (p *Project) depth(path string) int {
+	depth := -1
+	for _, v := range p.Watcher.Paths {
+		dir := p.abs(v)
+		if !within(path, dir) {
+			continue
+		}
+		d := 0
+		if rel, _ := filepath.Rel(dir, path); rel != "." {
+			d = strings.Count(rel, string(os.PathSeparator)) + 1
+		}
+		if depth == -1 || d < depth {
+			depth = d
 		}
 	}
-	if msg != "" {
-		log.Print(msg)
+	return depth
+}
+
+// Crowd warns once when the watched folders exceed max dirs, 1000 by default, each one takes an inotify watch
+func (p *Project) crowd() {
+	max := p.Watcher.MaxDirs
+	if max == 0 {
+		max = 1000
 	}
-	if stream != "" {
-		fmt.Fprintln(Output, stream)
+	if p.crowded || p.folders <= int64(max) {
+		return
 	}
-	go func() {
-		p.parent.Sync <- "sync"
-	}()
+	p.crowded = true
+	text := "Watching more than " + strconv.Itoa(max) + " folders, add the generated ones to the ignored paths or set a max depth"
+	msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Yellow.Regular(text))
+	out := BufferOut{Time: time.Now(), Text: text}
+	p.stamp("warn", out, msg, "")
+}
+
+// Watched reports if an absolute path is inside one of the watched paths
+func (p *Project) watched(path string) bool {
+	for _, v := range p.Watcher.Paths {
+		if within(path, p.abs(v)) {
+			return true
+		}
+	}
+	return false
 }
 
-func (p Project) buildEnvs() (envs []string) {
+// Abs path of a watched path, it's relative to the project
+func (p *Project) abs(path string) string {
+	base, _ := filepath.Abs(p.Path)
+	return filepath.Join(base, path)
+}
+
+// ShouldIgnore reports if a path is inside an ignored path, they are relative to the project and matched by whole segments
+func (p *Project) shouldIgnore(path string) bool {
+	return p.ignoredBy(path) != ""
+}
+
+// IgnoredBy returns the rule ignoring a path: a default one, the cache or an ignored path, empty if none
+func (p *Project) ignoredBy(path string) string {
+	path, _ = filepath.Abs(path)
+	return p.ignoring().by(path)
+}
+
+// Print on files, cli, ws and the other sinks
+func (p *Project) stamp(t string, o BufferOut, msg string, stream string) {
+	p.publish(p.message(t, o, msg, stream))
+}
+
+func (p *Project) buildEnvs() (envs []string) {
+	// the env files are overridden by the project env
+	env := make(map[string]string, len(p.fileEnv)+len(p.Env))
+	for k, v := range p.fileEnv {
+		env[k] = v
+	}
 	for k, v := range p.Env {
+		env[k] = v
+	}
+	for k, v := range env {
 		envs = append(envs, fmt.Sprintf("%s=%s", strings.Replace(k, "=", "", -1), v))
 	}
 	return
 }
 
+// Args splits the additional arguments of the project
+func (p *Project) args() (args []string) {
+	for _, arg := range p.Args {
+		a := strings.FieldsFunc(arg, func(i rune) bool {
+			return i == '"' || i == '=' || i == '\''
+		})
+		args = append(args, a...)
+	}
+	return
+}
+
+// Binary looks for the project executable in GOBIN or in the run path
+func (p *Project) binary(path string, args []string) (*exec.Cmd, error) {
+	path = p.binPath(path)
+	if _, err := os.Stat(path); err == nil {
+		return exec.Command(path, args...), nil
+	}
+	if _, err := os.Stat(path + RExtWin); err == nil {
+		return exec.Command(path+RExtWin, args...), nil
+	}
+	return nil, errors.New("project not found")
+}
+
+// BinPath is the expected path of the project executable
+func (p *Project) binPath(path string) string {
+	dirPath := gobin()
+	if p.Tools.Run.Path != "" {
+		dirPath, _ = filepath.Abs(p.Tools.Run.Path)
+	}
+	name := filepath.Base(path)
+	if path == "." && p.Tools.Run.Path == "" {
+		name = filepath.Base(Wdir())
+	} else if p.Tools.Run.Path != "" {
+		name = filepath.Base(dirPath)
+	}
+	if p.Tools.Run.Method != "" {
+		return p.Tools.Run.Method
+	}
+	return filepath.Join(dirPath, name)
+}
+
 // Run a project
-func (p *Project) run(path string, stream chan Response, stop <-chan bool) (err error) {
+func (p *Project) run(ctx context.Context, path string, stream chan Response) (err error) {
 	var args []string
 	var build *exec.Cmd
 	var r Response
+	// started in its own group, interrupted with its children
+	var grouped bool
+	// released once the program is reaped
+	defer p.hold()()
 	defer func() {
 		// https://github.com/golang/go/issues/5615
 		// https://github.com/golang/go/issues/6720
-		if build != nil {
-			build.Process.Signal(os.Interrupt)
-			build.Process.Wait()
+		if build != nil && build.Process != nil {
+			// the group of a command line, a go run program is a child of the go command
+			var ierr error
+			if grouped {
+				ierr = interrupt(build)
+			}
+			if !grouped || ierr != nil {
+				ierr = build.Process.Signal(os.Interrupt)
+			}
+			// the interrupt isn't supported on windows
+			if ierr != nil {
+				terminate(build)
+			}
+			// still running after the shutdown timeout, it's killed with its children
+			exited := make(chan bool)
+			go func() {
+				select {
+				case <-exited:
+				case <-time.After(p.parent.Settings.timeout()):
+					terminate(build)
+				}
+			}()
+			// exited by itself, the failure is reported
+			state, werr := build.Process.Wait()
+			close(exited)
+			if err == nil && werr == nil && ctx.Err() == nil && !state.Success() {
+				err = &exec.ExitError{ProcessState: state}
+			}
 		}
 	}()
 
@@ -596,77 +1689,113 @@ func (p *Project) run(path string, stream chan Response, stop <-chan bool) (err
 	}
 
 	// add additional arguments
-	for _, arg := range p.Args {
-		a := strings.FieldsFunc(arg, func(i rune) bool {
-			return i == '"' || i == '=' || i == '\''
-		})
-		args = append(args, a...)
-	}
-	dirPath := os.Getenv("GOBIN")
-	if p.Tools.Run.Path != "" {
-		dirPath, _ = filepath.Abs(p.Tools.Run.Path)
-	}
-	name := filepath.Base(path)
-	if path == "." && p.Tools.Run.Path == "" {
-		name = filepath.Base(Wdir())
-	} else if p.Tools.Run.Path != "" {
-		name = filepath.Base(dirPath)
-	}
-	path = filepath.Join(dirPath, name)
-	if p.Tools.Run.Method != "" {
-		path = p.Tools.Run.Method
-	}
-	if _, err := os.Stat(path); err == nil {
+	args = p.args()
+	// custom command, the args are part of its line
+	if p.Tools.Run.Command != "" {
+		build = shell(p.Tools.Run.Command)
+		build.Dir = workdir(p.Path, "")
+	} else if p.Tools.Run.Managed {
 		build = exec.Command(path, args...)
-	} else if _, err := os.Stat(path + RExtWin); err == nil {
-		build = exec.Command(path+RExtWin, args...)
-	} else {
-		if _, err = os.Stat(path); err == nil {
-			build = exec.Command(path, args...)
-		} else if _, err = os.Stat(path + RExtWin); err == nil {
-			build = exec.Command(path+RExtWin, args...)
-		} else {
-			return errors.New("project not found")
-		}
+	} else if build, err = p.binary(path, args); err != nil {
+		return err
 	}
 	appendEnvs := p.buildEnvs()
+	// the port of a blue-green binary
+	if port := port(ctx); port > 0 {
+		appendEnvs = append(appendEnvs, "PORT="+strconv.Itoa(port))
+	}
 	if len(appendEnvs) > 0 {
-		build.Env = append(build.Env, appendEnvs...)
+		build.Env = append(os.Environ(), appendEnvs...)
 	}
-	// scan project stream
-	stdout, err := build.StdoutPipe()
-	stderr, err := build.StderrPipe()
-	if err != nil {
+	if p.Tools.Run.Dir != "" {
+		build.Dir = workdir(p.Path, p.Tools.Run.Dir)
+	}
+	if p.Tools.Run.Stdin && !p.parent.Tui {
+		build.Stdin = os.Stdin
+	}
+	if err := runAs(build, p.Tools.Run.User, p.Tools.Run.Group); err != nil {
 		return err
 	}
-	if p.Tools.Run.Dir != "" {
-		build.Dir = p.Tools.Run.Dir
+	// scan project stream
+	var stdout, stderr io.Reader
+	var slave *os.File
+	stopOutput, stopError := make(chan bool, 1), make(chan bool, 1)
+	if p.Tools.Run.Pty {
+		var master *os.File
+		master, slave, err = openPty()
+		if err != nil {
+			return err
+		}
+		defer master.Close()
+		attachPty(build, slave)
+		// a session of its own
+		grouped = p.Tools.Run.Command != ""
+		if p.Tools.Run.Stdin && !p.parent.Tui {
+			defer p.stdin(master)()
+		}
+		// stdout and stderr are merged
+		stdout, stderr, stopError = master, nil, nil
+	} else {
+		// the children of the command are stopped with it, the stdin is left in the foreground
+		if p.Tools.Run.Command != "" && build.Stdin == nil {
+			group(build)
+			grouped = true
+		}
+		if stdout, err = build.StdoutPipe(); err != nil {
+			return err
+		}
+		if stderr, err = build.StderrPipe(); err != nil {
+			return err
+		}
 	}
 	if err := build.Start(); err != nil {
 		return err
 	}
-	execOutput, execError := bufio.NewScanner(stdout), bufio.NewScanner(stderr)
-	stopOutput, stopError := make(chan bool, 1), make(chan bool, 1)
+	if slave != nil {
+		slave.Close()
+	}
+	p.parent.forward.add(build.Process, p.Tools.Run.Signals)
+	defer p.parent.forward.remove(build.Process)
+	// the lines over the rate are suppressed, the marker of the last ones once the output ends
+	throttler := p.Tools.Run.Throttle.throttler()
 	scanner := func(stop chan bool, output *bufio.Scanner, isError bool) {
-		for output.Scan() {
-			text := output.Text()
+		defer close(stop)
+		send := func(text string) bool {
+			r := Response{Stream: StreamStdout}
+			if isError {
+				r.Stream = StreamStderr
+			}
 			if isError && !isErrorText(text) {
 				r.Err = errors.New(text)
-				stream <- r
-				r.Err = nil
 			} else {
 				r.Out = text
-				stream <- r
-				r.Out = ""
+			}
+			// the reader is gone after cancel
+			select {
+			case stream <- r:
+				return true
+			case <-ctx.Done():
+				return false
 			}
 		}
-		close(stop)
+		for output.Scan() {
+			for _, text := range throttler.pass(output.Text(), time.Now()) {
+				if !send(text) {
+					return
+				}
+			}
+		}
+		if m := throttler.marker(); m != "" {
+			send(m)
+		}
+	}
+	go scanner(stopOutput, p.Tools.Run.Throttle.scanner(stdout), false)
+	if stderr != nil {
+		go scanner(stopError, p.Tools.Run.Throttle.scanner(stderr), true)
 	}
-	go scanner(stopOutput, execOutput, false)
-	go scanner(stopError, execError, true)
 	for {
 		select {
-		case <-stop:
+		case <-ctx.Done():
 			return
 		case <-stopOutput:
 			return
@@ -678,49 +1807,195 @@ func (p *Project) run(path string, stream chan Response, stop <-chan bool) (err
 
 // Print with time after
 func (r *Response) print(start time.Time, p *Project) {
+	p.diagnose(r)
+	p.record(*r, start)
 	if r.Err != nil {
-		msg = fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold(r.Name), "\n", r.Err.Error())
-		out = BufferOut{Time: time.Now(), Text: r.Err.Error(), Type: r.Name, Stream: r.Out}
-		p.stamp("error", out, msg, r.Out)
+		msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold(r.Name), "\n", r.Err.Error())
+		out := BufferOut{Time: time.Now(), Text: r.Err.Error(), Type: r.Name, Stream: r.Out, ID: r.ID}
+		p.stamp("error", out, msg, p.prefixed(strings.ToLower(r.Name), r.Out, false))
+		p.fail(*r)
 	} else {
-		msg = fmt.Sprintln(p.pname(p.Name, 5), ":", Green.Bold(r.Name), "completed in", Magenta.Regular(big.NewFloat(float64(time.Since(start).Seconds())).Text('f', 3), " s"))
-		out = BufferOut{Time: time.Now(), Text: r.Name + " in " + big.NewFloat(float64(time.Since(start).Seconds())).Text('f', 3) + " s"}
-		p.stamp("log", out, msg, r.Out)
+		msg := fmt.Sprintln(p.pname(p.Name, 5), ":", Green.Bold(r.Name), "completed in", Magenta.Regular(big.NewFloat(float64(time.Since(start).Seconds())).Text('f', 3), " s"))
+		out := BufferOut{Time: time.Now(), Text: r.Name + " in " + big.NewFloat(float64(time.Since(start).Seconds())).Text('f', 3) + " s", ID: r.ID}
+		p.stamp("log", out, msg, p.prefixed(strings.ToLower(r.Name), r.Out, false))
 	}
 }
 
+// Stdin is passed to the command, the terminal ui keeps it
+func (c *Command) stdin() bool {
+	return c.Stdin && (c.parent == nil || c.parent.parent == nil || !c.parent.parent.Tui)
+}
+
 // Exec an additional command from a defined path if specified
-func (c *Command) exec(base string, stop <-chan bool) (response Response) {
+func (c *Command) exec(ctx context.Context, base string) (response Response) {
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
-	done := make(chan error)
-	args := strings.Split(strings.Replace(strings.Replace(c.Cmd, "'", "", -1), "\"", "", -1), " ")
-	ex := exec.Command(args[0], args[1:]...)
-	ex.Dir = base
+	done := make(chan error, 1)
+	// the last command of a pipe gives the output
+	cmds, err := c.commands()
+	if err != nil {
+		response.Name = c.line()
+		response.Err = err
+		return
+	}
+	ex, heads := cmds[len(cmds)-1], cmds[:len(cmds)-1]
 	// make cmd path
+	dir := base
 	if c.Path != "" {
-		if strings.Contains(c.Path, base) {
-			ex.Dir = c.Path
+		if filepath.IsAbs(c.Path) {
+			dir = c.Path
 		} else {
-			ex.Dir = filepath.Join(base, c.Path)
+			dir = filepath.Join(base, c.Path)
+		}
+	}
+	for _, cmd := range cmds {
+		cmd.Dir = dir
+		if c.parent != nil {
+			envs := append(c.parent.buildEnvs(), c.parent.runEnv(ctx)...)
+			cmd.Env = append(os.Environ(), append(envs, c.env...)...)
+		}
+		if err := runAs(cmd, c.User, c.Group); err != nil {
+			response.Name = c.line()
+			response.Err = err
+			return
 		}
 	}
-	ex.Stdout = &stdout
-	ex.Stderr = &stderr
+	var out, errs io.Writer = &stdout, &stderr
+	// throttled lines, the log file has them all
+	flush := func() {}
+	if c.Throttle != nil {
+		out, errs, flush = c.Throttle.writers(out, errs)
+	}
+	// the output is tee'd to the log file
+	if c.Log != "" {
+		f, err := c.logFile(base)
+		if err != nil {
+			response.Name = c.line()
+			response.Err = err
+			return
+		}
+		defer f.Close()
+		out, errs = io.MultiWriter(out, f), io.MultiWriter(errs, f)
+	}
+	if len(heads) > 0 {
+		errs = &locked{w: errs}
+	}
+	ex.Stdout = out
+	for _, cmd := range cmds {
+		cmd.Stderr = errs
+	}
+	if c.stdin() {
+		cmds[0].Stdin = os.Stdin
+	}
+	pipes, err := connect(cmds)
+	if err != nil {
+		response.Name = c.line()
+		response.Err = err
+		return
+	}
+	// pseudo-terminal, stdout and stderr are merged
+	var slave *os.File
+	copied := make(chan bool)
+	if c.Pty && len(heads) == 0 {
+		var master *os.File
+		var err error
+		master, slave, err = openPty()
+		if err != nil {
+			response.Name = c.line()
+			response.Err = err
+			return
+		}
+		defer master.Close()
+		attachPty(ex, slave)
+		go func() {
+			io.Copy(out, master)
+			close(copied)
+		}()
+		if c.stdin() {
+			defer c.parent.stdin(master)()
+		}
+	} else {
+		close(copied)
+	}
+	// go run starts the program as its child, they are stopped with their group
+	// a group in background reading the terminal would be stopped by SIGTTIN, the stdin is left in the foreground
+	run := goRun(c.Cmd)
+	if run && !c.Pty && !c.stdin() {
+		group(ex)
+	}
 	// Start command
-	ex.Start()
-	go func() { done <- ex.Wait() }()
+	for _, cmd := range cmds {
+		cmd.Start()
+	}
+	for _, f := range pipes {
+		f.Close()
+	}
+	if slave != nil {
+		slave.Close()
+	}
+	for _, cmd := range cmds {
+		if cmd.Process == nil {
+			continue
+		}
+		// lower priority and resource limits, once started on its pid only
+		if c.Limits != nil {
+			if err := c.Limits.apply(cmd.Process.Pid); err != nil && c.parent != nil {
+				c.parent.Err(err)
+			}
+		}
+		if c.parent != nil {
+			c.parent.parent.forward.add(cmd.Process, c.Signals)
+			defer c.parent.parent.forward.remove(cmd.Process)
+		}
+	}
+	release := c.parent.hold()
+	go func() {
+		err := ex.Wait()
+		// a pipe fails with the first command failed
+		for _, cmd := range heads {
+			if e := cmd.Wait(); e != nil && err == nil {
+				err = e
+			}
+		}
+		release()
+		done <- err
+	}()
 	// Wait a result
 	select {
-	case <-stop:
+	case <-ctx.Done():
 		// Stop running command
-		ex.Process.Kill()
+		if run {
+			terminate(ex)
+		} else if ex.Process != nil {
+			ex.Process.Kill()
+		}
+		for _, cmd := range heads {
+			if cmd.Process != nil {
+				cmd.Process.Kill()
+			}
+		}
+		go func() {
+			<-done
+			<-copied
+			flush()
+		}()
 	case err := <-done:
 		// Command completed
-		response.Name = c.Cmd
+		<-copied
+		flush()
+		response.Name = c.line()
 		response.Out = stdout.String()
 		if err != nil {
-			response.Err = errors.New(stderr.String() + stdout.String())
+			if stderr.Len()+stdout.Len() == 0 {
+				response.Err = err
+			} else {
+				response.Err = errors.New(stderr.String() + stdout.String())
+			}
+			response.Code = exitCode(err)
+		} else if lines := promoted(c.Rules, stderr.String()+stdout.String()); len(lines) > 0 {
+			// highlighted as errors
+			response.Err = errors.New(strings.Join(lines, "\n"))
+			response.Code = 1
 		}
 	}
 	return
@@ -2,10 +2,14 @@ package realize
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"github.com/fsnotify/fsnotify"
+	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
 	"testing"
@@ -94,8 +98,7 @@ func TestProject_Reload(t *testing.T) {
 	r.Reload = func(context Context) {
 		log.Println(context.Path)
 	}
-	stop := make(chan bool)
-	r.Projects[0].Reload(input, stop)
+	r.Projects[0].Reload(context.Background(), input)
 	if !strings.Contains(buf.String(), input) {
 		t.Error("Unexpected error")
 	}
@@ -128,6 +131,47 @@ func TestProject_Validate(t *testing.T) {
 	}
 }
 
+func TestProject_ShouldIgnore(t *testing.T) {
+	p := Project{Path: "/app", Watcher: Watch{Ignore: []string{"api", "internal/gen/", "/vendor"}}}
+	data := map[string]bool{
+		"/app/api":                  true,
+		"/app/api/main.go":          true,
+		"/app/apiserver/main.go":    false,
+		"/app/internal/apiserver":   false,
+		"/app/internal/api/main.go": false,
+		"/app/internal/gen/a.go":    true,
+		"/app/internal/generated/a": false,
+		"/app/vendor/pkg/a.go":      true,
+		"/app/vendored/a.go":        false,
+		"/app/../api/main.go":       false,
+		"/other/app/api/main.go":    false,
+		"/app/internal/../api/a.go": true,
+		"/app/internal/gen/../x.go": false,
+	}
+	for path, expected := range data {
+		path = filepath.FromSlash(path)
+		if abs, _ := filepath.Abs(path); p.shouldIgnore(abs) != expected {
+			t.Error("Unexpected ignore of", path, "expected", expected)
+		}
+	}
+}
+
+func TestWithin(t *testing.T) {
+	data := map[[2]string]bool{
+		{"/a/b", "/a"}:   true,
+		{"/a", "/a"}:     true,
+		{"/ab", "/a"}:    false,
+		{"/a/..b", "/a"}: true,
+		{"/a", "/a/b"}:   false,
+		{"a/b", "/a"}:    false,
+	}
+	for v, expected := range data {
+		if within(filepath.FromSlash(v[0]), filepath.FromSlash(v[1])) != expected {
+			t.Error("Unexpected result", v, "expected", expected)
+		}
+	}
+}
+
 func TestProject_Watch(t *testing.T) {
 	var wg sync.WaitGroup
 	r := Realize{}
@@ -144,3 +188,416 @@ func TestProject_Watch(t *testing.T) {
 	r.Projects[0].Watch(&wg)
 	wg.Wait()
 }
+
+func TestCommand_Stdin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("No cat on Windows")
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+	w.WriteString("input")
+	w.Close()
+	c := Command{Cmd: "cat", Stdin: true}
+	response := c.exec(context.Background(), os.TempDir())
+	if response.Out != "input" {
+		t.Error("Expected input instead", response.Out)
+	}
+}
+
+func TestCommand_Pty(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Pty supported only on Linux")
+	}
+	c := Command{Cmd: "test -t 1", Pty: true}
+	response := c.exec(context.Background(), os.TempDir())
+	if response.Err != nil {
+		t.Error("Expected a terminal", response.Err)
+	}
+	c = Command{Cmd: "test -t 1"}
+	response = c.exec(context.Background(), os.TempDir())
+	if response.Err == nil {
+		t.Error("Unexpected terminal")
+	}
+}
+
+func TestCommand_Shell(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("No sh on Windows")
+	}
+	c := Command{Cmd: "echo 'a|b' | tr '|' -", Shell: true}
+	response := c.exec(context.Background(), os.TempDir())
+	if response.Err != nil || response.Out != "a-b\n" {
+		t.Error("Unexpected output", response.Out, response.Err)
+	}
+	c = Command{Cmd: "echo 'a|b' | tr '|' -"}
+	response = c.exec(context.Background(), os.TempDir())
+	if response.Out != "a|b | tr | -\n" {
+		t.Error("Unexpected output", response.Out)
+	}
+	c = Command{Cmd: "echo 'open"}
+	if response = c.exec(context.Background(), os.TempDir()); response.Err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestCommand_Log(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("No sh on Windows")
+	}
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := Command{Cmd: "echo out; echo err >&2", Shell: true, Log: "logs/cmd.log"}
+	for i := 0; i < 2; i++ {
+		if response := c.exec(context.Background(), dir); response.Err != nil || response.Out != "out\n" {
+			t.Error("Unexpected output", response.Out, response.Err)
+		}
+	}
+	// appended, both streams
+	content, _ := ioutil.ReadFile(filepath.Join(dir, "logs", "cmd.log"))
+	if strings.Count(string(content), "out\n") != 2 || strings.Count(string(content), "err\n") != 2 {
+		t.Error("Unexpected log", string(content))
+	}
+}
+
+func TestProject_Verify(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("No true/false on Windows")
+	}
+	m := mockNotifier{}
+	r := Realize{}
+	r.Notify.Add(&m)
+	r.Projects = append(r.Projects, Project{
+		parent: &r,
+		Name:   "test",
+		Path:   os.TempDir(),
+		Verify: &Command{Cmd: "false"},
+	})
+	r.Projects[0].verify(context.Background(), Response{Name: "Build"})
+	if len(m.sent) != 1 || m.sent[0].Status != StatusFailure {
+		t.Fatal("Expected a failure", m.sent)
+	}
+	r.Projects[0].Verify.Cmd = "true"
+	r.Projects[0].verify(context.Background(), Response{Name: "Build"})
+	if len(m.sent) != 2 || m.sent[1].Status != StatusSuccess {
+		t.Fatal("Expected a success", m.sent)
+	}
+	r.Projects[0].verify(context.Background(), Response{Name: "Build", Err: errors.New("error")})
+	if len(m.sent) != 3 || m.sent[2].Status != StatusFailure {
+		t.Fatal("Expected a failure", m.sent)
+	}
+}
+
+func TestProject_AfterTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("No sleep on Windows")
+	}
+	r := Realize{}
+	r.Settings.Shutdown = 100 * time.Millisecond
+	r.Projects = append(r.Projects, Project{
+		parent: &r,
+		Path:   os.TempDir(),
+		Watcher: Watch{
+			Scripts: []Command{
+				{Type: "after", Cmd: "true", Global: true},
+				{Type: "after", Cmd: "sleep 5", Global: true},
+			},
+		},
+	})
+	start := time.Now()
+	r.Projects[0].After()
+	if time.Since(start) > time.Second {
+		t.Error("Unexpected wait", time.Since(start))
+	}
+	shutdown := r.Projects[0].shutdown
+	if len(shutdown) != 2 || shutdown[0].Err != nil || shutdown[1].Err == nil {
+		t.Error("Unexpected results", shutdown)
+	}
+}
+
+func TestProject_FailFast(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("No true/false on Windows")
+	}
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{
+		parent: &r,
+		Path:   os.TempDir(),
+		Watcher: Watch{
+			FailFast: true,
+			Scripts: []Command{
+				{Type: "before", Cmd: "false"},
+				{Type: "before", Cmd: "true"},
+			},
+		},
+	})
+	p := &r.Projects[0]
+	results := p.cmd(context.Background(), "before", false)
+	if len(results) != 1 || results[0].Code != 1 {
+		t.Error("Unexpected results", results)
+	}
+	// the failed tasks are listed at the end of the run
+	p.recap()
+	if len(p.failures) != 1 || len(p.Buffer.StdErr) != 2 || p.Buffer.StdErr[1].Text != "Run failed, 1 task/s: false" {
+		t.Error("Unexpected recap", p.failures, p.Buffer.StdErr)
+	}
+	// a failed wait stops the next scripts even without fail fast
+	p.Watcher.FailFast = false
+	p.Watcher.Scripts = []Command{
+		{Type: "before", WaitFor: &WaitFor{File: "missing", Timeout: 50 * time.Millisecond}},
+		{Type: "before", Cmd: "true"},
+	}
+	if results := p.cmd(context.Background(), "before", false); len(results) != 1 || results[0].Err == nil {
+		t.Error("Unexpected results", results)
+	}
+}
+
+func TestProject_Once(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("No true/false on Windows")
+	}
+	var wg sync.WaitGroup
+	r := Realize{Once: true}
+	r.Projects = append(r.Projects, Project{
+		parent: &r,
+		Path:   os.TempDir(),
+		exit:   make(chan os.Signal, 1),
+		Watcher: Watch{
+			Scripts: []Command{
+				{Type: "before", Cmd: "false"},
+				{Type: "after", Cmd: "true", Global: true},
+			},
+		},
+	})
+	wg.Add(1)
+	r.Projects[0].Once(&wg)
+	wg.Wait()
+	if r.Projects[0].reason != "completed" {
+		t.Error("Unexpected reason", r.Projects[0].reason)
+	}
+	if len(r.Projects[0].shutdown) != 1 {
+		t.Error("Expected the after commands to run")
+	}
+	if r.ExitCode() != 1 {
+		t.Error("Expected exit code 1 instead", r.ExitCode())
+	}
+}
+
+func TestProject_Prefixed(t *testing.T) {
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{
+		Name:    "api",
+		Watcher: Watch{Scripts: []Command{{Name: "migrations", Cmd: "make migrate"}, {Cmd: "/usr/bin/echo test"}}},
+	}, Project{Name: "web"})
+	for k := range r.Projects {
+		r.Projects[k].parent = &r
+	}
+	r.labels = r.width()
+	if r.labels != len("api|migrations") {
+		t.Error("Unexpected width", r.labels)
+	}
+	if r.Projects[0].Watcher.Scripts[1].label() != "echo" {
+		t.Error("Unexpected label", r.Projects[0].Watcher.Scripts[1].label())
+	}
+	out := r.Projects[1].prefixed(TaskRun, "first\nsecond\n", false)
+	lines := strings.Split(out, "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], "WEB|run") || !strings.HasSuffix(lines[1], " second") {
+		t.Error("Unexpected output", out)
+	}
+	if len(lines[0]) != len(r.Projects[0].prefix("migrations")+" first") {
+		t.Error("Unexpected alignment", lines[0])
+	}
+	if r.Projects[1].prefixed(TaskRun, "\n", false) != "" {
+		t.Error("Expected an empty output")
+	}
+}
+
+func TestProject_Restart(t *testing.T) {
+	reloaded := make(chan Context, 1)
+	r := Realize{Reload: func(c Context) {
+		reloaded <- c
+	}}
+	r.Projects = append(r.Projects, Project{parent: &r})
+	p := &r.Projects[0]
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	previous := p.ctx
+	p.restart("main.go")
+	if previous.Err() == nil {
+		t.Error("Expected a canceled context")
+	}
+	select {
+	case c := <-reloaded:
+		if c.Path != "main.go" || c.Ctx != p.ctx || c.Ctx.Err() != nil {
+			t.Error("Unexpected context", c)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected a reload")
+	}
+	p.cancel()
+}
+
+func TestProject_RunCommand(t *testing.T) {
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{parent: &r, Path: os.TempDir()})
+	p := &r.Projects[0]
+	p.Tools.Run = Tool{Status: true, Command: "echo hello"}
+	stream := make(chan Response, 10)
+	if err := p.run(context.Background(), p.Path, stream); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	select {
+	case r := <-stream:
+		if r.Out != "hello" {
+			t.Error("Unexpected output", r.Out)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected the command output")
+	}
+}
+
+func TestProject_WatchPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "gen"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "gen", "gen.go"), []byte("package gen"), 0644)
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{parent: &r, Path: dir})
+	p := &r.Projects[0]
+	p.Watcher.Exts = []string{"go"}
+	if p.watcher, err = NewFileWatcher(Legacy{}); err != nil {
+		t.Fatal(err)
+	}
+	defer p.watcher.Close()
+	p.watchPath("gen")
+	if p.files != 1 || p.folders != 1 || !p.watched(filepath.Join(p.abs("gen"), "gen.go")) {
+		t.Error("Unexpected index", p.files, p.folders)
+	}
+	p.unwatchPath("gen")
+	if p.files != 0 || p.folders != 0 || len(p.Watcher.Paths) != 0 {
+		t.Error("Unexpected index", p.files, p.folders, p.Watcher.Paths)
+	}
+	// the files removed from the disk are uncounted too
+	p.watchPath("gen")
+	os.RemoveAll(filepath.Join(dir, "gen"))
+	p.unwatchPath("gen")
+	if p.files != 0 || p.folders != 0 {
+		t.Error("Unexpected index", p.files, p.folders)
+	}
+}
+
+func TestProject_Rearm(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dir, _ = filepath.EvalSymlinks(dir)
+	gen := filepath.Join(dir, "gen")
+	os.Mkdir(gen, 0755)
+	ioutil.WriteFile(filepath.Join(gen, "a.go"), []byte("package gen"), 0644)
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{parent: &r, Path: dir})
+	p := &r.Projects[0]
+	p.Watcher.Paths = []string{"/"}
+	p.Watcher.Exts = []string{"go"}
+	if p.watcher, err = NewFileWatcher(Legacy{}); err != nil {
+		t.Fatal(err)
+	}
+	defer p.watcher.Close()
+	filepath.Walk(dir, p.walk)
+	if !p.dirs[gen] {
+		t.Fatal("Expected a watched directory", p.dirs)
+	}
+	// renamed
+	os.Rename(gen, gen+"2")
+	if !p.rearm(fsnotify.Event{Name: gen, Op: fsnotify.Rename}) || p.dirs[gen] || !p.dirs[gen+"2"] {
+		t.Error("Unexpected directories", p.dirs)
+	}
+	// removed and recreated
+	os.RemoveAll(gen + "2")
+	p.rearm(fsnotify.Event{Name: gen + "2", Op: fsnotify.Remove})
+	os.Mkdir(gen, 0755)
+	if !p.rearm(fsnotify.Event{Name: gen, Op: fsnotify.Create}) || !p.dirs[gen] || p.dirs[gen+"2"] || p.folders != 2 {
+		t.Error("Unexpected directories", p.dirs, p.folders)
+	}
+	if p.rearm(fsnotify.Event{Name: filepath.Join(dir, "main.go"), Op: fsnotify.Create}) {
+		t.Error("Unexpected directory event")
+	}
+	ioutil.WriteFile(filepath.Join(gen, "b.go"), []byte("package gen"), 0644)
+	for {
+		select {
+		case e := <-p.watcher.Events():
+			if e.Name == filepath.Join(gen, "b.go") {
+				return
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Expected an event of the recreated directory")
+		}
+	}
+}
+
+func TestProject_MaxDepth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "a", "b", "c"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "a", "b", "b.go"), []byte("package b"), 0644)
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{parent: &r, Path: dir})
+	p := &r.Projects[0]
+	p.Watcher.Paths = []string{"/"}
+	p.Watcher.Exts = []string{"go"}
+	p.Watcher.MaxDepth = 2
+	p.Watcher.MaxDirs = 2
+	filepath.Walk(p.abs("/"), p.walk)
+	if p.folders != 3 || p.files != 1 || p.dirs[filepath.Join(dir, "a", "b", "c")] {
+		t.Error("Unexpected index", p.folders, p.files, p.dirs)
+	}
+	if p.depth(filepath.Join(dir, "a", "b")) != 2 || p.depth(os.TempDir()) != -1 {
+		t.Error("Unexpected depth", p.depth(filepath.Join(dir, "a", "b")))
+	}
+	if !p.crowded || len(p.Buffer.StdErr) != 1 {
+		t.Error("Expected a warning", p.Buffer.StdErr)
+	}
+}
+
+func TestProject_Handover(t *testing.T) {
+	p := &Project{}
+	stopped := make(chan bool, 16)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			done := make(chan bool)
+			close(done)
+			m := &managed{cancel: func() { stopped <- true }, done: done}
+			if prev := p.handover(m); prev != nil {
+				prev.stop()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			p.kill()
+		}()
+	}
+	wg.Wait()
+	p.kill()
+	if len(stopped) != 8 || p.serving() != nil {
+		t.Error("Unexpected managed binaries", len(stopped))
+	}
+}
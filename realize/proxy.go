@@ -0,0 +1,192 @@
+package realize
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default time a request is held waiting for the project
+const proxyTimeout = 30 * time.Second
+
+// Proxy forwards the requests to the project, they are held while it's rebuilding or restarting
+type Proxy struct {
+	Listen  string        `yaml:"listen" json:"listen"`
+	Target  string        `yaml:"target" json:"target"`
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	gate    *gate
+	server  *http.Server
+	mu      sync.Mutex
+	current string
+}
+
+// Gate closed during a build, requests wait until it's open
+type gate struct {
+	mu     sync.Mutex
+	open   chan bool
+	failed bool
+}
+
+// Hold the requests
+func (g *gate) hold() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	select {
+	case <-g.open:
+		g.open = make(chan bool)
+	default:
+	}
+}
+
+// Release the requests, a failed build doesn't wait for the project
+func (g *gate) release(failed bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.failed = failed
+	select {
+	case <-g.open:
+	default:
+		close(g.open)
+	}
+}
+
+// Wait the gate, false if the context expires first
+func (g *gate) wait(ctx context.Context) bool {
+	g.mu.Lock()
+	open := g.open
+	g.mu.Unlock()
+	select {
+	case <-open:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Failing if the last build failed
+func (g *gate) failing() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.failed
+}
+
+// Address with a host, :8080 is localhost:8080
+func (p *Proxy) address(value string) string {
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "http://"), "https://")
+	if strings.HasPrefix(value, ":") {
+		return "localhost" + value
+	}
+	return value
+}
+
+// Timeout of the held requests
+func (p *Proxy) timeout() time.Duration {
+	if p.Timeout > 0 {
+		return p.Timeout
+	}
+	return proxyTimeout
+}
+
+// Retarget the proxy, the next requests go to the address
+func (p *Proxy) retarget(addr string) {
+	p.mu.Lock()
+	p.current = p.address(addr)
+	p.mu.Unlock()
+}
+
+// Upstream of the requests, the target until retargeted
+func (p *Proxy) upstream() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.current != "" {
+		return p.current
+	}
+	return p.address(p.Target)
+}
+
+// Hold the requests until the project restarts
+func (p *Proxy) hold() {
+	if p.gate != nil {
+		p.gate.hold()
+	}
+}
+
+// Release the held requests
+func (p *Proxy) release(failed bool) {
+	if p.gate != nil {
+		p.gate.release(failed)
+	}
+}
+
+// Handler of the proxy, the dial is retried until the project is listening
+func (p *Proxy) handler() http.Handler {
+	if p.gate == nil {
+		p.gate = &gate{open: make(chan bool)}
+		close(p.gate.open)
+	}
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: "http", Host: p.address(p.Target)})
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+		r.URL.Host = p.upstream()
+	}
+	dialer := &net.Dialer{Timeout: time.Second}
+	proxy.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			deadline := time.Now().Add(p.timeout())
+			for {
+				conn, err := dialer.DialContext(ctx, network, addr)
+				// a failed build isn't waited, the previous process may be still listening
+				if err == nil || ctx.Err() != nil || time.Now().After(deadline) || p.gate.failing() {
+					return conn, err
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+		},
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), p.timeout())
+		open := p.gate.wait(ctx)
+		cancel()
+		if !open {
+			http.Error(w, "Timeout waiting for the project", http.StatusGatewayTimeout)
+			return
+		}
+		proxy.ServeHTTP(w, r)
+	})
+}
+
+// Start the proxy server of a project
+func (p *Project) proxy() {
+	px := p.Proxy
+	// a blue-green run is on its first port
+	if px.Target == "" && p.Tools.Run.blueGreen() && len(p.Tools.Run.Ports) > 0 {
+		px.Target = ":" + strconv.Itoa(p.Tools.Run.Ports[0])
+	}
+	px.server = &http.Server{Addr: px.Listen, Handler: px.handler()}
+	msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Blue.Bold("Proxy"), Magenta.Bold(px.Listen), "to", Magenta.Bold(px.address(px.Target)))
+	out := BufferOut{Time: time.Now(), Text: "Proxy " + px.Listen + " to " + px.address(px.Target)}
+	p.stamp("log", out, msg, "")
+	go func() {
+		if err := px.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			p.Err(err)
+		}
+	}()
+}
+
+// Stop the proxy server
+func (p *Proxy) stop() {
+	if p.server != nil {
+		p.server.Close()
+	}
+}
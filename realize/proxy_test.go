@@ -0,0 +1,72 @@
+package realize
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProxy_Hold(t *testing.T) {
+	// free port of the project
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := l.Addr().String()
+	l.Close()
+	p := Proxy{Target: target, Timeout: 5 * time.Second}
+	ts := httptest.NewServer(p.handler())
+	defer ts.Close()
+	p.hold()
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		p.release(false)
+		// the project listens a bit after the build
+		time.Sleep(200 * time.Millisecond)
+		l, err := net.Listen("tcp", target)
+		if err != nil {
+			return
+		}
+		http.Serve(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}))
+	}()
+	start := time.Now()
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if string(body) != "ok" || time.Since(start) < 400*time.Millisecond {
+		t.Error("Unexpected response", string(body), time.Since(start))
+	}
+}
+
+func TestProxy_Failed(t *testing.T) {
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := l.Addr().String()
+	l.Close()
+	p := Proxy{Target: target, Timeout: 5 * time.Second}
+	ts := httptest.NewServer(p.handler())
+	defer ts.Close()
+	p.hold()
+	p.release(true)
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Error("Expected bad gateway instead", resp.StatusCode)
+	}
+	if p.address(":8080") != "localhost:8080" || p.address("http://host:80") != "host:80" {
+		t.Error("Unexpected address")
+	}
+}
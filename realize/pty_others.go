@@ -0,0 +1,22 @@
+// +build !linux,!darwin
+
+package realize
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// openPty isn't supported on this platform
+func openPty() (*os.File, *os.File, error) {
+	return nil, nil, errors.New("pty isn't supported on " + runtime.GOOS)
+}
+
+// attachPty connects a command to the slave side of a pseudo-terminal
+func attachPty(cmd *exec.Cmd, slave *os.File) {
+	cmd.Stdin = slave
+	cmd.Stdout = slave
+	cmd.Stderr = slave
+}
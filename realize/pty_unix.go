@@ -0,0 +1,21 @@
+// +build linux darwin
+
+package realize
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// attachPty connects a command to the slave side of a pseudo-terminal
+func attachPty(cmd *exec.Cmd, slave *os.File) {
+	cmd.Stdin = slave
+	cmd.Stdout = slave
+	cmd.Stderr = slave
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+}
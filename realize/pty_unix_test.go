@@ -0,0 +1,29 @@
+// +build linux darwin
+
+package realize
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestOpenPty(t *testing.T) {
+	master, slave, err := openPty()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer master.Close()
+	cmd := exec.Command("sh", "-c", "test -t 1 && echo tty")
+	attachPty(cmd, slave)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	slave.Close()
+	line, _ := bufio.NewReader(master).ReadString('\n')
+	if strings.TrimSpace(line) != "tty" {
+		t.Error("Unexpected output", line)
+	}
+	cmd.Wait()
+}
@@ -0,0 +1,417 @@
+// +build windows
+
+package realize
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const rdcBuffer = 64 * 1024
+
+const rdcMask = syscall.FILE_NOTIFY_CHANGE_FILE_NAME | syscall.FILE_NOTIFY_CHANGE_DIR_NAME |
+	syscall.FILE_NOTIFY_CHANGE_ATTRIBUTES | syscall.FILE_NOTIFY_CHANGE_SIZE |
+	syscall.FILE_NOTIFY_CHANGE_LAST_WRITE | syscall.FILE_NOTIFY_CHANGE_CREATION
+
+type (
+	// rdcWatcher satisfies the FileWatcher interface with a recursive ReadDirectoryChangesW
+	// handle for each watched root, fsnotify opens a handle for each dir instead
+	rdcWatcher struct {
+		mu     sync.Mutex
+		paths  map[string]bool
+		roots  map[string]*rdcRoot
+		closed bool
+		port   syscall.Handle
+		next   uint32
+		// the roots are updated one at a time
+		umu sync.Mutex
+		// the requests are run by the loop, the io is cancelled by the thread that started it
+		input  chan rdcRequest
+		queue  []fsnotify.Event
+		wake   chan struct{}
+		events chan fsnotify.Event
+		errors chan error
+		done   chan struct{}
+	}
+
+	// rdcRoot is a watched tree, kept until the completion of its cancelled read
+	rdcRoot struct {
+		ov     syscall.Overlapped
+		key    uint32
+		path   string
+		handle syscall.Handle
+		closed bool
+		buf    [rdcBuffer]byte
+	}
+
+	// rdcRequest to the loop, add and remove the roots or close
+	rdcRequest struct {
+		add    []string
+		remove []*rdcRoot
+		close  bool
+		reply  chan error
+	}
+)
+
+// Native watcher of windows
+func nativeWatcher() (FileWatcher, error) {
+	port, err := syscall.CreateIoCompletionPort(syscall.InvalidHandle, 0, 0, 0)
+	if err != nil {
+		return nil, os.NewSyscallError("CreateIoCompletionPort", err)
+	}
+	w := &rdcWatcher{
+		paths:  make(map[string]bool),
+		roots:  make(map[string]*rdcRoot),
+		port:   port,
+		input:  make(chan rdcRequest, 1),
+		wake:   make(chan struct{}, 1),
+		events: make(chan fsnotify.Event, 64),
+		errors: make(chan error, 1),
+		done:   make(chan struct{}),
+	}
+	go w.loop()
+	go w.forward()
+	return w, nil
+}
+
+// Add a path, a handle is opened only if it isn't inside a watched root
+func (w *rdcWatcher) Add(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return errors.New("watcher is closed")
+	}
+	w.paths[path] = true
+	for root := range w.roots {
+		if within(path, root) {
+			w.mu.Unlock()
+			return nil
+		}
+	}
+	w.mu.Unlock()
+	return w.update()
+}
+
+// Remove a path, the roots are updated if it was one
+func (w *rdcWatcher) Remove(path string) error {
+	w.mu.Lock()
+	if !w.paths[path] {
+		w.mu.Unlock()
+		return errNoSuchWatch
+	}
+	delete(w.paths, path)
+	_, root := w.roots[path]
+	w.mu.Unlock()
+	if !root {
+		return nil
+	}
+	return w.update()
+}
+
+// Walk ReadDirectoryChangesW
+func (w *rdcWatcher) Walk(path string, init bool) string {
+	if err := w.Add(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// Close the handles and the completion port
+func (w *rdcWatcher) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = true
+	w.mu.Unlock()
+	err := w.request(rdcRequest{close: true})
+	close(w.done)
+	return err
+}
+
+// Events returns the events of the watched paths
+func (w *rdcWatcher) Events() <-chan fsnotify.Event {
+	return w.events
+}
+
+// Errors returns the errors of the handles
+func (w *rdcWatcher) Errors() <-chan error {
+	return w.errors
+}
+
+// Update the roots to the watched paths not inside another one, a dir for a file
+func (w *rdcWatcher) update() error {
+	w.umu.Lock()
+	defer w.umu.Unlock()
+	w.mu.Lock()
+	// sorted, a dir comes before the paths inside it
+	paths := make([]string, 0, len(w.paths))
+	for path := range w.paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	roots := make(map[string]bool)
+	for _, path := range paths {
+		if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+			path = filepath.Dir(path)
+		}
+		covered := false
+		for root := range roots {
+			covered = covered || within(path, root)
+		}
+		if !covered {
+			roots[path] = true
+		}
+	}
+	var req rdcRequest
+	for path, root := range w.roots {
+		if !roots[path] {
+			req.remove = append(req.remove, root)
+			delete(w.roots, path)
+		}
+	}
+	for path := range roots {
+		if _, ok := w.roots[path]; !ok {
+			req.add = append(req.add, path)
+		}
+	}
+	w.mu.Unlock()
+	if len(req.add) == 0 && len(req.remove) == 0 {
+		return nil
+	}
+	return w.request(req)
+}
+
+// Request to the loop, woken by an empty completion
+func (w *rdcWatcher) request(req rdcRequest) error {
+	req.reply = make(chan error)
+	w.input <- req
+	if err := syscall.PostQueuedCompletionStatus(w.port, 0, 0, nil); err != nil {
+		<-w.input
+		return os.NewSyscallError("PostQueuedCompletionStatus", err)
+	}
+	return <-req.reply
+}
+
+// Loop on the completion port, a single locked thread starts and cancels the io of the handles
+func (w *rdcWatcher) loop() {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	keys := make(map[uint32]*rdcRoot)
+	var closing *rdcRequest
+	for {
+		if closing != nil && len(keys) == 0 {
+			closing.reply <- syscall.CloseHandle(w.port)
+			return
+		}
+		var n, key uint32
+		var ov *syscall.Overlapped
+		err := syscall.GetQueuedCompletionStatus(w.port, &n, &key, &ov, syscall.INFINITE)
+		if ov == nil {
+			if err != nil {
+				w.error(os.NewSyscallError("GetQueuedCompletionStatus", err))
+				continue
+			}
+			req := <-w.input
+			if req.close {
+				for _, root := range keys {
+					root.close()
+				}
+				closing = &req
+				continue
+			}
+			req.reply <- w.apply(req, keys)
+			continue
+		}
+		root := keys[key]
+		switch {
+		case root == nil:
+			continue
+		case root.closed:
+			// the buffer isn't written anymore
+			delete(keys, key)
+			continue
+		case err == syscall.ERROR_ACCESS_DENIED:
+			// the root was removed
+			w.send(fsnotify.Event{Name: root.path, Op: fsnotify.Remove})
+			w.drop(root, keys)
+			continue
+		case err != nil:
+			w.error(os.NewSyscallError("GetQueuedCompletionStatus", err))
+		case n == 0:
+			w.error(errors.New(root.path + ": too many changes, events lost"))
+		default:
+			w.changes(root, n)
+		}
+		if err := root.read(); err != nil {
+			w.error(err)
+			w.drop(root, keys)
+		}
+	}
+}
+
+// Drop a root without a pending read
+func (w *rdcWatcher) drop(root *rdcRoot, keys map[uint32]*rdcRoot) {
+	syscall.CloseHandle(root.handle)
+	delete(keys, root.key)
+	w.mu.Lock()
+	if w.roots[root.path] == root {
+		delete(w.roots, root.path)
+	}
+	w.mu.Unlock()
+}
+
+// Apply a request on the loop thread
+func (w *rdcWatcher) apply(req rdcRequest, keys map[uint32]*rdcRoot) error {
+	for _, root := range req.remove {
+		root.close()
+	}
+	var errs error
+	for _, path := range req.add {
+		w.next++
+		root, err := rdcOpen(path, w.next, w.port)
+		if err == nil {
+			err = root.read()
+			if err != nil {
+				syscall.CloseHandle(root.handle)
+			}
+		}
+		if err != nil {
+			errs = err
+			continue
+		}
+		keys[root.key] = root
+		w.mu.Lock()
+		w.roots[path] = root
+		w.mu.Unlock()
+	}
+	return errs
+}
+
+// Open a root dir on the completion port
+func rdcOpen(path string, key uint32, port syscall.Handle) (*rdcRoot, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := syscall.CreateFile(name, syscall.FILE_LIST_DIRECTORY,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE, nil,
+		syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS|syscall.FILE_FLAG_OVERLAPPED, 0)
+	if err != nil {
+		return nil, os.NewSyscallError("CreateFile", err)
+	}
+	if _, err := syscall.CreateIoCompletionPort(handle, port, key, 0); err != nil {
+		syscall.CloseHandle(handle)
+		return nil, os.NewSyscallError("CreateIoCompletionPort", err)
+	}
+	return &rdcRoot{key: key, path: path, handle: handle}, nil
+}
+
+// Close cancels the pending read, on the thread that started it
+func (r *rdcRoot) close() {
+	r.closed = true
+	syscall.CancelIo(r.handle)
+	syscall.CloseHandle(r.handle)
+}
+
+// Read the next changes of the tree
+func (r *rdcRoot) read() error {
+	r.ov = syscall.Overlapped{}
+	err := syscall.ReadDirectoryChanges(r.handle, &r.buf[0], uint32(len(r.buf)), true, rdcMask, nil, &r.ov, 0)
+	if err != nil {
+		return os.NewSyscallError("ReadDirectoryChanges", err)
+	}
+	return nil
+}
+
+// Changes of a root, the events of the watched paths and of the files in the watched dirs are sent as fsnotify
+func (w *rdcWatcher) changes(root *rdcRoot, n uint32) {
+	var offset uint32
+	for offset < n {
+		raw := (*syscall.FileNotifyInformation)(unsafe.Pointer(&root.buf[offset]))
+		chars := (*[rdcBuffer / 2]uint16)(unsafe.Pointer(&raw.FileName))[:raw.FileNameLength/2]
+		name := filepath.Join(root.path, syscall.UTF16ToString(chars))
+		w.mu.Lock()
+		watched := w.paths[name] || w.paths[filepath.Dir(name)]
+		w.mu.Unlock()
+		if op := action(raw.Action); watched && op != 0 {
+			w.send(fsnotify.Event{Name: name, Op: op})
+		}
+		if raw.NextEntryOffset == 0 {
+			break
+		}
+		offset += raw.NextEntryOffset
+	}
+}
+
+// Action of a change as fsnotify, the new name of a rename is a create
+func action(a uint32) fsnotify.Op {
+	switch a {
+	case syscall.FILE_ACTION_ADDED, syscall.FILE_ACTION_RENAMED_NEW_NAME:
+		return fsnotify.Create
+	case syscall.FILE_ACTION_REMOVED:
+		return fsnotify.Remove
+	case syscall.FILE_ACTION_MODIFIED:
+		return fsnotify.Write
+	case syscall.FILE_ACTION_RENAMED_OLD_NAME:
+		return fsnotify.Rename
+	}
+	return 0
+}
+
+// Send queues an event, the loop never waits for the receiver
+func (w *rdcWatcher) send(e fsnotify.Event) {
+	w.mu.Lock()
+	w.queue = append(w.queue, e)
+	w.mu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Error without waiting for the receiver, the pending one is kept
+func (w *rdcWatcher) error(err error) {
+	select {
+	case w.errors <- err:
+	default:
+	}
+}
+
+// Forward the queued events to the receiver
+func (w *rdcWatcher) forward() {
+	for {
+		select {
+		case <-w.wake:
+		case <-w.done:
+			return
+		}
+		for {
+			w.mu.Lock()
+			if len(w.queue) == 0 {
+				w.mu.Unlock()
+				break
+			}
+			e := w.queue[0]
+			w.queue = w.queue[1:]
+			w.mu.Unlock()
+			select {
+			case w.events <- e:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
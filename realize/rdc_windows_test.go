@@ -0,0 +1,52 @@
+// +build windows
+
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRdcWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sub := filepath.Join(dir, "sub")
+	os.Mkdir(sub, 0755)
+	os.Mkdir(filepath.Join(dir, "ignored"), 0755)
+	w, err := EventWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, ok := w.(*rdcWatcher); !ok {
+		t.Fatal("Expected the ReadDirectoryChangesW watcher")
+	}
+	// a single handle, the events are filtered by the watched paths
+	for _, path := range []string{dir, sub} {
+		if w.Walk(path, false) == "" {
+			t.Fatal("Unexpected walk error", path)
+		}
+	}
+	if roots := w.(*rdcWatcher).roots; len(roots) != 1 {
+		t.Error("Unexpected roots", roots)
+	}
+	ioutil.WriteFile(filepath.Join(sub, "a.go"), []byte("package a"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "ignored", "b.go"), []byte("package b"), 0644)
+	names := received(w)
+	if !names[filepath.Join(sub, "a.go")] || names[filepath.Join(dir, "ignored", "b.go")] {
+		t.Error("Unexpected events", names)
+	}
+	if err := w.Remove(dir); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	// the dir inside the removed root is the new one
+	roots := w.(*rdcWatcher).roots
+	if _, ok := roots[sub]; len(roots) != 1 || !ok {
+		t.Error("Unexpected roots", roots)
+	}
+}
@@ -0,0 +1,301 @@
+package realize
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Recorded file event of a project, the path is relative to it to replay the events on another checkout.
+// The state of the file is taken when the event is received, the replay reads no file.
+type Recorded struct {
+	Time    time.Time `json:"time"`
+	Project string    `json:"project"`
+	Path    string    `json:"path"`
+	Op      string    `json:"op"`
+	Missing bool      `json:"missing,omitempty"`
+	Dir     bool      `json:"dir,omitempty"`
+	Size    int64     `json:"size,omitempty"`
+}
+
+// Tape of the events of a project, every folder of its paths is watched but .git
+type tape struct {
+	project *Project
+	watcher FileWatcher
+	poller  bool
+	dirs    map[string]bool
+}
+
+// Record writes the file events of the projects to a file as json lines until stop is closed, nothing is run.
+// The ignored paths are recorded too, the replay tells why their events don't trigger.
+func (r *Realize) Record(file string, stop <-chan struct{}) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	file, _ = filepath.Abs(file)
+	events := make(chan Recorded)
+	projects := make(map[string]*Project)
+	for k := range r.Schema.Projects {
+		p := &r.Schema.Projects[k]
+		p.parent = r
+		projects[p.Name] = p
+		w, err := NewFileWatcher(r.Settings.Legacy)
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+		_, poller := w.(*filePoller)
+		t := &tape{project: p, watcher: w, poller: poller, dirs: make(map[string]bool)}
+		for _, path := range p.Watcher.Paths {
+			t.add(p.abs(path), false)
+		}
+		for _, name := range append(append([]string{}, p.Watcher.Files...), p.EnvFiles...) {
+			t.file(p.filePath(name))
+		}
+		go t.forward(file, events, stop)
+	}
+	encoder := json.NewEncoder(f)
+	for {
+		select {
+		case <-stop:
+			return nil
+		case e := <-events:
+			if err := encoder.Encode(e); err != nil {
+				return err
+			}
+			fmt.Fprintln(Output, projects[e.Project].pname(e.Project, 1), ":", Magenta.Bold(strings.ToUpper(e.Op)), e.Path)
+		}
+	}
+}
+
+// Add the folders of a path to the watcher, the files too by the poller, the new ones are created events once started
+func (t *tape) add(root string, started bool) {
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			t.dirs[path] = true
+		} else if !t.poller {
+			return nil
+		}
+		t.watcher.Walk(path, started)
+		return nil
+	})
+}
+
+// File watched by itself, its folder with fsnotify
+func (t *tape) file(path string) {
+	if !t.poller {
+		path = filepath.Dir(path)
+	}
+	t.watcher.Add(path)
+}
+
+// Forward the events of the watcher but the ones of the record file, the folders changed are walked again
+func (t *tape) forward(file string, events chan<- Recorded, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case _, ok := <-t.watcher.Errors():
+			if !ok {
+				return
+			}
+		case event, ok := <-t.watcher.Events():
+			if !ok {
+				return
+			}
+			if event.Name == file {
+				continue
+			}
+			e := t.recorded(event)
+			if e.Dir && !e.Missing {
+				t.add(event.Name, true)
+			}
+			select {
+			case events <- e:
+			case <-stop:
+				return
+			}
+		}
+	}
+}
+
+// Recorded event with the state of its file, a removed folder is still one
+func (t *tape) recorded(event fsnotify.Event) Recorded {
+	p := t.project
+	e := Recorded{Time: time.Now(), Project: p.Name, Path: event.Name, Op: opNames(event.Op)}
+	if base := p.abs(""); within(event.Name, base) {
+		rel, _ := filepath.Rel(base, event.Name)
+		e.Path = filepath.ToSlash(rel)
+	}
+	fi, err := os.Stat(event.Name)
+	if err != nil {
+		e.Missing, e.Dir = true, t.dirs[event.Name]
+		delete(t.dirs, event.Name)
+		return e
+	}
+	e.Dir, e.Size = fi.IsDir(), fi.Size()
+	if e.Dir {
+		t.dirs[event.Name] = true
+	}
+	return e
+}
+
+// Names of the ops of an event as the watch ops, separated by |
+func opNames(op fsnotify.Op) string {
+	var names []string
+	for _, name := range []string{"create", "write", "remove", "rename", "chmod"} {
+		if op&ops[name] != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// ReadRecorded reads the events of a record file
+func ReadRecorded(file string) ([]Recorded, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var events []Recorded
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Recorded
+		if json.Unmarshal(scanner.Bytes(), &e) != nil {
+			continue
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
+
+// Replay prints what the watcher does with each recorded event for the projects of the config: a reload or why it's skipped.
+// Nothing is run and no file is read, the state of the files is the recorded one.
+func (r *Realize) Replay(w io.Writer, events []Recorded) {
+	if err := r.resolve(); err != nil {
+		fmt.Fprintln(w, r.Prefix(Red.Regular(err.Error())))
+		return
+	}
+	type replay struct {
+		project *Project
+		last    time.Time
+		events  int
+		reloads int
+	}
+	replays := make(map[string]*replay)
+	var names []string
+	for k := range r.Schema.Projects {
+		p := &r.Schema.Projects[k]
+		p.parent = r
+		p.Tools.Setup()
+		if r.Settings.Index {
+			p.index = &index{}
+		}
+		replays[p.Name] = &replay{project: p}
+		names = append(names, p.Name)
+	}
+	unknown := make(map[string]bool)
+	for _, e := range events {
+		rp, ok := replays[e.Project]
+		if !ok {
+			if !unknown[e.Project] {
+				unknown[e.Project] = true
+				fmt.Fprintln(w, r.Prefix(Red.Regular("no project "+e.Project+" in the config, its events are skipped")))
+			}
+			continue
+		}
+		p := rp.project
+		rp.events++
+		offset := "+" + e.Time.Sub(events[0].Time).Truncate(time.Millisecond).String()
+		result := Green.Bold("reload")
+		if reason := p.replayed(e, &rp.last); reason != "" {
+			result = Yellow.Regular("skipped, " + reason)
+		} else {
+			rp.reloads++
+		}
+		fmt.Fprintln(w, p.pname(p.Name, 1), ":", offset, Magenta.Bold(strings.ToUpper(e.Op)), e.Path, result)
+	}
+	for _, name := range names {
+		if rp := replays[name]; rp.events > 0 {
+			fmt.Fprintln(w, rp.project.pname(name, 1), ":", Blue.Bold(rp.events), "events", Blue.Bold(rp.reloads), "reloads")
+		}
+	}
+}
+
+// Replayed decision of the watcher on a recorded event, empty for a reload.
+// Last is the second of the last reload, the leading debounce skips the events in the same one.
+func (p *Project) replayed(e Recorded, last *time.Time) string {
+	path := filepath.FromSlash(e.Path)
+	if !filepath.IsAbs(path) {
+		path = p.abs(path)
+	}
+	op, err := mask(strings.Split(e.Op, "|"))
+	if err != nil {
+		return err.Error()
+	}
+	switch {
+	case e.Dir:
+		return "a folder, watched again with its files"
+	case p.generated(path):
+		return "an output of a generate task"
+	}
+	trailing := p.Watcher.trailing()
+	if !trailing && !e.Time.Truncate(time.Second).After(*last) {
+		return "in the second of the last reload, leading debounce"
+	}
+	m, _ := mask(p.Watcher.Ops)
+	switch op & m {
+	case 0:
+		return "op not watched"
+	case fsnotify.Remove:
+		if p.single(path) {
+			return "a single file removed, its create follows"
+		}
+		if p.input(path) {
+			return ""
+		}
+		if reason := p.rejected(path, p.ignoring()); reason != "" {
+			return reason
+		}
+		if ext(path) == "" {
+			return "no extension"
+		}
+		return ""
+	}
+	switch {
+	case p.single(path) || p.input(path):
+		if e.Missing {
+			return "removed when handled, an atomic save"
+		}
+	default:
+		if reason := p.rejected(path, p.ignoring()); reason != "" {
+			return reason
+		}
+		switch {
+		case e.Missing:
+			return "removed when handled"
+		case ext(path) == "":
+			return "no extension"
+		case e.Size <= 0:
+			return "empty file"
+		}
+	}
+	*last = e.Time.Truncate(time.Second)
+	return ""
+}
@@ -0,0 +1,104 @@
+package realize
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestOpNames(t *testing.T) {
+	op := fsnotify.Create | fsnotify.Write
+	if names := opNames(op); names != "create|write" {
+		t.Error("Unexpected names", names)
+	}
+	if m, err := mask(strings.Split(opNames(op), "|")); err != nil || m != op {
+		t.Error("Unexpected op", m, err)
+	}
+}
+
+func TestProject_Replayed(t *testing.T) {
+	p := Project{parent: &Realize{}, Path: "/tmp/app", Watcher: Watch{Exts: []string{"go"}, Ignore: []string{"build"}}}
+	now := time.Now().Truncate(time.Second)
+	cases := []struct {
+		event  Recorded
+		reason string
+	}{
+		{Recorded{Time: now, Path: "main.go", Op: "write", Size: 10}, ""},
+		{Recorded{Time: now.Add(100 * time.Millisecond), Path: "util.go", Op: "write", Size: 10}, "in the second of the last reload, leading debounce"},
+		{Recorded{Time: now.Add(time.Second), Path: "notes.txt", Op: "write", Size: 10}, "extension txt not watched"},
+		{Recorded{Time: now.Add(time.Second), Path: "build/gen.go", Op: "write", Size: 10}, "ignored by build"},
+		{Recorded{Time: now.Add(time.Second), Path: "vendor/lib/lib.go", Op: "create", Size: 10}, "ignored by vendor"},
+		{Recorded{Time: now.Add(time.Second), Path: "main.go", Op: "chmod", Size: 10}, "op not watched"},
+		{Recorded{Time: now.Add(time.Second), Path: "pkg", Op: "create", Dir: true}, "a folder, watched again with its files"},
+		{Recorded{Time: now.Add(time.Second), Path: "empty.go", Op: "create"}, "empty file"},
+		{Recorded{Time: now.Add(time.Second), Path: "main.go~", Op: "rename", Missing: true}, "extension go~ not watched"},
+		{Recorded{Time: now.Add(time.Second), Path: "gone.go", Op: "write", Missing: true}, "removed when handled"},
+		{Recorded{Time: now.Add(time.Second), Path: "old.go", Op: "remove", Missing: true}, ""},
+		{Recorded{Time: now.Add(time.Second), Path: "main.go", Op: "write", Size: 10}, ""},
+	}
+	var last time.Time
+	for _, c := range cases {
+		if reason := p.replayed(c.event, &last); reason != c.reason {
+			t.Error("Unexpected reason", c.event.Path, c.event.Op, reason)
+		}
+	}
+	// the trailing debounce batches the events of the same second
+	p.Watcher.DebounceMode = DebounceTrailing
+	if reason := p.replayed(Recorded{Time: now.Add(time.Second), Path: "util.go", Op: "write", Size: 10}, &last); reason != "" {
+		t.Error("Unexpected reason", reason)
+	}
+}
+
+func TestRealize_Record(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "pkg"), 0755)
+	r := Realize{}
+	r.Settings.Legacy = Legacy{Force: true, Interval: 50 * time.Millisecond}
+	r.Projects = append(r.Projects, Project{Name: "app", Path: dir, Watcher: Watch{Paths: []string{"/"}, Exts: []string{"go"}}})
+	file := filepath.Join(dir, FileEvents)
+	stop := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- r.Record(file, stop)
+	}()
+	time.Sleep(200 * time.Millisecond)
+	ioutil.WriteFile(filepath.Join(dir, "pkg", "main.go"), []byte("package pkg\n"), 0644)
+	var events []Recorded
+	recorded := func() *Recorded {
+		for k, e := range events {
+			if e.Path == "pkg/main.go" {
+				return &events[k]
+			}
+		}
+		return nil
+	}
+	for i := 0; i < 50 && recorded() == nil; i++ {
+		time.Sleep(100 * time.Millisecond)
+		events, _ = ReadRecorded(file)
+	}
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if e := recorded(); e == nil || e.Project != "app" || e.Size == 0 {
+		t.Fatal("Unexpected events", events)
+	}
+	var buf bytes.Buffer
+	r.Replay(&buf, append(events, Recorded{Time: events[0].Time, Project: "other", Path: "main.go", Op: "write"}))
+	out := buf.String()
+	for _, s := range []string{"pkg/main.go", "reload", "no project other", "reloads"} {
+		if !strings.Contains(out, s) {
+			t.Error("Unexpected replay", s, out)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package realize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TaskSync is the label of the remote sync output
+const TaskSync = "sync"
+
+// Remote host where the changed files are copied and the remote commands run
+type Remote struct {
+	Host     string   `yaml:"host" json:"host"`
+	User     string   `yaml:"user,omitempty" json:"user,omitempty"`
+	Port     int      `yaml:"port,omitempty" json:"port,omitempty"`
+	Key      string   `yaml:"key,omitempty" json:"key,omitempty"`
+	Path     string   `yaml:"path" json:"path"`
+	Tool     string   `yaml:"tool,omitempty" json:"tool,omitempty"`
+	Delete   bool     `yaml:"delete,omitempty" json:"delete,omitempty"`
+	Commands []string `yaml:"commands,omitempty" json:"commands,omitempty"`
+}
+
+// Validate the remote, host and path are required
+func (r *Remote) validate() error {
+	if r.Host == "" || r.Path == "" {
+		return errors.New("Sync requires a host and a path")
+	}
+	if r.Tool != "" && r.Tool != "rsync" && r.Tool != "scp" {
+		return errors.New("Sync tool '" + r.Tool + "' not supported, use rsync or scp")
+	}
+	return nil
+}
+
+// Target of ssh, user@host
+func (r *Remote) target() string {
+	if r.User != "" {
+		return r.User + "@" + r.Host
+	}
+	return r.Host
+}
+
+// Options of ssh, port and identity
+func (r *Remote) options(port string) (opts []string) {
+	if r.Port > 0 {
+		opts = append(opts, port, strconv.Itoa(r.Port))
+	}
+	if r.Key != "" {
+		opts = append(opts, "-i", r.Key)
+	}
+	return
+}
+
+// Exec a command in the remote path
+func (r *Remote) exec(cmd string) []string {
+	return r.shell("cd " + quote(r.Path) + " && " + cmd)
+}
+
+// Shell runs a command from the remote home, the remote path may not exist yet
+func (r *Remote) shell(cmd string) []string {
+	args := append([]string{"ssh"}, r.options("-p")...)
+	return append(args, r.target(), cmd)
+}
+
+// Copy a path relative to the project, the whole project but the ignored paths if empty
+func (r *Remote) copy(rel string, p *Project) [][]string {
+	rel = filepath.ToSlash(rel)
+	// the local sources start with ./, a colon isn't read as a host or a dash as an option
+	if r.Tool == "scp" {
+		args := append([]string{"scp", "-r"}, r.options("-P")...)
+		if rel != "" {
+			return [][]string{
+				r.shell("mkdir -p " + quote(path.Join(r.Path, path.Dir(rel)))),
+				append(args, "./"+rel, r.target()+":"+path.Join(r.Path, rel)),
+			}
+		}
+		base, _ := filepath.Abs(p.Path)
+		list, whole := kept(base, p.ignoring())
+		if whole {
+			return [][]string{r.shell("mkdir -p " + quote(r.Path)), append(args, ".", r.target()+":"+r.Path)}
+		}
+		// scp flattens its sources, they're copied by remote dir
+		var dirs []string
+		groups := make(map[string][]string)
+		for _, v := range list {
+			src, _ := filepath.Rel(base, v)
+			src = filepath.ToSlash(src)
+			dir := path.Dir(src)
+			if _, ok := groups[dir]; !ok {
+				dirs = append(dirs, dir)
+			}
+			groups[dir] = append(groups[dir], "./"+src)
+		}
+		mkdir := "mkdir -p " + quote(r.Path)
+		for _, dir := range dirs {
+			if dir != "." {
+				mkdir += " " + quote(path.Join(r.Path, dir))
+			}
+		}
+		cmds := [][]string{r.shell(mkdir)}
+		for _, dir := range dirs {
+			cmds = append(cmds, append(append(append([]string{}, args...), groups[dir]...), r.target()+":"+path.Join(r.Path, dir)))
+		}
+		return cmds
+	}
+	args := []string{"rsync", "-az"}
+	if opts := r.options("-p"); len(opts) > 0 {
+		args = append(args, "-e", "ssh "+strings.Join(opts, " "))
+	}
+	if rel == "" {
+		if r.Delete {
+			args = append(args, "--delete")
+		}
+		for _, v := range p.Watcher.Ignore {
+			args = append(args, "--exclude", v)
+		}
+		return [][]string{append(args, "./", r.target()+":"+strings.TrimSuffix(r.Path, "/")+"/")}
+	}
+	return [][]string{append(args, "--relative", "./"+rel, r.target()+":"+strings.TrimSuffix(r.Path, "/")+"/")}
+}
+
+// Kept paths of a dir, the ignored ones are skipped and the dirs without ignored paths are listed whole
+func kept(dir string, i ignoring) (list []string, whole bool) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, false
+	}
+	whole = true
+	for _, e := range entries {
+		name := filepath.Join(dir, e.Name())
+		if i.by(name) != "" {
+			whole = false
+			continue
+		}
+		if !e.IsDir() {
+			list = append(list, name)
+			continue
+		}
+		if sub, ok := kept(name, i); ok {
+			list = append(list, name)
+		} else {
+			whole = false
+			list = append(list, sub...)
+		}
+	}
+	return list, whole
+}
+
+// Remove a path relative to the project
+func (r *Remote) remove(rel string) []string {
+	return r.exec("rm -rf " + quote(filepath.ToSlash(rel)))
+}
+
+// Quote a value for the remote shell
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// Remote copies the changed path, the whole project at start, then runs the remote commands
+func (p *Project) remote(ctx context.Context, changed string) (results []Response) {
+	r := p.Remote
+	msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Green.Regular("Sync"), "started")
+	out := BufferOut{Time: time.Now(), Text: "Sync started"}
+	p.stamp("log", out, msg, "")
+	id := p.started(ctx, "Sync")
+	start := time.Now()
+	sync := Response{Err: r.validate()}
+	if sync.Err == nil {
+		for _, cmd := range r.changes(p, changed) {
+			if sync = execute(ctx, p.Path, cmd); sync.Err != nil {
+				break
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	sync.Name, sync.ID = "Sync", id
+	sync.print(start, p)
+	results = append(results, sync)
+	if sync.Err != nil {
+		return
+	}
+	for _, c := range r.Commands {
+		res := execute(ctx, p.Path, r.exec(c))
+		if ctx.Err() != nil {
+			return
+		}
+		res.Name = c
+		p.results.send(res)
+		p.report(TaskSync, Command{Name: TaskSync}, res)
+		results = append(results, res)
+		if res.Err != nil && p.Watcher.FailFast {
+			break
+		}
+	}
+	return
+}
+
+// Changes are the commands that copy or remove a changed path, the whole project if empty
+func (r *Remote) changes(p *Project, changed string) [][]string {
+	if changed == "" {
+		return r.copy("", p)
+	}
+	base, _ := filepath.Abs(p.Path)
+	abs, _ := filepath.Abs(changed)
+	rel, err := filepath.Rel(base, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return r.copy("", p)
+	}
+	if _, err := os.Stat(changed); os.IsNotExist(err) {
+		return [][]string{r.remove(rel)}
+	}
+	return r.copy(rel, p)
+}
@@ -0,0 +1,86 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRemote_Copy(t *testing.T) {
+	r := Remote{Host: "dev", User: "me", Port: 2222, Key: "id_dev", Path: "/srv/app", Delete: true}
+	expected := [][]string{{"rsync", "-az", "-e", "ssh -p 2222 -i id_dev", "--delete", "--exclude", "vendor", "./", "me@dev:/srv/app/"}}
+	p := &Project{Path: ".", Watcher: Watch{Ignore: []string{"vendor"}}}
+	if cmds := r.copy("", p); !reflect.DeepEqual(cmds, expected) {
+		t.Error("Unexpected commands", cmds)
+	}
+	expected = [][]string{{"rsync", "-az", "-e", "ssh -p 2222 -i id_dev", "--relative", "./cmd/main.go", "me@dev:/srv/app/"}}
+	if cmds := r.copy(filepath.Join("cmd", "main.go"), p); !reflect.DeepEqual(cmds, expected) {
+		t.Error("Unexpected commands", cmds)
+	}
+	r.Tool = "scp"
+	expected = [][]string{
+		{"ssh", "-p", "2222", "-i", "id_dev", "me@dev", "mkdir -p '/srv/app/cmd'"},
+		{"scp", "-r", "-P", "2222", "-i", "id_dev", "./cmd/main.go", "me@dev:/srv/app/cmd/main.go"},
+	}
+	if cmds := r.copy("cmd/main.go", p); !reflect.DeepEqual(cmds, expected) {
+		t.Error("Unexpected commands", cmds)
+	}
+	// a colon isn't a host
+	expected = [][]string{
+		{"ssh", "-p", "2222", "-i", "id_dev", "me@dev", "mkdir -p '/srv/app'"},
+		{"scp", "-r", "-P", "2222", "-i", "id_dev", "./a:b.go", "me@dev:/srv/app/a:b.go"},
+	}
+	if cmds := r.copy("a:b.go", p); !reflect.DeepEqual(cmds, expected) {
+		t.Error("Unexpected commands", cmds)
+	}
+}
+
+func TestRemote_CopyIgnored(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, v := range []string{"main.go", "cmd/app/main.go", "web/app.js", "web/node_modules/lib.js", "tmp/out"} {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(v)), 0755)
+		ioutil.WriteFile(filepath.Join(dir, v), nil, 0644)
+	}
+	r := Remote{Host: "dev", Path: "app", Tool: "scp"}
+	p := &Project{Path: dir, Watcher: Watch{Ignore: []string{"tmp"}}}
+	expected := [][]string{
+		{"ssh", "dev", "mkdir -p 'app' 'app/web'"},
+		{"scp", "-r", "./cmd", "./main.go", "dev:app"},
+		{"scp", "-r", "./web/app.js", "dev:app/web"},
+	}
+	if cmds := r.copy("", p); !reflect.DeepEqual(cmds, expected) {
+		t.Error("Unexpected commands", cmds)
+	}
+	os.RemoveAll(filepath.Join(dir, "tmp"))
+	os.RemoveAll(filepath.Join(dir, "web", "node_modules"))
+	if cmds := r.copy("", p); !reflect.DeepEqual(cmds, [][]string{{"ssh", "dev", "mkdir -p 'app'"}, {"scp", "-r", ".", "dev:app"}}) {
+		t.Error("Unexpected commands", cmds)
+	}
+}
+
+func TestRemote_Changes(t *testing.T) {
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Project{Path: "."}
+	r := Remote{Host: "dev", Path: "/srv/it's"}
+	if cmds := r.changes(&p, filepath.Join(dir, "missing.go")); !reflect.DeepEqual(cmds, [][]string{{"ssh", "dev", `cd '/srv/it'\''s' && rm -rf 'missing.go'`}}) {
+		t.Error("Unexpected commands", cmds)
+	}
+	if cmds := r.changes(&p, filepath.Join(dir, "remote.go")); cmds[0][len(cmds[0])-2] != "./remote.go" {
+		t.Error("Unexpected commands", cmds)
+	}
+	if err := (&Remote{Host: "dev"}).validate(); err == nil {
+		t.Error("Expected an error")
+	}
+	if err := (&Remote{Host: "dev", Path: "/srv", Tool: "ftp"}).validate(); err == nil {
+		t.Error("Expected an error")
+	}
+}
@@ -0,0 +1,124 @@
+package realize
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RestartOnFailure restarts the run when it exits with an error
+const RestartOnFailure = "on-failure"
+
+const (
+	// first delay of the backoff, doubled at each restart
+	backoffMin = time.Second
+	// longest delay of the backoff
+	backoffMax = 30 * time.Second
+	// a run longer than this is stable, the next failure restarts from the first delay
+	backoffReset = time.Minute
+	// restarts after which realize gives up by default
+	restartsMax = 5
+)
+
+// OnFailure reports if the run is restarted when it fails
+func (t *Tool) onFailure() bool {
+	return strings.ToLower(strings.TrimSpace(t.Restart)) == RestartOnFailure
+}
+
+// Max restarts in a row before giving up
+func (t *Tool) restarts() int {
+	if t.Restarts > 0 {
+		return t.Restarts
+	}
+	return restartsMax
+}
+
+// Backoff delay of a restart
+func backoff(attempt int) time.Duration {
+	delay := backoffMin
+	for i := 0; i < attempt && delay < backoffMax; i++ {
+		delay *= 2
+	}
+	if delay > backoffMax {
+		delay = backoffMax
+	}
+	return delay
+}
+
+// Supervise runs the project, restarted on failure with an exponential backoff until the max restarts
+func (p *Project) supervise(ctx context.Context, path string, stream chan Response) error {
+	attempt := 0
+	for {
+		start := time.Now()
+		err := p.run(ctx, path, stream)
+		if err == nil || ctx.Err() != nil || !p.Tools.Run.onFailure() {
+			return err
+		}
+		if time.Since(start) > backoffReset {
+			attempt = 0
+		}
+		if attempt >= p.Tools.Run.restarts() {
+			msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold("Run"), "gave up after", Magenta.Bold(attempt), "restarts")
+			out := BufferOut{Time: time.Now(), Text: fmt.Sprint("Run gave up after ", attempt, " restarts"), Type: "Go Run"}
+			p.stamp("error", out, msg, "")
+			return err
+		}
+		delay := backoff(attempt)
+		attempt++
+		msg := fmt.Sprintln(p.pname(p.Name, 3), ":", Yellow.Bold("Run"), "failed,", "restart", Magenta.Bold(attempt), "of", p.Tools.Run.restarts(), "in", Magenta.Bold(delay))
+		out := BufferOut{Time: time.Now(), Text: fmt.Sprint("Run failed, restart ", attempt, " of ", p.Tools.Run.restarts(), " in ", delay), Type: "Go Run"}
+		p.stamp("warn", out, msg, "")
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// Keep a command alive in the background, relaunched with the backoff when it exits by itself until the context is done
+func (p *Project) keep(ctx context.Context, flag string, cmd Command) {
+	attempt := 0
+	for {
+		start := time.Now()
+		id := p.started(ctx, cmd.line())
+		r := cmd.run(ctx, p.Path)
+		if ctx.Err() != nil {
+			return
+		}
+		r.ID = id
+		p.finished(r, start)
+		p.diagnose(&r)
+		p.results.send(r)
+		if time.Since(start) > backoffReset {
+			attempt = 0
+		}
+		delay := backoff(attempt)
+		attempt++
+		stream := paint(cmd.Rules, r.Out)
+		if r.Err != nil {
+			stream = r.Err.Error()
+		}
+		// an exit is a failure
+		if !cmd.Output.shows(true) {
+			stream = ""
+		}
+		msg := fmt.Sprintln(p.pname(p.Name, 3), ":", Yellow.Bold("Command"), Yellow.Bold("\"")+cmd.line()+Yellow.Bold("\""), "exited, relaunched in", Magenta.Bold(delay))
+		out := BufferOut{Time: time.Now(), Text: fmt.Sprint("Command \"", cmd.line(), "\" exited, relaunched in ", delay), Type: flag, ID: id}
+		p.stamp("warn", out, msg, p.prefixed(cmd.label(), stream, r.Err != nil))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Lifetime of the watcher, the current run once
+func (p *Project) lifetime() context.Context {
+	if p.life == nil {
+		return p.context()
+	}
+	return p.life
+}
@@ -0,0 +1,78 @@
+package realize
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	for attempt, delay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		if d := backoff(attempt); d != delay {
+			t.Error("Unexpected delay", attempt, d)
+		}
+	}
+	if d := backoff(100); d != backoffMax {
+		t.Error("Unexpected max delay", d)
+	}
+	tool := Tool{Restart: " On-Failure"}
+	if !tool.onFailure() || tool.restarts() != restartsMax {
+		t.Error("Unexpected restart", tool.onFailure(), tool.restarts())
+	}
+}
+
+func TestProject_Supervise(t *testing.T) {
+	r := Realize{}
+	p := Project{parent: &r, Name: "test", Path: "."}
+	p.Tools.Run.Command = "exit 3"
+	stream := make(chan Response, 10)
+	// without restart the failure is returned
+	start := time.Now()
+	err := p.supervise(context.Background(), "", stream)
+	if exitCode(err) != 3 || time.Since(start) > backoffMin {
+		t.Error("Unexpected error", err)
+	}
+	// a restart, then realize gives up
+	p.Tools.Run.Restart, p.Tools.Run.Restarts = RestartOnFailure, 1
+	p.Buffer.StdErr = nil
+	start = time.Now()
+	err = p.supervise(context.Background(), "", stream)
+	if exitCode(err) != 3 || time.Since(start) < backoffMin || len(p.Buffer.StdErr) != 2 {
+		t.Error("Unexpected restart", err, p.Buffer.StdErr)
+	}
+	// canceled during the backoff
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if err = p.supervise(ctx, "", stream); err == nil || time.Since(start) > backoffMin {
+		t.Error("Expected the error of the canceled restart", err)
+	}
+}
+
+func TestProject_Keep(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r := Realize{}
+	p := Project{parent: &r, Name: "test", Path: dir}
+	p.Watcher.Scripts = []Command{
+		{Type: "before", Cmd: "echo started >> log", Shell: true, Alive: true},
+		{Type: "before", Cmd: "echo next"},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), backoffMin+500*time.Millisecond)
+	defer cancel()
+	// the sequence goes on, the command is relaunched after the first delay
+	if results := p.cmd(ctx, "before", false); len(results) != 1 || results[0].Out != "next\n" {
+		t.Error("Unexpected results", results)
+	}
+	<-ctx.Done()
+	time.Sleep(100 * time.Millisecond)
+	if log, _ := ioutil.ReadFile(filepath.Join(dir, "log")); string(log) != "started\nstarted\n" {
+		t.Error("Unexpected launches", string(log))
+	}
+}
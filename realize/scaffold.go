@@ -0,0 +1,281 @@
+package realize
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Built-in templates of the new command, the files are text templates of the name and the module
+var templates = map[string]map[string]string{
+	"api": {
+		"go.mod": "module {{.Module}}\n\ngo 1.14\n",
+		"main.go": `package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+)
+
+func main() {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "Hello from {{.Name}}")
+	})
+	log.Println("{{.Name}} listening on :" + port)
+	log.Fatal(http.ListenAndServe(":"+port, nil))
+}
+`,
+	},
+	"blue-green": {
+		"go.mod": "module {{.Module}}\n\ngo 1.14\n",
+		"main.go": `package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Started on the other port at each reload, the proxy on :8080 switches to it once healthy
+func main() {
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8081"
+	}
+	started := time.Now()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "Hello from {{.Name}} on :%s, pid %d, up %s\n", port, os.Getpid(), time.Since(started).Round(time.Second))
+	})
+	server := &http.Server{Addr: ":" + port, Handler: mux}
+	go func() {
+		log.Println("{{.Name}} listening on :" + port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+	// the requests in flight end before the exit
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	server.Shutdown(ctx)
+	log.Println("{{.Name}} on :" + port + " stopped")
+}
+`,
+		RFile: `settings:
+  legacy:
+    interval: 100ms
+schema:
+- name: {{.Name}}
+  path: .
+  commands:
+    run:
+      status: true
+      managed: true
+      strategy: blue-green
+      ports:
+      - 8081
+      - 8082
+      healthcheck:
+        url: http://localhost:8081/health
+        timeout: 30s
+  proxy:
+    listen: :8080
+  watcher:
+    extensions:
+    - go
+    paths:
+    - /
+    ignored_paths:
+    - .git
+    - .realize
+    - vendor
+`,
+	},
+	"cli": {
+		"go.mod": "module {{.Module}}\n\ngo 1.14\n",
+		"main.go": `package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+func main() {
+	name := flag.String("name", "world", "name to greet")
+	flag.Parse()
+	fmt.Printf("Hello %s from {{.Name}}\n", *name)
+}
+`,
+	},
+	"worker": {
+		"go.mod": "module {{.Module}}\n\ngo 1.14\n",
+		"main.go": `package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func main() {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	log.Println("{{.Name}} started")
+	for {
+		select {
+		case t := <-ticker.C:
+			log.Println("{{.Name}} working at", t.Format(time.RFC3339))
+		case <-stop:
+			log.Println("{{.Name}} stopped")
+			return
+		}
+	}
+}
+`,
+	},
+}
+
+// Templates returns the names of the built-in templates
+func Templates() (names []string) {
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// Scaffold writes a project in dir from a built-in template or a template dir, with a config running generate, install and run.
+// A config in the template dir is used as is, the existing files aren't overwritten. It returns the written files.
+func Scaffold(dir, name, module string) ([]string, error) {
+	files := map[string]string{}
+	if builtin, ok := templates[name]; ok {
+		for path, content := range builtin {
+			files[path] = content
+		}
+	} else if dir, err := readTemplate(name); err == nil {
+		files = dir
+	} else {
+		return nil, errors.New("there isn't a template " + name + ", use " + strings.Join(Templates(), ", ") + " or a template dir")
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	data := struct{ Name, Module string }{filepath.Base(abs), module}
+	if data.Module == "" {
+		data.Module = data.Name
+	}
+	if _, ok := files[RFile]; !ok {
+		config, err := scaffoldConfig(data.Name)
+		if err != nil {
+			return nil, err
+		}
+		files[RFile] = config
+	}
+	var paths []string
+	for path := range files {
+		if _, err := os.Stat(filepath.Join(dir, path)); err == nil {
+			return nil, errors.New(filepath.Join(dir, path) + " already exists")
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		tmpl, err := template.New(path).Parse(files[path])
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, err
+		}
+		file := filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(file), Permission); err != nil {
+			return nil, err
+		}
+		if err := ioutil.WriteFile(file, buf.Bytes(), 0644); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+// ReadTemplate reads the files of a template dir
+func readTemplate(dir string) (map[string]string, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, errors.New(dir + " isn't a directory")
+	}
+	files := map[string]string{}
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files[rel] = string(content)
+		return nil
+	})
+	return files, err
+}
+
+// ScaffoldConfig is the config of a new project, go generate, install and run on each change
+func scaffoldConfig(name string) (string, error) {
+	r := Realize{
+		Settings: Settings{Legacy: Legacy{Interval: 100 * time.Millisecond}},
+		Schema: Schema{Projects: []Project{{
+			Name: name,
+			Path: ".",
+			Tools: Tools{
+				Generate: Tool{Status: true},
+				Install:  Tool{Status: true},
+				Run:      Tool{Status: true},
+			},
+			Watcher: Watch{
+				Paths:  []string{"/"},
+				Ignore: []string{".git", ".realize", "vendor"},
+				Exts:   []string{"go"},
+			},
+		}}},
+	}
+	out, err := yaml.Marshal(r)
+	return string(out), err
+}
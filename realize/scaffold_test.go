@@ -0,0 +1,75 @@
+package realize
+
+import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestScaffold(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range Templates() {
+		app := filepath.Join(dir, name+"app")
+		files, err := Scaffold(app, name, "example.com/"+name+"app")
+		if err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+		if len(files) != 3 {
+			t.Error("Unexpected files", files)
+		}
+		if _, err := parser.ParseFile(token.NewFileSet(), filepath.Join(app, "main.go"), nil, 0); err != nil {
+			t.Error("Unexpected main.go", err)
+		}
+		mod, _ := ioutil.ReadFile(filepath.Join(app, "go.mod"))
+		if !strings.HasPrefix(string(mod), "module example.com/"+name+"app\n") {
+			t.Error("Unexpected go.mod", string(mod))
+		}
+		var r Realize
+		content, _ := ioutil.ReadFile(filepath.Join(app, RFile))
+		if err := yaml.Unmarshal(content, &r); err != nil || len(r.Projects) != 1 {
+			t.Fatal("Unexpected config", err, string(content))
+		}
+		p := r.Projects[0]
+		if p.Name != name+"app" || !p.Tools.Run.Status {
+			t.Error("Unexpected project", p)
+		}
+		// the blue-green one swaps a managed binary behind the proxy
+		if name == "blue-green" {
+			if err := p.checkStrategy(); err != nil || !p.Tools.Run.blueGreen() {
+				t.Error("Unexpected blue-green project", err)
+			}
+		} else if !p.Tools.Generate.Status || !p.Tools.Install.Status {
+			t.Error("Unexpected project", p)
+		}
+	}
+	if _, err := Scaffold(filepath.Join(dir, "apiapp"), "api", ""); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Error("Expected an error", err)
+	}
+	if _, err := Scaffold(filepath.Join(dir, "other"), "missing", ""); err == nil {
+		t.Error("Expected an error")
+	}
+	// template dir
+	tmpl := filepath.Join(dir, "tmpl")
+	os.MkdirAll(filepath.Join(tmpl, "cmd"), 0755)
+	ioutil.WriteFile(filepath.Join(tmpl, "cmd", "main.go"), []byte("package main // {{.Name}} {{.Module}}"), 0644)
+	if _, err := Scaffold(filepath.Join(dir, "custom"), tmpl, "example.com/custom"); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	main, _ := ioutil.ReadFile(filepath.Join(dir, "custom", "cmd", "main.go"))
+	if string(main) != "package main // custom example.com/custom" {
+		t.Error("Unexpected file", string(main))
+	}
+	if _, err := os.Stat(filepath.Join(dir, "custom", RFile)); err != nil {
+		t.Error("Expected a config", err)
+	}
+}
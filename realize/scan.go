@@ -0,0 +1,98 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Workers reading the folders of a scan
+const scanWorkers = 16
+
+// Scanned is a path checked by a scan worker
+type scanned struct {
+	path  string
+	info  os.FileInfo
+	rule  string
+	deep  bool
+	valid bool
+}
+
+// Check a path, it's safe for the scan workers: the ignore rule, the max depth and the validation
+func (p *Project) check(ignoring ignoring, path string, info os.FileInfo) scanned {
+	abs, _ := filepath.Abs(path)
+	s := scanned{path: path, info: info, rule: ignoring.by(abs)}
+	if s.rule != "" {
+		return s
+	}
+	// too deep below the watched path
+	s.deep = p.Watcher.MaxDepth > 0 && info != nil && info.IsDir() && p.depth(path) > p.Watcher.MaxDepth
+	s.valid = !s.deep && p.validate(path, true, ignoring)
+	return s
+}
+
+// Listing is a folder read by a scan worker, its checked entries or the error reading it
+type listing struct {
+	dir     string
+	entries []scanned
+	err     error
+}
+
+// Read the entries of a folder and check them
+func (p *Project) read(ignoring ignoring, dir string) listing {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return listing{dir: dir, err: err}
+	}
+	list := listing{dir: dir, entries: make([]scanned, 0, len(infos))}
+	for _, info := range infos {
+		list.entries = append(list.entries, p.check(ignoring, filepath.Join(dir, info.Name()), info))
+	}
+	return list
+}
+
+// Scan indexes a tree, its folders are read and their entries checked by a pool of workers.
+// The ignored and too deep folders aren't read, the checked paths are added to the watcher one at a time.
+func (p *Project) scan(root string) {
+	info, err := os.Lstat(root)
+	if err != nil {
+		p.set.fail(root, err)
+		return
+	}
+	ignoring := p.ignoring()
+	var queue []string
+	if p.admit(p.check(ignoring, root, info)) && info.IsDir() {
+		queue = append(queue, root)
+	}
+	dirs, results := make(chan string), make(chan listing)
+	defer close(dirs)
+	for i := 0; i < scanWorkers; i++ {
+		go func() {
+			for dir := range dirs {
+				results <- p.read(ignoring, dir)
+			}
+		}()
+	}
+	for reading := 0; len(queue) > 0 || reading > 0; {
+		var send chan string
+		var next string
+		if len(queue) > 0 {
+			send, next = dirs, queue[len(queue)-1]
+		}
+		select {
+		case send <- next:
+			queue = queue[:len(queue)-1]
+			reading++
+		case list := <-results:
+			reading--
+			if list.err != nil {
+				p.set.fail(list.dir, list.err)
+			}
+			for _, s := range list.entries {
+				if p.admit(s) && s.info.IsDir() {
+					queue = append(queue, s.path)
+				}
+			}
+		}
+	}
+}
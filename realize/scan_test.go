@@ -0,0 +1,58 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestProject_Scan(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for i := 0; i < 20; i++ {
+		sub := filepath.Join(dir, "pkg"+strconv.Itoa(i), "internal")
+		os.MkdirAll(filepath.Join(sub, "deep"), 0755)
+		os.MkdirAll(filepath.Join(dir, "pkg"+strconv.Itoa(i), "vendor"), 0755)
+		// an ignored file doesn't skip the next ones
+		ioutil.WriteFile(filepath.Join(dir, "pkg"+strconv.Itoa(i), "a.test"), []byte("x"), 0644)
+		ioutil.WriteFile(filepath.Join(dir, "pkg"+strconv.Itoa(i), "b.go"), []byte("x"), 0644)
+		ioutil.WriteFile(filepath.Join(sub, "c.go"), []byte("x"), 0644)
+		ioutil.WriteFile(filepath.Join(sub, "deep", "d.go"), []byte("x"), 0644)
+	}
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{parent: &r, Path: dir, set: &recorder{}})
+	p := &r.Projects[0]
+	p.Watcher = Watch{Exts: []string{"go"}, Paths: []string{"/"}, MaxDepth: 2}
+	p.scan(dir)
+	if p.files != 40 || p.folders != 41 || len(p.dirs) != 41 {
+		t.Error("Unexpected index", p.files, p.folders)
+	}
+	set := p.set.snapshot()
+	if len(set.Ignored) != 2 || set.Ignored[0] != (IgnoreRule{Rule: "*.test", Matches: 20}) || set.Ignored[1] != (IgnoreRule{Rule: "vendor", Matches: 20}) {
+		t.Error("Unexpected ignore rules", set.Ignored)
+	}
+}
+
+func TestProject_ScanErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p := Project{parent: &Realize{}, Path: dir, set: &recorder{}}
+	p.scan(filepath.Join(dir, "missing"))
+	p.walk(filepath.Join(dir, "denied"), nil, os.ErrPermission)
+	set := p.set.snapshot()
+	if len(set.Errors) != 2 || set.Errors[0].Path != filepath.Join(dir, "missing") || set.Errors[1].Error != os.ErrPermission.Error() {
+		t.Error("Unexpected errors", set.Errors)
+	}
+	p.summary()
+	if len(p.Buffer.StdErr) != 1 || p.Buffer.StdErr[0].Text != "2 paths not indexed" {
+		t.Error("Unexpected report", p.Buffer.StdErr)
+	}
+}
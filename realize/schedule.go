@@ -0,0 +1,211 @@
+package realize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TaskSchedule is the output type of the scheduled commands
+const TaskSchedule = "schedule"
+
+// Schedule of a periodic command, cron expression or interval
+type schedule interface {
+	next(time.Time) time.Time
+}
+
+// Every schedule, @every 5m or a plain duration
+type every time.Duration
+
+// Cron schedule, one bit for each allowed value of the fields
+type cron struct {
+	minute, hour, dom, month, dow uint64
+	any                           bool // day of month or day of week is a wildcard
+}
+
+// Cron macros
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Cron fields bounds
+var bounds = []struct{ min, max int }{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// ParseSchedule parse a cron expression, a macro as @daily or an interval as @every 10m
+func parseSchedule(value string) (schedule, error) {
+	value = strings.TrimSpace(value)
+	if m, ok := macros[value]; ok {
+		value = m
+	}
+	if strings.HasPrefix(value, "@every ") {
+		value = strings.TrimSpace(strings.TrimPrefix(value, "@every "))
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < time.Second {
+			return nil, errors.New("Schedule interval too short '" + value + "'")
+		}
+		return every(d), nil
+	}
+	fields := strings.Fields(value)
+	if len(fields) != 5 {
+		return nil, errors.New("Invalid schedule '" + value + "', expected 5 cron fields or an interval")
+	}
+	var bits [5]uint64
+	for i, field := range fields {
+		b, err := parseField(field, bounds[i].min, bounds[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid schedule '%s': %s", value, err)
+		}
+		bits[i] = b
+	}
+	// sunday is both 0 and 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return &cron{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		any:    strings.HasPrefix(fields[2], "*") || strings.HasPrefix(fields[4], "*"),
+	}, nil
+}
+
+// ParseField parse a list of values, ranges and steps as 1,5-10,*/15
+func parseField(field string, min, max int) (bits uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, errors.New("invalid step '" + part + "'")
+			}
+			part = part[:i]
+		}
+		start, end := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			r := strings.SplitN(part, "-", 2)
+			if start, err = strconv.Atoi(r[0]); err != nil {
+				return 0, errors.New("invalid range '" + part + "'")
+			}
+			if end, err = strconv.Atoi(r[1]); err != nil {
+				return 0, errors.New("invalid range '" + part + "'")
+			}
+		default:
+			if start, err = strconv.Atoi(part); err != nil {
+				return 0, errors.New("invalid value '" + part + "'")
+			}
+			end = start
+			if step > 1 {
+				end = max
+			}
+		}
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("'%s' out of range %d-%d", part, min, max)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next run after the given time
+func (i every) next(t time.Time) time.Time {
+	return t.Add(time.Duration(i))
+}
+
+// Next minute matching the cron fields, zero if there isn't one within five years
+func (c *cron) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.day(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			// the zone offset may not be whole hours
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Day match, day of month and day of week are in or when both are restricted
+func (c *cron) day(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.any {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Schedules start a timer for each scheduled command, the index of a due command is sent on the channel
+func (p *Project) schedules(ctx context.Context) <-chan int {
+	due := make(chan int)
+	for i, c := range p.Watcher.scripts() {
+		if c.Schedule == "" {
+			continue
+		}
+		s, err := parseSchedule(c.Schedule)
+		if err != nil {
+			p.Err(err)
+			continue
+		}
+		go func(i int, s schedule) {
+			for {
+				next := s.next(time.Now())
+				if next.IsZero() {
+					return
+				}
+				timer := time.NewTimer(time.Until(next))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case due <- i:
+				}
+			}
+		}(i, s)
+	}
+	return due
+}
+
+// Scheduled run a command fired by its schedule, it's canceled with the current run
+func (p *Project) scheduled(ctx context.Context, i int) {
+	cmd := p.Watcher.scripts()[i]
+	cmd.parent = p
+	start := time.Now()
+	id := p.started(ctx, cmd.line())
+	r := cmd.run(ctx, p.Path)
+	if ctx.Err() != nil {
+		return
+	}
+	r.ID = id
+	p.finished(r, start)
+	p.results.send(r)
+	p.report(TaskSchedule, cmd, r)
+}
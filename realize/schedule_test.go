@@ -0,0 +1,52 @@
+package realize
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	valid := []string{"*/5 * * * *", "0 9-17 * * 1-5", "@daily", "@every 10m", "90s", "0 0 1,15 * 7"}
+	for _, v := range valid {
+		if _, err := parseSchedule(v); err != nil {
+			t.Error("Unexpected error", v, err)
+		}
+	}
+	invalid := []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "a * * * *", "@every 10ms", "5-1 * * * *"}
+	for _, v := range invalid {
+		if _, err := parseSchedule(v); err == nil {
+			t.Error("Expected an error", v)
+		}
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	// saturday
+	now := time.Date(2018, 3, 10, 10, 7, 30, 0, time.UTC)
+	expected := map[string]time.Time{
+		"*/15 * * * *":   time.Date(2018, 3, 10, 10, 15, 0, 0, time.UTC),
+		"0 9 * * 1-5":    time.Date(2018, 3, 12, 9, 0, 0, 0, time.UTC),
+		"30 2 1 * *":     time.Date(2018, 4, 1, 2, 30, 0, 0, time.UTC),
+		"0 0 13 * 0":     time.Date(2018, 3, 11, 0, 0, 0, 0, time.UTC),
+		"@yearly":        time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		"@every 1h":      now.Add(time.Hour),
+		"0 0 31 2 *":     {},
+		"7 10 10 3 *":    time.Date(2019, 3, 10, 10, 7, 0, 0, time.UTC),
+		"8-10 10 10 3 *": time.Date(2018, 3, 10, 10, 8, 0, 0, time.UTC),
+	}
+	for v, e := range expected {
+		s, err := parseSchedule(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if next := s.next(now); !next.Equal(e) {
+			t.Error("Unexpected next run", v, next, e)
+		}
+	}
+	// an offset of half an hour
+	zone := time.FixedZone("IST", 5*3600+1800)
+	s, _ := parseSchedule("0 12 * * *")
+	if next := s.next(time.Date(2018, 3, 10, 10, 7, 0, 0, zone)); !next.Equal(time.Date(2018, 3, 10, 12, 0, 0, 0, zone)) {
+		t.Error("Unexpected next run", next)
+	}
+}
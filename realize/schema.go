@@ -4,6 +4,7 @@ import (
 	"errors"
 	"path/filepath"
 	"reflect"
+	"strings"
 
 	"github.com/urfave/cli/v2"
 )
@@ -56,8 +57,12 @@ func (s *Schema) New(c *cli.Context) Project {
 			Fmt: Tool{
 				Status: c.Bool("fmt"),
 			},
+			Lint: Tool{
+				Status: c.Bool("lint"),
+			},
 			Test: Tool{
-				Status: c.Bool("test"),
+				Status:   c.Bool("test") || c.Bool("affected"),
+				Affected: c.Bool("affected"),
 			},
 			Generate: Tool{
 				Status: c.Bool("generate"),
@@ -80,6 +85,20 @@ func (s *Schema) New(c *cli.Context) Project {
 			Exts:   []string{"go"},
 		},
 	}
+	// ad-hoc paths, extensions and run command
+	if paths := List(c.String("watch")); len(paths) > 0 {
+		project.Watcher.Paths = paths
+	}
+	if exts := List(c.String("ext")); len(exts) > 0 {
+		project.Watcher.Exts = nil
+		for _, e := range exts {
+			project.Watcher.Exts = append(project.Watcher.Exts, strings.TrimPrefix(e, "."))
+		}
+	}
+	if cmd := c.String("cmd"); cmd != "" {
+		project.Tools.Run.Status = true
+		project.Tools.Run.Command = cmd
+	}
 	return project
 }
 
@@ -98,3 +117,36 @@ func (s *Schema) Filter(field string, value interface{}) []Project {
 	}
 	return result
 }
+
+// Select the projects by name or by tag, an unknown name is an error
+func (s *Schema) Select(names, tags []string) ([]Project, error) {
+	result := []Project{}
+	found := make(map[string]bool)
+	for _, item := range s.Projects {
+		selected := false
+		for _, name := range names {
+			if strings.EqualFold(item.Name, name) {
+				selected, found[strings.ToLower(name)] = true, true
+			}
+		}
+		for _, tag := range tags {
+			for _, t := range item.Tags {
+				if strings.EqualFold(t, tag) {
+					selected = true
+				}
+			}
+		}
+		if selected {
+			result = append(result, item)
+		}
+	}
+	for _, name := range names {
+		if !found[strings.ToLower(name)] {
+			return nil, errors.New("There isn't a project with name '" + name + "'. Check your config file!")
+		}
+	}
+	if len(result) == 0 {
+		return nil, errors.New("There isn't a project with tag '" + strings.Join(tags, "', '") + "'. Check your config file!")
+	}
+	return result, nil
+}
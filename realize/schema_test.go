@@ -4,6 +4,7 @@ import (
 	"flag"
 	"github.com/urfave/cli/v2"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -81,6 +82,31 @@ func TestSchema_New(t *testing.T) {
 	}
 }
 
+func TestSchema_NewAdhoc(t *testing.T) {
+	r := Realize{}
+	set := flag.NewFlagSet("test", 0)
+	set.Bool("run", false, "")
+	set.String("path", "", "")
+	set.String("watch", "", "")
+	set.String("ext", "", "")
+	set.String("cmd", "", "")
+	c := cli.NewContext(nil, set, nil)
+	set.Parse([]string{"--path", "cmd/api", "--watch", "/, ../templates", "--ext", "go,.tmpl", "--cmd", "go run ./cmd/api"})
+	p := r.New(c)
+	if p.Name != "api" || p.Path != "cmd/api" {
+		t.Error("Unexpected project", p.Name, p.Path)
+	}
+	if !reflect.DeepEqual(p.Watcher.Paths, []string{"/", "../templates"}) {
+		t.Error("Unexpected paths", p.Watcher.Paths)
+	}
+	if !reflect.DeepEqual(p.Watcher.Exts, []string{"go", "tmpl"}) {
+		t.Error("Unexpected extensions", p.Watcher.Exts)
+	}
+	if !p.Tools.Run.Status || p.Tools.Run.Command != "go run ./cmd/api" {
+		t.Error("Unexpected run", p.Tools.Run)
+	}
+}
+
 func TestSchema_Filter(t *testing.T) {
 	r := Realize{}
 	r.Schema.Projects = []Project{
@@ -102,3 +128,25 @@ func TestSchema_Filter(t *testing.T) {
 		t.Error("Expected one project")
 	}
 }
+
+func TestSchema_Select(t *testing.T) {
+	s := Schema{Projects: []Project{
+		{Name: "api", Tags: []string{"backend"}},
+		{Name: "worker", Tags: []string{"backend"}},
+		{Name: "web", Tags: []string{"frontend"}},
+	}}
+	result, err := s.Select([]string{"API", "web"}, nil)
+	if err != nil || len(result) != 2 || result[0].Name != "api" || result[1].Name != "web" {
+		t.Error("Unexpected result", result, err)
+	}
+	result, err = s.Select([]string{"web"}, []string{"backend"})
+	if err != nil || len(result) != 3 {
+		t.Error("Unexpected result", result, err)
+	}
+	if _, err = s.Select([]string{"missing"}, nil); err == nil {
+		t.Error("Expected an error")
+	}
+	if _, err = s.Select(nil, []string{"missing"}); err == nil {
+		t.Error("Expected an error")
+	}
+}
@@ -10,11 +10,15 @@ import (
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
 	"golang.org/x/net/websocket"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strconv"
+	"strings"
+	"sync"
 )
 
 // Dafault host and port
@@ -23,13 +27,165 @@ const (
 	Port = 5002
 )
 
+// Reload script injected in the pages that need to be refreshed after a build,
+// a project parameter in the script url filters the events
+const reloadScript = `(function () {
+	var src = document.currentScript.src;
+	var query = src.indexOf("?") >= 0 ? src.substring(src.indexOf("?")) : "";
+	var source = new EventSource(src.substring(0, src.indexOf("/", 8)) + "/livereload/events" + query);
+	source.onmessage = function () {
+		location.reload();
+	};
+})();
+`
+
+// LiveReload protocol handshake
+var liveReloadHello = map[string]interface{}{
+	"command":    "hello",
+	"protocols":  []string{"http://livereload.com/protocols/official-7"},
+	"serverName": "realize",
+}
+
 // Server settings
 type Server struct {
-	Parent *Realize `yaml:"-" json:"-"`
-	Status bool     `yaml:"status" json:"status"`
-	Open   bool     `yaml:"open" json:"open"`
-	Port   int      `yaml:"port" json:"port"`
-	Host   string   `yaml:"host" json:"host"`
+	Parent  *Realize `yaml:"-" json:"-"`
+	Status  bool     `yaml:"status" json:"status"`
+	Open    bool     `yaml:"open" json:"open"`
+	Port    int      `yaml:"port" json:"port"`
+	Host    string   `yaml:"host" json:"host"`
+	clients *clients
+}
+
+// Clients waiting for a reload event
+type clients struct {
+	mu   sync.Mutex
+	list map[chan string]bool
+}
+
+// Add a client
+func (c *clients) add() chan string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.list == nil {
+		c.list = make(map[chan string]bool)
+	}
+	ch := make(chan string, 1)
+	c.list[ch] = true
+	return ch
+}
+
+// Remove a client
+func (c *clients) remove(ch chan string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.list, ch)
+}
+
+// Send an event to all the clients, slow clients skip it
+func (c *clients) send(event string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for ch := range c.list {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Reload the browsers connected to the server
+func (s *Server) Reload(project string) {
+	if s.clients != nil {
+		s.clients.send(project)
+	}
+}
+
+// Events streams the reload events to a browser, optionally only the ones of a project
+func (s *Server) events(c echo.Context) error {
+	ch := s.clients.add()
+	defer s.clients.remove(ch)
+	filter := c.QueryParam("project")
+	rs := c.Response()
+	rs.Header().Set(echo.HeaderContentType, "text/event-stream")
+	rs.Header().Set("Cache-Control", "no-cache")
+	rs.Header().Set(echo.HeaderAccessControlAllowOrigin, "*")
+	rs.WriteHeader(http.StatusOK)
+	rs.Flush()
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case project := <-ch:
+			if filter != "" && filter != project {
+				continue
+			}
+			if _, err := fmt.Fprintf(rs, "data: %s\n\n", project); err != nil {
+				return nil
+			}
+			rs.Flush()
+		}
+	}
+}
+
+// LiveReload speaks the livereload protocol with the browser extensions and livereload.js
+func (s *Server) liveReload(c echo.Context) error {
+	websocket.Handler(func(ws *websocket.Conn) {
+		ch := s.clients.add()
+		defer s.clients.remove(ch)
+		var hello map[string]interface{}
+		if err := websocket.JSON.Receive(ws, &hello); err != nil || hello["command"] != "hello" {
+			return
+		}
+		if err := websocket.JSON.Send(ws, liveReloadHello); err != nil {
+			return
+		}
+		// info and url commands are ignored, a read error means the browser is gone
+		closed := make(chan bool)
+		go func() {
+			var msg map[string]interface{}
+			for websocket.JSON.Receive(ws, &msg) == nil {
+			}
+			close(closed)
+		}()
+		for {
+			select {
+			case <-closed:
+				return
+			case project := <-ch:
+				reload := map[string]interface{}{"command": "reload", "path": project, "liveCSS": true}
+				if err := websocket.JSON.Send(ws, reload); err != nil {
+					return
+				}
+			}
+		}
+	}).ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// Wasm serves the webassembly artifact of a project, built in the dir of the tool
+func (s *Server) wasm(c echo.Context) error {
+	for _, p := range s.Parent.Schema.Projects {
+		if p.Name == c.Param("project") && p.Tools.Wasm.Status {
+			data, err := ioutil.ReadFile(workdir(p.Tools.Wasm.workdir(p.Path), p.Tools.Wasm.Path))
+			if err != nil {
+				return echo.NewHTTPError(http.StatusNotFound)
+			}
+			c.Response().Header().Set(echo.HeaderAccessControlAllowOrigin, "*")
+			return c.Blob(http.StatusOK, "application/wasm", data)
+		}
+	}
+	return echo.NewHTTPError(http.StatusNotFound)
+}
+
+// WasmExec serves the javascript support file shipped with go
+func (s *Server) wasmExec(c echo.Context) error {
+	for _, dir := range []string{"lib", "misc"} {
+		data, err := ioutil.ReadFile(filepath.Join(runtime.GOROOT(), dir, "wasm", "wasm_exec.js"))
+		if err == nil {
+			return c.Blob(http.StatusOK, echo.MIMEApplicationJavaScriptCharsetUTF8, data)
+		}
+	}
+	return echo.NewHTTPError(http.StatusNotFound)
 }
 
 // Websocket projects
@@ -109,8 +265,12 @@ func (s *Server) Set(status bool, open bool, port int, host string) {
 func (s *Server) Start() (err error) {
 	if s.Status {
 		e := echo.New()
+		s.clients = &clients{}
 		e.Use(middleware.GzipWithConfig(middleware.GzipConfig{
 			Level: 2,
+			Skipper: func(c echo.Context) bool {
+				return strings.HasSuffix(c.Path(), "/events") || c.Path() == "/livereload"
+			},
 		}))
 		e.Use(middleware.Recover())
 
@@ -152,6 +312,23 @@ func (s *Server) Start() (err error) {
 			return s.render(c, "assets/assets/img/svg/ic_settings_black_48px.svg", 4)
 		})
 
+		// browser refresh
+		script := func(c echo.Context) error {
+			return c.Blob(http.StatusOK, echo.MIMEApplicationJavaScriptCharsetUTF8, []byte(reloadScript))
+		}
+		e.GET("/livereload/events", s.events)
+		e.GET("/livereload/reload.js", script)
+		e.GET("/livereload", s.liveReload)
+
+		// webassembly
+		e.GET("/wasm/events", s.events)
+		e.GET("/wasm/reload.js", script)
+		e.GET("/wasm/wasm_exec.js", s.wasmExec)
+		e.GET("/wasm/:project", s.wasm)
+
+		// coverage report
+		e.GET("/cover/:project", s.cover)
+
 		//websocket
 		e.GET("/ws", s.projects)
 		e.HideBanner = true
@@ -1,8 +1,17 @@
 package realize
 
 import (
+	"github.com/labstack/echo"
+	"golang.org/x/net/websocket"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"runtime"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestServer_Open(t *testing.T) {
@@ -16,3 +25,92 @@ func TestServer_Open(t *testing.T) {
 		t.Error("System not supported")
 	}
 }
+
+func TestServer_Reload(t *testing.T) {
+	s := Server{}
+	// no clients without a running server
+	s.Reload("test")
+	s.clients = &clients{}
+	ch := s.clients.add()
+	s.Reload("test")
+	if v := <-ch; v != "test" {
+		t.Error("Expected test instead", v)
+	}
+	s.clients.remove(ch)
+	if len(s.clients.list) != 0 {
+		t.Error("Unexpected client")
+	}
+}
+
+func TestServer_LiveReload(t *testing.T) {
+	s := Server{clients: &clients{}}
+	e := echo.New()
+	e.GET("/livereload", s.liveReload)
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(ts.URL, "http")+"/livereload", "", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	websocket.JSON.Send(ws, map[string]interface{}{"command": "hello", "protocols": []string{"http://livereload.com/protocols/official-7"}})
+	var msg map[string]interface{}
+	if err := websocket.JSON.Receive(ws, &msg); err != nil || msg["command"] != "hello" {
+		t.Fatal("Expected hello", msg, err)
+	}
+	// the client is added before the handshake
+	s.Reload("test")
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+	if err := websocket.JSON.Receive(ws, &msg); err != nil || msg["command"] != "reload" || msg["path"] != "test" {
+		t.Error("Expected reload", msg, err)
+	}
+}
+
+func TestProject_LiveReload(t *testing.T) {
+	r := Realize{}
+	r.Server.clients = &clients{}
+	ch := r.Server.clients.add()
+	p := Project{parent: &r, Name: "test"}
+	p.notify(StatusSuccess, "completed")
+	select {
+	case v := <-ch:
+		t.Error("Unexpected reload", v)
+	default:
+	}
+	p.LiveReload = true
+	p.notify(StatusFailure, "failed")
+	p.notify(StatusSuccess, "completed")
+	if v := <-ch; v != "test" {
+		t.Error("Expected test instead", v)
+	}
+}
+
+func TestServer_Wasm(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.MkdirAll(filepath.Join(dir, "web"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "web", "main.wasm"), []byte("wasm"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{Name: "app", Path: dir, Tools: Tools{Wasm: Tool{Status: true, Path: "main.wasm", Dir: "web"}}})
+	r.Projects[0].Tools.Wasm.parent = &r.Projects[0]
+	s := Server{Parent: &r}
+	e := echo.New()
+	e.GET("/wasm/:project", s.wasm)
+	ts := httptest.NewServer(e)
+	defer ts.Close()
+	resp, err := http.Get(ts.URL + "/wasm/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Access-Control-Allow-Origin") != "*" {
+		t.Error("Unexpected response", resp.Status, resp.Header)
+	}
+}
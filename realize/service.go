@@ -0,0 +1,214 @@
+package realize
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+)
+
+// Journald priorities of the levels, as sd-daemon line prefixes
+var priorities = map[Level]string{
+	LevelDebug: "<7>",
+	LevelInfo:  "<6>",
+	LevelWarn:  "<4>",
+	LevelError: "<3>",
+}
+
+// Journal output, without colors and timestamps and with the priority of each line
+var journal bool
+
+// Service is a systemd unit, or a winsw service on windows, running realize as supervisor of the projects of a dir
+type Service struct {
+	Name string
+	Dir  string
+	Bin  string
+	Args []string
+	User string
+	Env  []string
+}
+
+// Supervise prepares the output to run unattended, journald stamps the lines by itself
+func Supervise() {
+	Plain()
+	journal = true
+}
+
+// Journald is true when the output is connected to the journal
+func Journald() bool {
+	return os.Getenv("JOURNAL_STREAM") != ""
+}
+
+// NewService of the projects of a dir, the current path and gopath are kept because
+// the service manager starts the units with a minimal environment
+func NewService(dir string, name string, args []string) (Service, error) {
+	bin, err := os.Executable()
+	if err != nil {
+		return Service{}, err
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return Service{}, err
+	}
+	if name == "" {
+		name = RPrefix + "-" + filepath.Base(dir)
+	}
+	s := Service{
+		Name: serviceName(name),
+		Dir:  dir,
+		Bin:  bin,
+		Args: append([]string{"start", "--supervisor"}, args...),
+		Env:  []string{"PATH=" + os.Getenv("PATH"), "GOPATH=" + build.Default.GOPATH},
+	}
+	if u, err := user.Current(); err == nil {
+		s.User = u.Username
+	}
+	return s, nil
+}
+
+// Unit file of the service, user units run as the owner of the service manager
+func (s Service) Unit(system bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Unit]\nDescription=%s %s\nAfter=network.target\n\n", strings.Title(RPrefix), specifiers(s.Dir))
+	b.WriteString("[Service]\nType=simple\n")
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", specifiers(s.Dir))
+	exec := []string{unitQuote(variables(specifiers(s.Bin)))}
+	for _, arg := range s.Args {
+		exec = append(exec, unitQuote(variables(specifiers(arg))))
+	}
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(exec, " "))
+	for _, env := range s.Env {
+		fmt.Fprintf(&b, "Environment=%s\n", unitQuote(specifiers(env)))
+	}
+	if system && s.User != "" {
+		fmt.Fprintf(&b, "User=%s\n", s.User)
+	}
+	// only realize gets the stop signal, it interrupts the commands and runs the exit ones
+	b.WriteString("KillMode=mixed\nRestart=on-failure\nRestartSec=5\n\n[Install]\n")
+	if system {
+		b.WriteString("WantedBy=multi-user.target\n")
+	} else {
+		b.WriteString("WantedBy=default.target\n")
+	}
+	return b.String()
+}
+
+// Winsw config of the service, the windows services are run by the winsw wrapper named after it.
+// They're system services, run as the local system
+func (s Service) Winsw() string {
+	var b strings.Builder
+	b.WriteString("<service>\n")
+	fmt.Fprintf(&b, "  <id>%s</id>\n", xmlText(s.Name))
+	fmt.Fprintf(&b, "  <name>%s</name>\n", xmlText(s.Name))
+	fmt.Fprintf(&b, "  <description>%s %s</description>\n", strings.Title(RPrefix), xmlText(s.Dir))
+	fmt.Fprintf(&b, "  <executable>%s</executable>\n", xmlText(s.Bin))
+	args := make([]string, len(s.Args))
+	for i, arg := range s.Args {
+		args[i] = winQuote(arg)
+	}
+	fmt.Fprintf(&b, "  <arguments>%s</arguments>\n", xmlText(strings.Join(args, " ")))
+	fmt.Fprintf(&b, "  <workingdirectory>%s</workingdirectory>\n", xmlText(s.Dir))
+	for _, env := range s.Env {
+		kv := strings.SplitN(env, "=", 2)
+		if len(kv) == 2 {
+			fmt.Fprintf(&b, "  <env name=\"%s\" value=\"%s\"/>\n", xmlText(kv[0]), xmlText(kv[1]))
+		}
+	}
+	b.WriteString("  <onfailure action=\"restart\" delay=\"5 sec\"/>\n  <log mode=\"roll\"/>\n</service>\n")
+	return b.String()
+}
+
+// Install writes the unit file and returns its path, existing units aren't overwritten
+func (s Service) Install(system bool) (string, error) {
+	dir, err := serviceDir(system)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, s.Name+serviceExt)
+	if _, err := os.Stat(path); err == nil {
+		return path, errors.New(path + " already exists, uninstall it first")
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return path, err
+	}
+	return path, ioutil.WriteFile(path, []byte(s.Config(system)), 0644)
+}
+
+// Uninstall removes the unit file of a service and returns its path
+func Uninstall(name string, system bool) (string, error) {
+	dir, err := serviceDir(system)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, serviceName(name)+serviceExt)
+	return path, os.Remove(path)
+}
+
+// Name of a service without the extension of its file
+func serviceName(name string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(name, ".service"), ".xml")
+}
+
+// Prioritize each line of a text, each one is a journal entry
+func prioritize(text string, priority string) string {
+	if priority == "" {
+		return text
+	}
+	lines := strings.TrimSuffix(text, "\n")
+	return priority + strings.Replace(lines, "\n", "\n"+priority, -1) + text[len(lines):]
+}
+
+// Quote a unit value when it has spaces or quotes
+func unitQuote(value string) string {
+	if strings.ContainsAny(value, " \t\"'\\") {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+	}
+	return value
+}
+
+// Quote a windows argument when it has spaces or quotes
+func winQuote(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\"") {
+		return value
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	slashes := 0
+	for _, c := range value {
+		switch c {
+		case '\\':
+			slashes++
+		case '"':
+			// the backslashes before a quote are escaped too
+			b.WriteString(strings.Repeat(`\`, slashes+1))
+			slashes = 0
+		default:
+			slashes = 0
+		}
+		b.WriteRune(c)
+	}
+	b.WriteString(strings.Repeat(`\`, slashes))
+	b.WriteByte('"')
+	return b.String()
+}
+
+// Escape a text of the xml
+func xmlText(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+// Escape the specifiers expanded by systemd
+func specifiers(value string) string {
+	return strings.Replace(value, "%", "%%", -1)
+}
+
+// Escape the variables expanded in the command lines
+func variables(value string) string {
+	return strings.Replace(value, "$", "$$", -1)
+}
@@ -0,0 +1,25 @@
+// +build linux
+
+package realize
+
+import "os"
+
+// Extension of the systemd units
+const serviceExt = ".service"
+
+// Dir of the systemd units, of the system or of the current user
+func serviceDir(system bool) (string, error) {
+	if system {
+		return "/etc/systemd/system", nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return dir + "/systemd/user", nil
+}
+
+// Config of the service, its systemd unit
+func (s Service) Config(system bool) string {
+	return s.Unit(system)
+}
@@ -0,0 +1,37 @@
+// +build linux
+
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestService_Install(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Setenv("XDG_CONFIG_HOME", dir)
+	s := Service{Name: "realize-app", Dir: dir, Bin: "realize"}
+	path, err := s.Install(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(dir, "systemd", "user", "realize-app.service") {
+		t.Error("Unexpected path", path)
+	}
+	if _, err = s.Install(false); err == nil {
+		t.Error("Expected an error, the unit exists")
+	}
+	if _, err = Uninstall("realize-app", false); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected the unit removed")
+	}
+}
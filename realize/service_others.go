@@ -0,0 +1,21 @@
+// +build !linux,!windows
+
+package realize
+
+import (
+	"errors"
+	"runtime"
+)
+
+// Extension of the systemd units
+const serviceExt = ".service"
+
+// serviceDir isn't supported on this platform, only systemd units and winsw services are generated
+func serviceDir(system bool) (string, error) {
+	return "", errors.New("services aren't supported on " + runtime.GOOS + ", run realize start --supervisor from the service manager")
+}
+
+// Config of the service, its systemd unit
+func (s Service) Config(system bool) string {
+	return s.Unit(system)
+}
@@ -0,0 +1,79 @@
+package realize
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestService_Unit(t *testing.T) {
+	s := Service{Name: "realize-app", Dir: "/srv/my app", Bin: "/usr/bin/realize", Args: []string{"start", "--supervisor", "--name", "a$b"}, User: "dev", Env: []string{"PATH=/usr/bin"}}
+	unit := s.Unit(false)
+	for _, line := range []string{"WorkingDirectory=/srv/my app\n", "ExecStart=/usr/bin/realize start --supervisor --name a$$b\n", "Environment=PATH=/usr/bin\n", "KillMode=mixed\n", "WantedBy=default.target\n"} {
+		if !strings.Contains(unit, line) {
+			t.Error("Unexpected unit", line, unit)
+		}
+	}
+	if strings.Contains(unit, "User=") {
+		t.Error("Unexpected user in a user unit", unit)
+	}
+	unit = s.Unit(true)
+	if !strings.Contains(unit, "User=dev\n") || !strings.Contains(unit, "WantedBy=multi-user.target\n") {
+		t.Error("Unexpected system unit", unit)
+	}
+	if unitQuote(`a "b"`) != `"a \"b\""` || unitQuote("ab") != "ab" || specifiers("50%") != "50%%" {
+		t.Error("Unexpected quoting")
+	}
+}
+
+func TestService_Winsw(t *testing.T) {
+	s := Service{Name: "realize-app", Dir: `C:\my app`, Bin: `C:\bin\realize.exe`, Args: []string{"start", "--supervisor", "--name", `a "b" & c`}, Env: []string{"PATH=C:\\Go\\bin"}}
+	config := s.Winsw()
+	for _, line := range []string{"<id>realize-app</id>\n", "<executable>C:\\bin\\realize.exe</executable>\n", `<arguments>start --supervisor --name &#34;a \&#34;b\&#34; &amp; c&#34;</arguments>`, "<workingdirectory>C:\\my app</workingdirectory>\n", `<env name="PATH" value="C:\Go\bin"/>`, `<onfailure action="restart"`} {
+		if !strings.Contains(config, line) {
+			t.Error("Unexpected config", line, config)
+		}
+	}
+	if winQuote(`C:\a b\`) != `"C:\a b\\"` || winQuote("ab") != "ab" || winQuote("") != `""` {
+		t.Error("Unexpected quoting")
+	}
+}
+
+func TestNewService(t *testing.T) {
+	s, err := NewService("/srv/app", "", []string{"--tag", "api"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, _ := filepath.Abs("/srv/app")
+	if s.Name != "realize-app" || s.Dir != dir || strings.Join(s.Args, " ") != "start --supervisor --tag api" {
+		t.Error("Unexpected service", s)
+	}
+	if s, _ = NewService("/srv/app", "web.service", nil); s.Name != "web" {
+		t.Error("Unexpected name", s.Name)
+	}
+	if s, _ = NewService("/srv/app", "web.xml", nil); s.Name != "web" {
+		t.Error("Unexpected name", s.Name)
+	}
+}
+
+func TestSupervise(t *testing.T) {
+	output := Output
+	defer func() { Output, journal = output, false }()
+	var buf bytes.Buffer
+	Output = &buf
+	log.SetOutput(LogWriter{})
+	log.SetFlags(0)
+	defer log.SetFlags(log.LstdFlags)
+	defer log.SetOutput(os.Stderr)
+	Supervise()
+	r := Realize{Sync: make(chan string, 10)}
+	r.Projects = append(r.Projects, Project{parent: &r})
+	r.Projects[0].stamp("error", BufferOut{Time: time.Now(), Text: "failed"}, "failed\n exit status 1\n", "line 1\nline 2")
+	if buf.String() != "<3>failed\n<3> exit status 1\n<3>line 1\n<3>line 2\n" {
+		t.Errorf("Unexpected output %q", buf.String())
+	}
+}
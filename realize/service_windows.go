@@ -0,0 +1,26 @@
+// +build windows
+
+package realize
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Extension of the winsw configs
+const serviceExt = ".xml"
+
+// Dir of the winsw configs, the windows services are system ones
+func serviceDir(system bool) (string, error) {
+	dir := os.Getenv("ProgramData")
+	if dir == "" {
+		return "", errors.New("ProgramData isn't set")
+	}
+	return filepath.Join(dir, RPrefix), nil
+}
+
+// Config of the service, its winsw config
+func (s Service) Config(system bool) string {
+	return s.Winsw()
+}
@@ -1,6 +1,7 @@
 package realize
 
 import (
+	"errors"
 	"gopkg.in/yaml.v2"
 	"io/ioutil"
 	"log"
@@ -16,6 +17,20 @@ const (
 	FileOut    = ".r.outputs.log"
 	FileErr    = ".r.errors.log"
 	FileLog    = ".r.logs.log"
+	// Files of a daemon
+	FileSock   = ".r.sock"
+	FilePid    = ".r.pid"
+	FileDaemon = ".r.daemon.log"
+	// File of the runs history
+	FileHistory = ".r.history.jsonl"
+	// File of the recorded file events
+	FileEvents = ".r.events.jsonl"
+	// File of the indexed files of the last successful runs
+	FileIndex = ".r.index.json"
+	// Folder of the cached outputs of the commands
+	FileCache = ".r.cache"
+	// Timeout of the commands run on exit
+	Timeout = 10 * time.Second
 )
 
 // random string preference
@@ -32,6 +47,28 @@ type Settings struct {
 	FileLimit int32    `yaml:"flimit,omitempty" json:"flimit,omitempty"`
 	Legacy    Legacy   `yaml:"legacy" json:"legacy"`
 	Recovery  Recovery `yaml:"recovery,omitempty" json:"recovery,omitempty"`
+	// Shutdown is the time budget of the commands run on exit
+	Shutdown time.Duration `yaml:"shutdown,omitempty" json:"shutdown,omitempty"`
+	// MaxRuntime stops the projects after it, as on exit
+	MaxRuntime time.Duration `yaml:"max_runtime,omitempty" json:"max_runtime,omitempty"`
+	// Summary is the path of the json file written on exit
+	Summary string `yaml:"summary,omitempty" json:"summary,omitempty"`
+	// ExitOnError stops realize at the first failure, its exit code is propagated
+	ExitOnError bool `yaml:"exit_on_error,omitempty" json:"exit_on_error,omitempty"`
+	// Verbosity is the lowest level printed: debug, info, warn or error
+	Verbosity string `yaml:"verbosity,omitempty" json:"verbosity,omitempty"`
+	// Control is the address of the control api, a unix socket path or host:port
+	Control string `yaml:"control,omitempty" json:"control,omitempty"`
+	// Hyperlinks links the file positions of the diagnostics in the terminals supporting them
+	Hyperlinks bool `yaml:"hyperlinks,omitempty" json:"hyperlinks,omitempty"`
+	// StatusLine shows the state of the projects in the terminal title
+	StatusLine bool `yaml:"status_line,omitempty" json:"status_line,omitempty"`
+	// History stores each run in the history file, listed by the history command
+	History bool `yaml:"history,omitempty" json:"history,omitempty"`
+	// Index stores the indexed files after each successful run, a start without changes skips the first build
+	Index bool `yaml:"index,omitempty" json:"index,omitempty"`
+	// included is set when the config includes other configs, it isn't rewritten
+	included bool
 }
 
 type Recovery struct {
@@ -61,6 +98,14 @@ type Resource struct {
 	Name   string
 }
 
+// Timeout returns the time budget of the commands run on exit
+func (s *Settings) timeout() time.Duration {
+	if s.Shutdown > 0 {
+		return s.Shutdown
+	}
+	return Timeout
+}
+
 // Set legacy watcher with an interval
 func (l *Legacy) Set(status bool, interval int) {
 	l.Force = true
@@ -76,22 +121,37 @@ func (s *Settings) Remove(d string) error {
 	return err
 }
 
-// Read config file
+// Read config file, the included configs are merged
 func (s *Settings) Read(out interface{}) error {
 	// backward compatibility
 	if _, err := os.Stat(RFile); err != nil {
 		return err
 	}
 	content, err := s.Stream(RFile)
-	if err == nil {
-		err = yaml.Unmarshal(content, out)
+	if err != nil {
 		return err
 	}
-	return err
+	config, n, err := include(RFile, content, map[string]bool{})
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		if content, err = yaml.Marshal(config); err != nil {
+			return err
+		}
+	}
+	if err = yaml.Unmarshal(content, out); err != nil {
+		return err
+	}
+	s.included = n > 0
+	return nil
 }
 
 // Write config file
 func (s *Settings) Write(out interface{}) error {
+	if s.included {
+		return errors.New(RFile + " includes other configs, edit it by hand")
+	}
 	y, err := yaml.Marshal(out)
 	if err != nil {
 		return err
@@ -0,0 +1,226 @@
+package realize
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+type (
+	// registry of the fs-event watcher shared by the projects, a path is watched once
+	// and its events are sent to each project watching it
+	registry struct {
+		mu      sync.Mutex
+		watcher FileWatcher
+		paths   map[string]int
+		subs    map[*sharedWatcher]bool
+	}
+
+	// sharedWatcher is the FileWatcher of a project on the registry
+	sharedWatcher struct {
+		parent *registry
+		paths  map[string]bool
+		events chan fsnotify.Event
+		errors chan error
+		done   chan struct{}
+		// events queued by the dispatch, a busy project doesn't block the others
+		mu     sync.Mutex
+		queue  []fsnotify.Event
+		queued chan struct{}
+		// err of the last walk
+		err error
+	}
+)
+
+// Watcher of a project, the fs-event one is shared when started by realize
+func (r *Realize) watcher() (FileWatcher, error) {
+	if r.shared == nil || r.Settings.Legacy.Force {
+		return NewFileWatcher(r.Settings.Legacy)
+	}
+	return r.shared.subscribe(r.Settings.Legacy)
+}
+
+// Subscribe a project, the kernel watcher is created by the first one, the poller is the fallback
+func (g *registry) subscribe(legacy Legacy) (FileWatcher, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.watcher == nil {
+		w, err := EventWatcher()
+		if err != nil {
+			// without inotify each project polls its own files
+			return NewFileWatcher(legacy)
+		}
+		g.watcher, g.paths, g.subs = w, make(map[string]int), make(map[*sharedWatcher]bool)
+		go g.dispatch(w)
+	}
+	s := &sharedWatcher{
+		parent: g,
+		paths:  make(map[string]bool),
+		events: make(chan fsnotify.Event, 64),
+		errors: make(chan error, 1),
+		done:   make(chan struct{}),
+		queued: make(chan struct{}, 1),
+	}
+	g.subs[s] = true
+	go s.deliver()
+	return s, nil
+}
+
+// Dispatch the events to the projects watching their path or its dir, the errors to all of them
+func (g *registry) dispatch(w FileWatcher) {
+	for {
+		select {
+		case e, ok := <-w.Events():
+			if !ok {
+				return
+			}
+			for _, s := range g.subscribers(e.Name, filepath.Dir(e.Name)) {
+				s.enqueue(e)
+			}
+		case err, ok := <-w.Errors():
+			if !ok {
+				return
+			}
+			for _, s := range g.subscribers() {
+				select {
+				case s.errors <- err:
+				case <-s.done:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// Enqueue an event for the project, it never blocks
+func (s *sharedWatcher) enqueue(e fsnotify.Event) {
+	s.mu.Lock()
+	s.queue = append(s.queue, e)
+	s.mu.Unlock()
+	select {
+	case s.queued <- struct{}{}:
+	default:
+	}
+}
+
+// Deliver the queued events in order until the project unsubscribes
+func (s *sharedWatcher) deliver() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.queued:
+		}
+		s.mu.Lock()
+		queue := s.queue
+		s.queue = nil
+		s.mu.Unlock()
+		for _, e := range queue {
+			select {
+			case s.events <- e:
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
+
+// Subscribers watching one of the paths, all of them without paths
+func (g *registry) subscribers(paths ...string) (list []*sharedWatcher) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for s := range g.subs {
+		matched := len(paths) == 0
+		for _, path := range paths {
+			matched = matched || s.paths[path]
+		}
+		if matched {
+			list = append(list, s)
+		}
+	}
+	return
+}
+
+// Add a path, the kernel watch is added only if no other project watches it
+func (s *sharedWatcher) Add(path string) error {
+	g := s.parent
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if s.paths[path] {
+		return nil
+	}
+	if g.paths[path] == 0 {
+		if err := g.watcher.Add(path); err != nil {
+			return err
+		}
+	}
+	g.paths[path]++
+	s.paths[path] = true
+	return nil
+}
+
+// Remove a path, the kernel watch is removed with the last project watching it
+func (s *sharedWatcher) Remove(path string) error {
+	g := s.parent
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return s.remove(path)
+}
+
+func (s *sharedWatcher) remove(path string) error {
+	g := s.parent
+	if !s.paths[path] {
+		return errNoSuchWatch
+	}
+	delete(s.paths, path)
+	if g.paths[path]--; g.paths[path] > 0 {
+		return nil
+	}
+	delete(g.paths, path)
+	return g.watcher.Remove(path)
+}
+
+// Walk adds a path, as the fsnotify watcher
+func (s *sharedWatcher) Walk(path string, init bool) string {
+	if s.err = s.Add(path); s.err != nil {
+		return ""
+	}
+	return path
+}
+
+// Error of the last walk
+func (s *sharedWatcher) walked() error {
+	return s.err
+}
+
+// Close unsubscribes the project, the kernel watcher is closed with the last one
+func (s *sharedWatcher) Close() error {
+	g := s.parent
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.subs[s] {
+		return nil
+	}
+	for path := range s.paths {
+		s.remove(path)
+	}
+	delete(g.subs, s)
+	close(s.done)
+	if len(g.subs) > 0 {
+		return nil
+	}
+	w := g.watcher
+	g.watcher = nil
+	return w.Close()
+}
+
+// Events of the paths of the project
+func (s *sharedWatcher) Events() <-chan fsnotify.Event {
+	return s.events
+}
+
+// Errors of the shared watcher
+func (s *sharedWatcher) Errors() <-chan error {
+	return s.errors
+}
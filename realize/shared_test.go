@@ -0,0 +1,88 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Subscribe(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	sub := filepath.Join(dir, "sub")
+	os.Mkdir(sub, 0755)
+	g := &registry{}
+	a, err := g.subscribe(Legacy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := a.(*sharedWatcher); !ok {
+		t.Skip("no fs-event watcher")
+	}
+	b, _ := g.subscribe(Legacy{})
+	a.Walk(dir, false)
+	a.Walk(sub, false)
+	b.Walk(sub, false)
+	if len(g.paths) != 2 || g.paths[sub] != 2 {
+		t.Error("Unexpected watched paths", g.paths)
+	}
+	// both get the events of the shared path, only one the other ones
+	if err := ioutil.WriteFile(filepath.Join(sub, "a.go"), []byte("package a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []FileWatcher{a, b} {
+		if names := received(w); !names[filepath.Join(sub, "a.go")] {
+			t.Error("Expected an event of a.go", names)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "b.go"), []byte("package b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if names := received(a); !names[filepath.Join(dir, "b.go")] {
+		t.Error("Expected an event of b.go", names)
+	}
+	if names := received(b); len(names) > 0 {
+		t.Error("Unexpected events", names)
+	}
+	// a project not reading its events doesn't block the other one
+	for i := 0; i < 100; i++ {
+		ioutil.WriteFile(filepath.Join(sub, "a.go"), []byte("package a"), 0644)
+	}
+	ioutil.WriteFile(filepath.Join(dir, "c.go"), []byte("package c"), 0644)
+	if names := received(a); !names[filepath.Join(dir, "c.go")] {
+		t.Error("Expected an event of c.go", names)
+	}
+	received(b)
+	// the kernel watch is kept until the last project removes it
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.paths) != 1 || g.paths[sub] != 1 || g.watcher == nil {
+		t.Error("Unexpected watched paths", g.paths)
+	}
+	if err := b.Remove(sub); err != nil || len(g.paths) != 0 {
+		t.Error("Unexpected watched paths", g.paths, err)
+	}
+	b.Close()
+	if g.watcher != nil {
+		t.Error("Expected the watcher closed")
+	}
+}
+
+// Names of the events received until none comes for a while
+func received(w FileWatcher) map[string]bool {
+	names := make(map[string]bool)
+	for {
+		select {
+		case e := <-w.Events():
+			names[e.Name] = true
+		case <-time.After(200 * time.Millisecond):
+			return names
+		}
+	}
+}
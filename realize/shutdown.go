@@ -0,0 +1,100 @@
+package realize
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reaper of the processes of a project, the shutdown waits them
+type reaper struct {
+	wg sync.WaitGroup
+}
+
+// Hold a process until it's reaped, the release is called once its wait returns
+func (p *Project) hold() func() {
+	if p == nil || p.reaper == nil {
+		return func() {}
+	}
+	p.reaper.wg.Add(1)
+	var once sync.Once
+	return func() { once.Do(p.reaper.wg.Done) }
+}
+
+// Wait the processes held, it reports if they're all reaped within the timeout
+func (r *reaper) wait(timeout time.Duration) bool {
+	if r == nil {
+		return true
+	}
+	done := make(chan bool)
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Reap the processes of the project on exit, the ones still running after the shutdown timeout are reported
+func (p *Project) reap() {
+	if !p.reaper.wait(p.parent.Settings.timeout()) {
+		p.Err(errors.New("processes still running after the shutdown timeout"))
+	}
+}
+
+// Exited sets the reason of the exit, the signal received or stopped
+func (p *Project) exited(sig os.Signal, ok bool) {
+	p.reason = "stopped"
+	if ok {
+		p.reason = sig.String()
+	}
+}
+
+// Expired is sent to a project when its max runtime elapses
+type expired struct{}
+
+// Signal implements os.Signal
+func (expired) Signal() {}
+
+// String implements os.Signal
+func (expired) String() string {
+	return "max runtime"
+}
+
+// MaxRuntime of a project before it's stopped, the shortest of its own and the global one, 0 without limit
+func (p *Project) maxRuntime() time.Duration {
+	d, global := p.MaxRuntime, p.parent.Settings.MaxRuntime
+	if d <= 0 || global > 0 && global < d {
+		d = global
+	}
+	return d
+}
+
+// Expire stops each project once its max runtime elapses, the after commands are run as on exit
+func (r *Realize) expire() (stop func()) {
+	var timers []*time.Timer
+	for k := range r.Schema.Projects {
+		p := &r.Schema.Projects[k]
+		d := p.maxRuntime()
+		if d <= 0 {
+			continue
+		}
+		exit := p.exit
+		timers = append(timers, time.AfterFunc(d, func() {
+			select {
+			case exit <- expired{}:
+			default:
+			}
+		}))
+	}
+	return func() {
+		for _, t := range timers {
+			t.Stop()
+		}
+	}
+}
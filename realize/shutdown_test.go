@@ -0,0 +1,97 @@
+package realize
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReaper(t *testing.T) {
+	var p *Project
+	p.hold()()
+	p = &Project{reaper: &reaper{}}
+	release := p.hold()
+	if p.reaper.wait(50 * time.Millisecond) {
+		t.Error("Unexpected reaped")
+	}
+	release()
+	release()
+	if !p.reaper.wait(time.Second) {
+		t.Error("Unexpected not reaped")
+	}
+}
+
+func TestCommand_Reaped(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sleep not available")
+	}
+	p := &Project{parent: &Realize{}, reaper: &reaper{}}
+	c := Command{Cmd: "sleep 5", parent: p}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan Response)
+	go func() { done <- c.exec(ctx, ".") }()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+	// the canceled command is reaped in background
+	if !p.reaper.wait(2 * time.Second) {
+		t.Error("Unexpected not reaped")
+	}
+}
+
+func TestProject_Exited(t *testing.T) {
+	p := Project{}
+	p.exited(nil, false)
+	if p.reason != "stopped" {
+		t.Error("Unexpected reason", p.reason)
+	}
+	for _, sig := range []os.Signal{syscall.SIGTERM, errSignal{}} {
+		p.exited(sig, true)
+		if p.reason != sig.String() {
+			t.Error("Unexpected reason", p.reason)
+		}
+	}
+}
+
+func TestProject_MaxRuntime(t *testing.T) {
+	r := Realize{}
+	cases := []struct {
+		own, global, expected time.Duration
+	}{
+		{0, 0, 0},
+		{time.Hour, 0, time.Hour},
+		{0, 8 * time.Hour, 8 * time.Hour},
+		{time.Hour, 8 * time.Hour, time.Hour},
+		{8 * time.Hour, time.Hour, time.Hour},
+	}
+	for _, c := range cases {
+		r.Settings.MaxRuntime = c.global
+		p := Project{parent: &r, MaxRuntime: c.own}
+		if d := p.maxRuntime(); d != c.expected {
+			t.Error("Unexpected max runtime", c.own, c.global, d)
+		}
+	}
+}
+
+func TestRealize_Expire(t *testing.T) {
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{Name: "a", parent: &r, MaxRuntime: 20 * time.Millisecond, exit: make(chan os.Signal, 1)}, Project{Name: "b", parent: &r, exit: make(chan os.Signal, 1)})
+	stop := r.expire()
+	defer stop()
+	select {
+	case sig := <-r.Projects[0].exit:
+		if sig.String() != "max runtime" {
+			t.Error("Unexpected signal", sig)
+		}
+	case <-time.After(time.Second):
+		t.Error("Expected an expired project")
+	}
+	select {
+	case sig := <-r.Projects[1].exit:
+		t.Error("Unexpected signal", sig)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
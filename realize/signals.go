@@ -0,0 +1,152 @@
+package realize
+
+import (
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+)
+
+// Forwarder relays the signals received by realize to the running commands
+type forwarder struct {
+	mu       sync.Mutex
+	procs    map[*os.Process][]os.Signal
+	listened map[os.Signal]bool
+	c        chan os.Signal
+}
+
+// Signals parse a list of signal names, unknown names are skipped
+func parseSignals(names []string) (list []os.Signal) {
+	for _, name := range names {
+		name = strings.ToUpper(name)
+		if !strings.HasPrefix(name, "SIG") {
+			name = "SIG" + name
+		}
+		if s, ok := signals[name]; ok {
+			list = append(list, s)
+		}
+	}
+	return
+}
+
+// Start forwarding, a signal is listened only once a command forwards it
+func (f *forwarder) start() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.c != nil {
+		return
+	}
+	c := make(chan os.Signal, 1)
+	f.c, f.listened = c, make(map[os.Signal]bool)
+	go func() {
+		for s := range c {
+			f.forward(s)
+		}
+	}()
+}
+
+// Stop listening
+func (f *forwarder) stop() {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.c == nil {
+		return
+	}
+	signal.Stop(f.c)
+	close(f.c)
+	f.c, f.listened = nil, nil
+}
+
+// Add a process with the signals that must be forwarded to it
+func (f *forwarder) add(proc *os.Process, names []string) {
+	list := parseSignals(names)
+	if f == nil || proc == nil || len(list) == 0 {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.procs == nil {
+		f.procs = make(map[*os.Process][]os.Signal)
+	}
+	f.procs[proc] = list
+	if f.c == nil {
+		return
+	}
+	for _, s := range list {
+		if !f.listened[s] {
+			f.listened[s] = true
+			signal.Notify(f.c, s)
+		}
+	}
+}
+
+// Remove a process
+func (f *forwarder) remove(proc *os.Process) {
+	if f == nil || proc == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.procs, proc)
+}
+
+// Forward a signal to every process registered for it
+func (f *forwarder) forward(s os.Signal) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for proc, list := range f.procs {
+		for _, v := range list {
+			if v == s {
+				proc.Signal(s)
+				break
+			}
+		}
+	}
+}
+
+// Toggle pauses the watching of all the projects, it resumes them when all are paused
+func (r *Realize) toggle() {
+	r.pause(!r.paused())
+}
+
+// Toggled by the pause signal
+func (r *Realize) toggled() (stop func()) {
+	if pauseSignal == nil {
+		return func() {}
+	}
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, pauseSignal)
+	go func() {
+		for range c {
+			r.toggle()
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(c)
+	}
+}
+
+// Paused reports if the watching of all the projects is paused
+func (r *Realize) paused() bool {
+	for _, p := range r.Schema.Projects {
+		if paused, _, _, _ := p.watching(); !paused {
+			return false
+		}
+	}
+	return len(r.Schema.Projects) > 0
+}
+
+// Pause or resume the watching of all the projects, the running commands go on
+func (r *Realize) pause(paused bool) {
+	action := ActionResume
+	if paused {
+		action = ActionPause
+	}
+	for k := range r.Schema.Projects {
+		r.Schema.Projects[k].send(action)
+	}
+}
@@ -0,0 +1,18 @@
+// +build !windows
+
+package realize
+
+import (
+	"os"
+	"syscall"
+)
+
+// signals that can be forwarded to the running commands, the pause signal isn't
+var signals = map[string]os.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGUSR2": syscall.SIGUSR2,
+	"SIGTERM": syscall.SIGTERM,
+}
+
+// signal that pauses the watching of all the projects, the next one resumes it
+var pauseSignal os.Signal = syscall.SIGUSR1
@@ -0,0 +1,93 @@
+// +build !windows
+
+package realize
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseSignals(t *testing.T) {
+	list := parseSignals([]string{"hup", "SIGUSR2", "unknown", "SIGUSR1"})
+	if len(list) != 2 {
+		t.Fatal("Expected 2 signals instead", len(list))
+	}
+	if list[0] != syscall.SIGHUP || list[1] != syscall.SIGUSR2 {
+		t.Error("Unexpected signals", list)
+	}
+}
+
+func TestForwarder_Forward(t *testing.T) {
+	f := forwarder{}
+	cmd := exec.Command("sleep", "10")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- cmd.Wait() }()
+	f.add(cmd.Process, []string{"SIGUSR2"})
+	f.forward(syscall.SIGHUP)
+	select {
+	case <-done:
+		t.Fatal("Unexpected signal forwarded")
+	case <-time.After(100 * time.Millisecond):
+	}
+	f.forward(syscall.SIGUSR2)
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Expected the process to be killed by the signal")
+		}
+	case <-time.After(time.Second):
+		cmd.Process.Kill()
+		t.Error("Signal not forwarded")
+	}
+	f.remove(cmd.Process)
+	if len(f.procs) != 0 {
+		t.Error("Unexpected process", f.procs)
+	}
+}
+
+func TestForwarder_Listened(t *testing.T) {
+	f := forwarder{}
+	f.start()
+	defer f.stop()
+	f.add(&os.Process{Pid: -1}, []string{"SIGUSR2"})
+	if len(f.listened) != 1 || !f.listened[syscall.SIGUSR2] {
+		t.Error("Unexpected listened signals", f.listened)
+	}
+	f.stop()
+	f.stop()
+	if f.c != nil || f.listened != nil {
+		t.Error("Unexpected listening after stop")
+	}
+}
+
+func TestRealize_Toggle(t *testing.T) {
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{Name: "a", control: make(chan string, 1), paused: true}, Project{Name: "b", control: make(chan string, 1)})
+	stop := r.toggled()
+	defer stop()
+	// a project still watching pauses all of them
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	for _, p := range r.Projects {
+		select {
+		case action := <-p.control:
+			if action != ActionPause {
+				t.Error("Unexpected action", action)
+			}
+		case <-time.After(time.Second):
+			t.Error("Expected a pause", p.Name)
+		}
+	}
+	r.Projects[1].paused = true
+	if !r.paused() {
+		t.Error("Expected all the projects paused")
+	}
+	if (&Realize{}).paused() {
+		t.Error("Unexpected paused without projects")
+	}
+}
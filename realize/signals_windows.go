@@ -0,0 +1,11 @@
+// +build windows
+
+package realize
+
+import "os"
+
+// signals that can be forwarded to the running commands, windows doesn't support them
+var signals = map[string]os.Signal{}
+
+// signal that pauses the watching of all the projects, not supported on windows
+var pauseSignal os.Signal
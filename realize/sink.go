@@ -0,0 +1,149 @@
+package realize
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Sink receives the messages of a project, the terminal, the log files and the web server are sinks too.
+// Write is called synchronously, slow sinks should work in background
+type Sink interface {
+	Write(m Message) error
+}
+
+// Message stamped by a project
+type Message struct {
+	Project string    `json:"project"`
+	Type    string    `json:"type"`
+	Level   Level     `json:"level"`
+	Out     BufferOut `json:"out"`
+	Text    string    `json:"-"`
+	Stream  string    `json:"stream,omitempty"`
+	// retained in the buffer, not written on the sinks
+	retained bool
+}
+
+// Sinks of a project defined in the config
+type Sinks struct {
+	JSON     []JSONSink `yaml:"json,omitempty" json:"json,omitempty"`
+	Webhooks []Webhook  `yaml:"webhooks,omitempty" json:"webhooks,omitempty"`
+}
+
+// JSONSink appends the messages as json lines to a file, relative to the project path
+type JSONSink struct {
+	Path string `yaml:"path" json:"path"`
+}
+
+// Close the sinks of the config once their queued messages are posted
+func (s *Sinks) close() {
+	for i := range s.Webhooks {
+		s.Webhooks[i].close()
+	}
+}
+
+// Terminal prints the messages at or above the verbosity
+type terminalSink struct {
+	settings *Settings
+}
+
+// Files writes the messages in the log files enabled in the settings
+type fileSink struct {
+	project *Project
+}
+
+// Sync notifies the web server
+type syncSink struct {
+	parent *Realize
+}
+
+// AddSink attaches a sink to the project
+func (p *Project) AddSink(s Sink) {
+	p.sinks = append(p.sinks, s)
+}
+
+// Outputs of the project, the default sinks first
+func (p *Project) outputs() []Sink {
+	sinks := []Sink{terminalSink{&p.parent.Settings}, fileSink{p}, syncSink{p.parent}}
+	for _, s := range p.Sinks.JSON {
+		if !filepath.IsAbs(s.Path) {
+			s.Path = filepath.Join(p.Path, s.Path)
+		}
+		sinks = append(sinks, s)
+	}
+	for i := range p.Sinks.Webhooks {
+		sinks = append(sinks, &p.Sinks.Webhooks[i])
+	}
+	return append(sinks, p.sinks...)
+}
+
+// Write a message on the terminal
+func (s terminalSink) Write(m Message) error {
+	if !s.settings.verbose(m.Level) {
+		return nil
+	}
+	var priority string
+	if journal {
+		priority = priorities[m.Level]
+	}
+	if m.Text != "" {
+		log.Print(prioritize(m.Text, priority))
+	}
+	if m.Stream != "" {
+		fmt.Fprintln(Output, prioritize(m.Stream, priority))
+	}
+	return nil
+}
+
+// Write a message in the log file of its type
+func (s fileSink) Write(m Message) error {
+	var r Resource
+	files := s.project.parent.Settings.Files
+	switch m.Type {
+	case "out":
+		r = files.Outputs
+	case "debug", "log":
+		r = files.Logs
+	case "warn", "error":
+		r = files.Errors
+	}
+	if !r.Status {
+		return nil
+	}
+	content := []string{time.Now().Format("2006-01-02 15:04:05"), strings.ToUpper(m.Project), ":", m.Out.Text, "\r\n", m.Stream}
+	f, err := os.OpenFile(filepath.Join(s.project.Path, r.Name), os.O_APPEND|os.O_WRONLY|os.O_CREATE|os.O_SYNC, Permission)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(strings.Join(content, " "))
+	return err
+}
+
+// Write notifies the web server, pending notifications are merged
+func (s syncSink) Write(m Message) error {
+	select {
+	case s.parent.Sync <- "sync":
+	default:
+	}
+	return nil
+}
+
+// Write a message as a json line
+func (s JSONSink) Write(m Message) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.Path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, Permission)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
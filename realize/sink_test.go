@@ -0,0 +1,65 @@
+package realize
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type mockSink struct {
+	messages []Message
+}
+
+func (m *mockSink) Write(msg Message) error {
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+func TestProject_AddSink(t *testing.T) {
+	r := Realize{Sync: make(chan string, 10)}
+	r.Projects = append(r.Projects, Project{Name: "test", parent: &r})
+	sink := &mockSink{}
+	r.Projects[0].AddSink(sink)
+	r.Projects[0].stamp("warn", BufferOut{Time: time.Now(), Text: "text"}, "", "stream")
+	if len(sink.messages) != 1 {
+		t.Fatal("Unexpected messages", sink.messages)
+	}
+	m := sink.messages[0]
+	if m.Project != "test" || m.Level != LevelWarn || m.Out.Text != "text" || m.Stream != "stream" {
+		t.Error("Unexpected message", m)
+	}
+}
+
+func TestJSONSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r := Realize{Sync: make(chan string, 10)}
+	r.Projects = append(r.Projects, Project{Name: "test", Path: dir, parent: &r})
+	r.Projects[0].Sinks.JSON = []JSONSink{{Path: "out.jsonl"}}
+	r.Projects[0].stamp("log", BufferOut{Time: time.Now(), Text: "first"}, "", "")
+	r.Projects[0].stamp("error", BufferOut{Time: time.Now(), Text: "second"}, "", "")
+	f, err := os.Open(filepath.Join(dir, "out.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var lines []map[string]interface{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 || lines[1]["level"] != "error" || lines[1]["project"] != "test" {
+		t.Error("Unexpected lines", lines)
+	}
+}
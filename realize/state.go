@@ -0,0 +1,141 @@
+package realize
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// States of a project, pending before it starts: indexing its files, idle without a program up, building a run,
+// running its program, failed by the last run or the program, stopped on exit
+const (
+	StatePending  = "pending"
+	StateIndexing = "indexing"
+	StateIdle     = "idle"
+	StateBuilding = "building"
+	StateRunning  = "running"
+	StateFailed   = "failed"
+	StateStopped  = "stopped"
+)
+
+// Transitions of the states, the states each one is entered from, stopped is final
+var transitions = map[string][]string{
+	StateIndexing: {StatePending},
+	StateBuilding: {StatePending, StateIndexing, StateIdle, StateRunning, StateFailed},
+	StateRunning:  {StateBuilding, StateIdle, StateFailed},
+	StateIdle:     {StateIndexing, StateBuilding, StateRunning, StateFailed},
+	StateFailed:   {StateIndexing, StateBuilding, StateRunning, StateIdle},
+	StateStopped:  {StatePending, StateIndexing, StateIdle, StateBuilding, StateRunning, StateFailed},
+}
+
+// Lifecycle of a project, its state and since when
+type lifecycle struct {
+	mu    sync.Mutex
+	state string
+	since time.Time
+}
+
+// Records of a project written by its tasks and the broker: the buffer, the task results and the status.
+// The terminal ui, the control api and the summary read them from other goroutines
+type records struct {
+	mu sync.Mutex
+}
+
+// Lock the records of the project, the returned func unlocks them
+func (p *Project) locked() func() {
+	if p.records == nil {
+		return func() {}
+	}
+	p.records.mu.Lock()
+	return p.records.mu.Unlock
+}
+
+// Current state and since when, pending without a lifecycle
+func (l *lifecycle) current() (string, time.Time) {
+	if l == nil {
+		return StatePending, time.Time{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.state == "" {
+		return StatePending, l.since
+	}
+	return l.state, l.since
+}
+
+// Enter a state if the transition is allowed and the current state is one of from, if any
+func (l *lifecycle) enter(state string, from ...string) bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	current := l.state
+	if current == "" {
+		current = StatePending
+	}
+	if current == state || !contains(transitions[state], current) || len(from) > 0 && !contains(from, current) {
+		return false
+	}
+	l.state, l.since = state, time.Now()
+	return true
+}
+
+// Transition of the project to a state, optionally only from some states.
+// The state is emitted as an event, published to the sinks and shown on the status line.
+func (p *Project) transition(state string, from ...string) {
+	if !p.lifecycle.enter(state, from...) {
+		return
+	}
+	p.emit(Event{Event: EventState, Status: state})
+	p.publish(p.message("state", BufferOut{Time: time.Now(), Text: state}, "", ""))
+	p.parent.line.refresh(p.parent)
+}
+
+// Current state of the project
+func (p *Project) current() string {
+	state, _ := p.lifecycle.current()
+	return state
+}
+
+// Status line of the projects states in the terminal title
+type statusLine struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// StatusLine on stdout if it's a terminal
+func newStatusLine() *statusLine {
+	if _, _, err := termSize(os.Stdout.Fd()); err != nil {
+		return nil
+	}
+	return &statusLine{out: os.Stdout}
+}
+
+// Refresh the status line with the state of each project
+func (s *statusLine) refresh(r *Realize) {
+	if s == nil {
+		return
+	}
+	var states []string
+	for k := range r.Schema.Projects {
+		p := &r.Schema.Projects[k]
+		states = append(states, p.Name+" "+p.current())
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprint(s.out, "\x1b]0;"+RPrefix+": "+strings.Join(states, " · ")+"\x07")
+}
+
+// Clear the status line on exit
+func (s *statusLine) clear() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprint(s.out, "\x1b]0;\x07")
+}
@@ -0,0 +1,79 @@
+package realize
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLifecycle_Enter(t *testing.T) {
+	var l *lifecycle
+	if state, _ := l.current(); state != StatePending || l.enter(StateIndexing) {
+		t.Error("Unexpected nil lifecycle", state)
+	}
+	l = &lifecycle{}
+	steps := []struct {
+		state string
+		from  []string
+		ok    bool
+	}{
+		{StateRunning, nil, false},
+		{StateIndexing, nil, true},
+		{StateRunning, nil, false},
+		{StateBuilding, nil, true},
+		{StateBuilding, nil, false},
+		{StateRunning, nil, true},
+		{StateIdle, []string{StateBuilding}, false},
+		{StateFailed, nil, true},
+		{StateBuilding, nil, true},
+		{StateIdle, []string{StateBuilding}, true},
+		{StateStopped, nil, true},
+		{StateBuilding, nil, false},
+	}
+	for _, s := range steps {
+		if ok := l.enter(s.state, s.from...); ok != s.ok {
+			t.Error("Unexpected transition to", s.state, s.from, ok)
+		}
+	}
+	if state, since := l.current(); state != StateStopped || since.IsZero() {
+		t.Error("Unexpected state", state, since)
+	}
+}
+
+func TestProject_Transition(t *testing.T) {
+	var events bytes.Buffer
+	r := Realize{Sync: make(chan string, 10)}
+	r.EventStream(&events)
+	var title bytes.Buffer
+	r.line = &statusLine{out: &title}
+	r.Projects = append(r.Projects, Project{Name: "app", parent: &r, lifecycle: &lifecycle{}}, Project{Name: "api"})
+	p := &r.Projects[0]
+	sink := &mockSink{}
+	p.AddSink(sink)
+	p.transition(StateIndexing)
+	p.transition(StateBuilding)
+	p.transition(StateBuilding)
+	p.transition(StateIdle, StateRunning)
+	var states []string
+	for _, line := range strings.Split(strings.TrimSpace(events.String()), "\n") {
+		var e Event
+		json.Unmarshal([]byte(line), &e)
+		if e.Event == EventState && e.Project == "app" {
+			states = append(states, e.Status)
+		}
+	}
+	if expected := []string{StateIndexing, StateBuilding}; !reflect.DeepEqual(states, expected) {
+		t.Error("Unexpected events", states)
+	}
+	if len(sink.messages) != 2 || sink.messages[1].Type != "state" || sink.messages[1].Out.Text != StateBuilding || sink.messages[1].Level != LevelInfo {
+		t.Error("Unexpected messages", sink.messages)
+	}
+	if !strings.HasSuffix(title.String(), "\x1b]0;"+RPrefix+": app building · api pending\x07") {
+		t.Error("Unexpected status line", title.String())
+	}
+	if s := p.state(); s.State != StateBuilding || s.Since.IsZero() {
+		t.Error("Unexpected state", s)
+	}
+}
@@ -0,0 +1,86 @@
+package realize
+
+import (
+	"math"
+	"sort"
+)
+
+// TaskTotal is the name of the whole run in the statistics
+const TaskTotal = "total"
+
+// Durations of each task kept in the session, the statistics are of the last ones
+const statsWindow = 1000
+
+// TaskStats are the durations statistics of a task, in seconds
+type TaskStats struct {
+	Name    string  `json:"name"`
+	Runs    int     `json:"runs"`
+	Average float64 `json:"average"`
+	P95     float64 `json:"p95"`
+	Last    float64 `json:"last"`
+}
+
+// Timing of a task in the current run and in the session
+func (p *Project) timing(name string, duration float64) {
+	if duration <= 0 {
+		return
+	}
+	if p.timings == nil {
+		p.timings = make(map[string]float64)
+	}
+	if p.durations == nil {
+		p.durations = make(map[string][]float64)
+	}
+	p.timings[name] += duration
+	p.durations[name] = append(p.durations[name], duration)
+	if values := p.durations[name]; len(values) > statsWindow {
+		p.durations[name] = values[len(values)-statsWindow:]
+	}
+}
+
+// Statistics of the durations of each task, sorted by name
+func statistics(durations map[string][]float64) []TaskStats {
+	list := make([]TaskStats, 0, len(durations))
+	for name, values := range durations {
+		if len(values) == 0 {
+			continue
+		}
+		s := TaskStats{Name: name, Runs: len(values), Last: values[len(values)-1]}
+		sorted := append([]float64{}, values...)
+		sort.Float64s(sorted)
+		var sum float64
+		for _, v := range sorted {
+			sum += v
+		}
+		s.Average = sum / float64(len(sorted))
+		// nearest rank
+		s.P95 = sorted[int(math.Ceil(0.95*float64(len(sorted))))-1]
+		list = append(list, s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Stats of the runs of a history, by project
+func Stats(runs []Run) map[string][]TaskStats {
+	durations := make(map[string]map[string][]float64)
+	for _, run := range runs {
+		if run.Status == StatusCanceled {
+			continue
+		}
+		d, ok := durations[run.Project]
+		if !ok {
+			d = make(map[string][]float64)
+			durations[run.Project] = d
+		}
+		d[TaskTotal] = append(d[TaskTotal], run.Duration)
+		for name, v := range run.Tasks {
+			d[name] = append(d[name], v)
+		}
+	}
+	stats := make(map[string][]TaskStats, len(durations))
+	for project, d := range durations {
+		stats[project] = statistics(d)
+	}
+	return stats
+}
@@ -0,0 +1,63 @@
+package realize
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatistics(t *testing.T) {
+	values := []float64{}
+	for i := 1; i <= 20; i++ {
+		values = append(values, float64(i))
+	}
+	list := statistics(map[string][]float64{"Build": values, "Install": {2}, "Vet": {}})
+	if len(list) != 2 || list[0].Name != "Build" {
+		t.Fatal("Unexpected stats", list)
+	}
+	if s := list[0]; s.Runs != 20 || s.Average != 10.5 || s.P95 != 19 || s.Last != 20 {
+		t.Error("Unexpected stats", s)
+	}
+	if s := list[1]; s.Average != 2 || s.P95 != 2 {
+		t.Error("Unexpected stats", s)
+	}
+}
+
+func TestStats(t *testing.T) {
+	runs := []Run{
+		{Project: "a", Duration: 2, Status: StatusSuccess, Tasks: map[string]float64{"Build": 1}},
+		{Project: "a", Duration: 4, Status: StatusFailure, Tasks: map[string]float64{"Build": 3}},
+		{Project: "a", Duration: 9, Status: StatusCanceled},
+		{Project: "b", Duration: 1, Status: StatusSuccess},
+	}
+	stats := Stats(runs)
+	if len(stats) != 2 || len(stats["a"]) != 2 || len(stats["b"]) != 1 {
+		t.Fatal("Unexpected stats", stats)
+	}
+	if s := stats["a"][1]; s.Name != TaskTotal || s.Average != 3 || s.Runs != 2 {
+		t.Error("Unexpected stats", s)
+	}
+}
+
+func TestProject_Timing(t *testing.T) {
+	r := Realize{}
+	p := Project{parent: &r, Name: "a"}
+	p.record(Response{Name: "Build"}, time.Now().Add(-time.Second))
+	p.record(Response{Name: "Verify"}, time.Time{})
+	if len(p.timings) != 1 || p.timings["Build"] < 1 {
+		t.Error("Unexpected timings", p.timings)
+	}
+	if s := r.Summary(""); len(s.Projects) != 0 {
+		t.Error("Unexpected projects", s.Projects)
+	}
+	// only the last durations are kept
+	for i := 0; i < statsWindow+10; i++ {
+		p.timing("Test", float64(i+1))
+	}
+	if values := p.durations["Test"]; len(values) != statsWindow || values[0] != 11 {
+		t.Error("Unexpected durations", len(values), values[0])
+	}
+	r.Schema.Projects = append(r.Schema.Projects, p)
+	if s := r.Summary(""); len(s.Projects[0].Stats) != 2 {
+		t.Error("Expected stats", s.Projects[0].Stats)
+	}
+}
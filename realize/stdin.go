@@ -0,0 +1,57 @@
+package realize
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// Pump copies realize stdin to the pseudo-terminal of the current run, a single reader for all the runs of a project
+type pump struct {
+	mu     sync.Mutex
+	once   sync.Once
+	target io.Writer
+}
+
+// Attach the pseudo-terminal of a run, the stdin is copied to it until detached or another run attaches
+func (s *pump) attach(w io.Writer) (detach func()) {
+	s.once.Do(func() { go s.copy(os.Stdin) })
+	s.mu.Lock()
+	s.target = w
+	s.mu.Unlock()
+	return func() {
+		s.mu.Lock()
+		if s.target == w {
+			s.target = nil
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Copy the input to the attached target, the input without a target is dropped
+func (s *pump) copy(r io.Reader) {
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			s.mu.Lock()
+			target := s.target
+			s.mu.Unlock()
+			if target != nil {
+				target.Write(buf[:n])
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Stdin of the runs of a project to a pseudo-terminal, a copy for each one without a pump
+func (p *Project) stdin(master io.Writer) (detach func()) {
+	if p == nil || p.pump == nil {
+		go io.Copy(master, os.Stdin)
+		return func() {}
+	}
+	return p.pump.attach(master)
+}
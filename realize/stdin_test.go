@@ -0,0 +1,43 @@
+package realize
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+type chanWriter chan string
+
+func (c chanWriter) Write(b []byte) (int, error) {
+	c <- string(b)
+	return len(b), nil
+}
+
+func TestPump(t *testing.T) {
+	r, w := io.Pipe()
+	defer w.Close()
+	s := &pump{}
+	s.once.Do(func() { go s.copy(r) })
+	// the input goes to the last run attached
+	first, second := make(chanWriter, 1), make(chanWriter, 1)
+	s.attach(first)
+	detach := s.attach(second)
+	w.Write([]byte("a"))
+	select {
+	case v := <-second:
+		if v != "a" {
+			t.Error("Unexpected input", v)
+		}
+	case v := <-first:
+		t.Error("Unexpected input of a previous run", v)
+	case <-time.After(time.Second):
+		t.Error("Expected an input")
+	}
+	detach()
+	w.Write([]byte("b"))
+	select {
+	case v := <-second:
+		t.Error("Unexpected input of a detached run", v)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -1,6 +1,10 @@
 package realize
 
 import (
+	"io"
+	"os"
+	"regexp"
+
 	"github.com/fatih/color"
 )
 
@@ -19,9 +23,48 @@ var (
 	Magenta = colorBase(color.FgHiMagenta)
 )
 
+// Escape sequences, csi and osc
+var escapes = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(\x07|\x1b\\)`)
+
 // ColorBase type
 type colorBase color.Attribute
 
+// PlainWriter removes the escape sequences
+type plainWriter struct {
+	w io.Writer
+}
+
+// NO_COLOR, a dumb terminal or a pipe disable the colors
+func init() {
+	if os.Getenv("NO_COLOR") != "" || color.NoColor {
+		Plain()
+	}
+}
+
+// Plain disables the colors, the escape sequences of the commands output are removed too
+func Plain() {
+	color.NoColor = true
+	if _, ok := Output.(plainWriter); !ok {
+		Output = plainWriter{w: Output}
+	}
+}
+
+// Stderr moves the output on stderr, stdout is left to the events
+func Stderr() {
+	Output = color.Error
+	if color.NoColor {
+		Output = plainWriter{w: Output}
+	}
+}
+
+// Write without escape sequences
+func (p plainWriter) Write(b []byte) (int, error) {
+	if _, err := p.w.Write(escapes.ReplaceAll(b, nil)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
 // Regular font with a color
 func (c colorBase) Regular(a ...interface{}) string {
 	return color.New(color.Attribute(c)).Sprint(a...)
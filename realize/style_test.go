@@ -34,3 +34,36 @@ func TestStyle_Bold(t *testing.T) {
 		t.Error("Expected:", expected, "instead", result)
 	}
 }
+
+func TestPlainWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := plainWriter{w: &buf}
+	input := "\x1b[91mred\x1b[0m \x1b]0;title\x07text\x1b[2K"
+	n, err := w.Write([]byte(input))
+	if err != nil || n != len(input) {
+		t.Error("Unexpected error", err, n)
+	}
+	if buf.String() != "red text" {
+		t.Error("Expected: red text instead", buf.String())
+	}
+}
+
+func TestPlain(t *testing.T) {
+	output, nocolor := Output, color.NoColor
+	defer func() {
+		Output, color.NoColor = output, nocolor
+	}()
+	Plain()
+	Plain()
+	if !color.NoColor {
+		t.Error("Expected no colors")
+	}
+	if w, ok := Output.(plainWriter); !ok {
+		t.Error("Expected a plain writer")
+	} else if _, ok := w.w.(plainWriter); ok {
+		t.Error("Unexpected nested plain writer")
+	}
+	if Red.Bold("text") != "text" {
+		t.Error("Unexpected color", Red.Bold("text"))
+	}
+}
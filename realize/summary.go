@@ -0,0 +1,98 @@
+package realize
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+)
+
+type (
+	// Summary is written on exit, it describes what happened during the session
+	Summary struct {
+		Reason   string           `json:"reason"`
+		Start    time.Time        `json:"start"`
+		End      time.Time        `json:"end"`
+		Projects []ProjectSummary `json:"projects"`
+	}
+
+	// ProjectSummary is the last known state of a project
+	ProjectSummary struct {
+		Name   string       `json:"name"`
+		Status string       `json:"status,omitempty"`
+		Tasks  []TaskResult `json:"tasks"`
+		Exit   []TaskResult `json:"exit"`
+		// Stats are the durations of the tasks in the session, the last ones of each task
+		Stats []TaskStats `json:"stats"`
+	}
+
+	// TaskResult is the last result of a task
+	TaskResult struct {
+		Name     string    `json:"name"`
+		Status   string    `json:"status"`
+		Error    string    `json:"error,omitempty"`
+		Time     time.Time `json:"time"`
+		Duration float64   `json:"duration"`
+		// Diagnostics are the positioned errors of the task
+		Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+	}
+)
+
+// NewTaskResult converts a response in a task result
+func newTaskResult(r Response, start time.Time) TaskResult {
+	t := TaskResult{Name: r.Name, Status: StatusSuccess, Time: time.Now()}
+	if !start.IsZero() {
+		t.Duration = time.Since(start).Seconds()
+	}
+	if r.Err != nil {
+		t.Status = StatusFailure
+		t.Error = r.Err.Error()
+		t.Diagnostics = r.Diagnostics
+	}
+	return t
+}
+
+// Record the last result of a task
+func (p *Project) record(r Response, start time.Time) {
+	p.results.send(r)
+	p.finished(r, start)
+	t := newTaskResult(r, start)
+	defer p.locked()()
+	p.timing(t.Name, t.Duration)
+	for i := range p.tasks {
+		if p.tasks[i].Name == t.Name {
+			p.tasks[i] = t
+			return
+		}
+	}
+	p.tasks = append(p.tasks, t)
+}
+
+// Summary of the current session
+func (r *Realize) Summary(reason string) Summary {
+	s := Summary{Reason: reason, Start: r.started, End: time.Now(), Projects: []ProjectSummary{}}
+	for _, p := range r.Schema.Projects {
+		unlock := p.locked()
+		ps := ProjectSummary{Name: p.Name, Status: p.status, Tasks: append([]TaskResult(nil), p.tasks...), Exit: []TaskResult{}, Stats: statistics(p.durations)}
+		unlock()
+		if ps.Tasks == nil {
+			ps.Tasks = []TaskResult{}
+		}
+		for _, res := range p.shutdown {
+			ps.Exit = append(ps.Exit, newTaskResult(res, time.Time{}))
+		}
+		s.Projects = append(s.Projects, ps)
+	}
+	return s
+}
+
+// Report writes the session summary in the configured file
+func (r *Realize) report(reason string) error {
+	if r.Settings.Summary == "" {
+		return nil
+	}
+	content, err := json.MarshalIndent(r.Summary(reason), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.Settings.Summary, content, Permission)
+}
@@ -0,0 +1,53 @@
+package realize
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestProject_Record(t *testing.T) {
+	p := Project{}
+	p.record(Response{Name: "Build"}, time.Now())
+	p.record(Response{Name: "Install"}, time.Now())
+	p.record(Response{Name: "Build", Err: errors.New("error")}, time.Now())
+	if len(p.tasks) != 2 {
+		t.Fatal("Expected 2 tasks instead", len(p.tasks))
+	}
+	if p.tasks[0].Status != StatusFailure || p.tasks[0].Error != "error" {
+		t.Error("Unexpected result", p.tasks[0])
+	}
+}
+
+func TestRealize_Report(t *testing.T) {
+	f, err := ioutil.TempFile("", "summary_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	r := Realize{}
+	r.Settings.Summary = f.Name()
+	r.Projects = append(r.Projects, Project{Name: "test", status: StatusSuccess, shutdown: []Response{{Name: "echo"}}})
+	r.Projects[0].record(Response{Name: "Build"}, time.Now())
+	if err := r.report("interrupt"); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Summary{}
+	if err := json.Unmarshal(content, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Reason != "interrupt" || len(s.Projects) != 1 {
+		t.Fatal("Unexpected summary", s)
+	}
+	if s.Projects[0].Status != StatusSuccess || len(s.Projects[0].Tasks) != 1 || len(s.Projects[0].Exit) != 1 {
+		t.Error("Unexpected project summary", s.Projects[0])
+	}
+}
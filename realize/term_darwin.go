@@ -0,0 +1,11 @@
+// +build darwin
+
+package realize
+
+import "syscall"
+
+// Requests of the terminal attributes
+const (
+	tcget = syscall.TIOCGETA
+	tcset = syscall.TIOCSETA
+)
@@ -0,0 +1,11 @@
+// +build linux
+
+package realize
+
+import "syscall"
+
+// Requests of the terminal attributes
+const (
+	tcget = syscall.TCGETS
+	tcset = syscall.TCSETS
+)
@@ -0,0 +1,23 @@
+// +build !linux,!darwin
+
+package realize
+
+import (
+	"errors"
+	"runtime"
+)
+
+// makeRaw isn't supported on this platform
+func makeRaw(fd uintptr) (func(), error) {
+	return nil, errors.New("raw terminal isn't supported on " + runtime.GOOS)
+}
+
+// makeCbreak isn't supported on this platform
+func makeCbreak(fd uintptr) (func(), error) {
+	return nil, errors.New("cbreak terminal isn't supported on " + runtime.GOOS)
+}
+
+// termSize isn't supported on this platform
+func termSize(fd uintptr) (int, int, error) {
+	return 0, 0, errors.New("terminal size isn't supported on " + runtime.GOOS)
+}
@@ -0,0 +1,66 @@
+// +build linux darwin
+
+package realize
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// makeRaw puts the terminal in raw mode and returns a func to restore it
+func makeRaw(fd uintptr) (func(), error) {
+	var old syscall.Termios
+	if err := ioctl(fd, tcget, uintptr(unsafe.Pointer(&old))); err != nil {
+		return nil, err
+	}
+	raw := old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(fd, tcset, uintptr(unsafe.Pointer(&raw))); err != nil {
+		return nil, err
+	}
+	return func() {
+		ioctl(fd, tcset, uintptr(unsafe.Pointer(&old)))
+	}, nil
+}
+
+// makeCbreak disables the line buffering and the echo of the terminal, the signals keys still work
+func makeCbreak(fd uintptr) (func(), error) {
+	var old syscall.Termios
+	if err := ioctl(fd, tcget, uintptr(unsafe.Pointer(&old))); err != nil {
+		return nil, err
+	}
+	cbreak := old
+	cbreak.Lflag &^= syscall.ECHO | syscall.ICANON
+	cbreak.Cc[syscall.VMIN] = 1
+	cbreak.Cc[syscall.VTIME] = 0
+	if err := ioctl(fd, tcset, uintptr(unsafe.Pointer(&cbreak))); err != nil {
+		return nil, err
+	}
+	return func() {
+		ioctl(fd, tcset, uintptr(unsafe.Pointer(&old)))
+	}, nil
+}
+
+// termSize returns the columns and rows of the terminal
+func termSize(fd uintptr) (int, int, error) {
+	var ws struct {
+		Row, Col, X, Y uint16
+	}
+	if err := ioctl(fd, syscall.TIOCGWINSZ, uintptr(unsafe.Pointer(&ws))); err != nil {
+		return 0, 0, err
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
+
+func ioctl(fd, cmd, ptr uintptr) error {
+	_, _, e := syscall.Syscall(syscall.SYS_IOCTL, fd, cmd, ptr)
+	if e != 0 {
+		return e
+	}
+	return nil
+}
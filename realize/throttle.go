@@ -0,0 +1,174 @@
+package realize
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Longest line scanned without a max, the rest is truncated
+const lineMax = bufio.MaxScanTokenSize - 1
+
+// Throttle of the output lines of a command, the lines longer than max_line are truncated
+// and the lines over max_rate per second suppressed, a marker counts them
+type Throttle struct {
+	MaxLine int `yaml:"max_line,omitempty" json:"max_line,omitempty"`
+	MaxRate int `yaml:"max_rate,omitempty" json:"max_rate,omitempty"`
+}
+
+// Max length of a line
+func (t *Throttle) maxLine() int {
+	if t != nil && t.MaxLine > 0 {
+		return t.MaxLine
+	}
+	return lineMax
+}
+
+// Scanner of the lines of an output, a longer line is truncated instead of stopping the scan
+func (t *Throttle) scanner(r io.Reader) *bufio.Scanner {
+	max := t.maxLine()
+	size := 4096
+	if size > max+1 {
+		size = max + 1
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, size), max+1)
+	scanner.Split(truncated(max))
+	return scanner
+}
+
+// Throttler of the lines of a command, shared by its outputs
+func (t *Throttle) throttler() *throttler {
+	if t == nil {
+		return &throttler{}
+	}
+	return &throttler{rate: t.MaxRate}
+}
+
+// Writers of the throttled lines of a command, the flush waits them and writes the marker of the last lines suppressed
+func (t *Throttle) writers(out, errs io.Writer) (io.Writer, io.Writer, func()) {
+	th := t.throttler()
+	var wg sync.WaitGroup
+	pipe := func(w io.Writer) *io.PipeWriter {
+		r, pw := io.Pipe()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scanner := t.scanner(r)
+			for scanner.Scan() {
+				for _, line := range th.pass(scanner.Text(), time.Now()) {
+					fmt.Fprintln(w, line)
+				}
+			}
+			// the command doesn't block on a failed scan
+			io.Copy(ioutil.Discard, r)
+		}()
+		return pw
+	}
+	o, e := pipe(out), pipe(errs)
+	return o, e, func() {
+		o.Close()
+		e.Close()
+		wg.Wait()
+		if m := th.marker(); m != "" {
+			fmt.Fprintln(out, m)
+		}
+	}
+}
+
+// Truncated lines of at most max bytes, the rest of a longer line is dropped
+func truncated(max int) bufio.SplitFunc {
+	dropping := false
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 && atEOF && len(data) > 0 {
+			i = len(data)
+		}
+		switch {
+		case i >= 0 && dropping:
+			dropping = false
+			return advance(i, len(data)), nil, nil
+		case i >= 0:
+			return advance(i, len(data)), cut(bytes.TrimSuffix(data[:i], []byte("\r")), max), nil
+		case dropping:
+			return len(data), nil, nil
+		case len(data) > max:
+			dropping = true
+			return len(data), cut(data, max), nil
+		}
+		return 0, nil, nil
+	}
+}
+
+// Cut a line longer than max on a rune, an ellipsis marks it
+func cut(line []byte, max int) []byte {
+	if len(line) <= max {
+		return line
+	}
+	n := max
+	for n > 0 && !utf8.RuneStart(line[n]) {
+		n--
+	}
+	return append(append([]byte{}, line[:n]...), "…"...)
+}
+
+// Advance past a line and its newline
+func advance(i, n int) int {
+	if i < n {
+		return i + 1
+	}
+	return n
+}
+
+// Throttler counts the lines of the current second, the ones over the rate are suppressed
+type throttler struct {
+	mu         sync.Mutex
+	rate       int
+	second     time.Time
+	lines      int
+	suppressed int
+}
+
+// Pass a line, the lines to output: none when suppressed, after the marker of the previous second
+func (t *throttler) pass(line string, now time.Time) []string {
+	if t.rate <= 0 {
+		return []string{line}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var out []string
+	if now.Sub(t.second) >= time.Second {
+		if m := t.mark(); m != "" {
+			out = append(out, m)
+		}
+		t.second, t.lines = now, 0
+	}
+	if t.lines >= t.rate {
+		t.suppressed++
+		return out
+	}
+	t.lines++
+	return append(out, line)
+}
+
+// Marker of the lines suppressed, empty without
+func (t *throttler) marker() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.mark()
+}
+
+// Mark the suppressed lines and reset their count
+func (t *throttler) mark() string {
+	if t.suppressed == 0 {
+		return ""
+	}
+	m := fmt.Sprintf("…suppressed %d lines", t.suppressed)
+	t.suppressed = 0
+	return m
+}
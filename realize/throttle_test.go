@@ -0,0 +1,68 @@
+package realize
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThrottle_Scanner(t *testing.T) {
+	input := "short\r\n0123456789abcdef\nèèèèèèèè\n\nlast"
+	scanner := (&Throttle{MaxLine: 10}).scanner(strings.NewReader(input))
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	expected := []string{"short", "0123456789…", "èèèèè…", "", "last"}
+	if scanner.Err() != nil || !reflect.DeepEqual(lines, expected) {
+		t.Error("Unexpected lines", lines, scanner.Err())
+	}
+	// a line longer than the default buffer doesn't stop the scan
+	var throttle *Throttle
+	scanner = throttle.scanner(strings.NewReader(strings.Repeat("a", 100000) + "\nnext\n"))
+	lines = nil
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if scanner.Err() != nil || len(lines) != 2 || len(lines[0]) != lineMax+len("…") || lines[1] != "next" {
+		t.Error("Unexpected lines", len(lines), scanner.Err())
+	}
+}
+
+func TestThrottler(t *testing.T) {
+	th := (&Throttle{MaxRate: 2}).throttler()
+	now := time.Now()
+	var lines []string
+	for i, line := range []string{"a", "b", "c", "d"} {
+		lines = append(lines, th.pass(line, now.Add(time.Duration(i)*time.Millisecond))...)
+	}
+	lines = append(lines, th.pass("e", now.Add(time.Second))...)
+	if expected := []string{"a", "b", "…suppressed 2 lines", "e"}; !reflect.DeepEqual(lines, expected) {
+		t.Error("Unexpected lines", lines)
+	}
+	if m := th.marker(); m != "" {
+		t.Error("Unexpected marker", m)
+	}
+	var throttle *Throttle
+	if lines := throttle.throttler().pass("a", now); !reflect.DeepEqual(lines, []string{"a"}) {
+		t.Error("Unexpected lines", lines)
+	}
+}
+
+func TestCommand_Throttle(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sh not available")
+	}
+	c := Command{Cmd: `sh -c 'i=0; while [ $i -lt 100 ]; do echo line$i; i=$((i+1)); done'`, Throttle: &Throttle{MaxRate: 10, MaxLine: 5}}
+	r := c.exec(context.Background(), ".")
+	if r.Err != nil {
+		t.Fatal("Unexpected error", r.Err)
+	}
+	lines := strings.Split(strings.TrimSpace(r.Out), "\n")
+	if len(lines) != 11 || lines[0] != "line0" || lines[9] != "line9" || lines[10] != "…suppressed 90 lines" {
+		t.Error("Unexpected output", lines)
+	}
+}
@@ -2,43 +2,139 @@ package realize
 
 import (
 	"bytes"
+	"context"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"time"
 )
 
 // Tool info
 type Tool struct {
-	Args   []string `yaml:"args,omitempty" json:"args,omitempty"`
-	Method string   `yaml:"method,omitempty" json:"method,omitempty"`
-	Path   string   `yaml:"path,omitempty" json:"path,omitempty"`
-	Dir    string   `yaml:"dir,omitempty" json:"dir,omitempty"` //wdir of the command
-	Status bool     `yaml:"status,omitempty" json:"status,omitempty"`
-	Output bool     `yaml:"output,omitempty" json:"output,omitempty"`
-	dir    bool
-	isTool bool
-	method []string
-	cmd    []string
-	name   string
-	parent *Project
+	Args     []string     `yaml:"args,omitempty" json:"args,omitempty"`
+	Method   string       `yaml:"method,omitempty" json:"method,omitempty"`
+	Path     string       `yaml:"path,omitempty" json:"path,omitempty"`
+	Dir      string       `yaml:"dir,omitempty" json:"dir,omitempty"` //wdir of the command
+	Status   bool         `yaml:"status,omitempty" json:"status,omitempty"`
+	Output   bool         `yaml:"output,omitempty" json:"output,omitempty"`
+	Managed  bool         `yaml:"managed,omitempty" json:"managed,omitempty"`         //run only, swap a temp binary on reload
+	Signals  []string     `yaml:"signals,omitempty" json:"signals,omitempty"`         //run only, signals forwarded to the project
+	Stdin    bool         `yaml:"stdin,omitempty" json:"stdin,omitempty"`             //run only, connect realize stdin
+	Pty      bool         `yaml:"pty,omitempty" json:"pty,omitempty"`                 //run only, run in a pseudo-terminal
+	Health   *Healthcheck `yaml:"healthcheck,omitempty" json:"healthcheck,omitempty"` //run only, polled before the project is running
+	Command  string       `yaml:"command,omitempty" json:"command,omitempty"`         //run only, a command line started instead of the binary
+	Restart  string       `yaml:"restart,omitempty" json:"restart,omitempty"`         //run only, on-failure restarts it with a backoff
+	Restarts int          `yaml:"restarts,omitempty" json:"restarts,omitempty"`       //run only, restarts in a row before giving up
+	Rules    []Highlight  `yaml:"highlight,omitempty" json:"highlight,omitempty"`     //run only, the matching lines are colored and their level changed
+	Flags    []string     `yaml:"flags,omitempty" json:"flags,omitempty"`             //go builds only, go build flags
+	Ldflags  string       `yaml:"ldflags,omitempty" json:"ldflags,omitempty"`         //go builds only, template of the build vars
+	Tags     []string     `yaml:"tags,omitempty" json:"tags,omitempty"`               //go builds only, build tags
+	Cover    bool         `yaml:"cover,omitempty" json:"cover,omitempty"`             //test only, coverage profile and html report
+	Scope    string       `yaml:"scope,omitempty" json:"scope,omitempty"`             //lint only, changed dirs or all packages
+	Affected bool         `yaml:"affected,omitempty" json:"affected,omitempty"`       //test only, the changed package and its importers
+	Targets  []string     `yaml:"targets,omitempty" json:"targets,omitempty"`         //cross only, goos/goarch built in parallel
+	User     string       `yaml:"user,omitempty" json:"user,omitempty"`               //run as the user, a name or an id
+	Group    string       `yaml:"group,omitempty" json:"group,omitempty"`             //run as the group, the user one by default
+	Strategy string       `yaml:"strategy,omitempty" json:"strategy,omitempty"`       //managed run only, stop or blue-green
+	Ports    []int        `yaml:"ports,omitempty" json:"ports,omitempty"`             //blue-green only, the ports of the binaries in turn
+	Throttle *Throttle    `yaml:"throttle,omitempty" json:"throttle,omitempty"`       //run only, max line length and lines per second
+	dir      bool
+	env      []string
+	isTool   bool
+	lint     bool
+	method   []string
+	cmd      []string
+	name     string
+	extra    []string
+	parent   *Project
 }
 
 // Tools go
 type Tools struct {
 	Clean    Tool `yaml:"clean,omitempty" json:"clean,omitempty"`
 	Vet      Tool `yaml:"vet,omitempty" json:"vet,omitempty"`
+	Lint     Tool `yaml:"lint,omitempty" json:"lint,omitempty"`
 	Fmt      Tool `yaml:"fmt,omitempty" json:"fmt,omitempty"`
 	Test     Tool `yaml:"test,omitempty" json:"test,omitempty"`
 	Generate Tool `yaml:"generate,omitempty" json:"generate,omitempty"`
 	Install  Tool `yaml:"install,omitempty" json:"install,omitempty"`
 	Build    Tool `yaml:"build,omitempty" json:"build,omitempty"`
+	Wasm     Tool `yaml:"wasm,omitempty" json:"wasm,omitempty"`
+	Cross    Tool `yaml:"cross,omitempty" json:"cross,omitempty"`
 	Run      Tool `yaml:"run,omitempty" json:"run,omitempty"`
 	vgo      bool
 }
 
+// All the tools
+func (t *Tools) all() []*Tool {
+	return []*Tool{&t.Clean, &t.Vet, &t.Lint, &t.Fmt, &t.Test, &t.Generate, &t.Install, &t.Build, &t.Wasm, &t.Cross, &t.Run}
+}
+
+// Bind the tools to their project, its dir and env apply to them
+func (t *Tools) bind(p *Project) {
+	for _, tool := range t.all() {
+		tool.parent = p
+		if old, ok := tool.migrate(); ok && p.parent != nil {
+			text := strings.ToLower(tool.name) + " dir " + old + " is resolved against the project path now, migrated to " + tool.Dir
+			msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Yellow.Regular(text))
+			out := BufferOut{Time: time.Now(), Text: text}
+			p.stamp("warn", out, msg, "")
+		}
+	}
+}
+
+// Migrate a relative dir found only against the working directory, as resolved before, to the project path.
+// The previous dir is returned
+func (t *Tool) migrate() (string, bool) {
+	if t.Dir == "" || filepath.IsAbs(t.Dir) || t.parent == nil {
+		return "", false
+	}
+	if fi, err := os.Stat(workdir(t.parent.Path, t.Dir)); err == nil && fi.IsDir() {
+		return "", false
+	}
+	if fi, err := os.Stat(t.Dir); err != nil || !fi.IsDir() {
+		return "", false
+	}
+	old, _ := filepath.Abs(t.Dir)
+	base, _ := filepath.Abs(t.parent.Path)
+	dir, err := filepath.Rel(base, old)
+	if err != nil {
+		return "", false
+	}
+	t.Dir, dir = dir, t.Dir
+	return dir, true
+}
+
+// Environ of a tool, the project env is added to the realize one
+func (t *Tool) environ() []string {
+	env := t.env
+	if t.parent != nil {
+		env = append(t.parent.buildEnvs(), env...)
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	return append(os.Environ(), env...)
+}
+
+// Workdir of a tool, a relative dir is resolved against the project path
+func (t *Tool) workdir(path string) string {
+	if t.Dir == "" {
+		return path
+	}
+	if t.parent != nil {
+		return workdir(t.parent.Path, t.Dir)
+	}
+	dir, _ := filepath.Abs(t.Dir)
+	return dir
+}
+
 // Setup go tools
 func (t *Tools) Setup() {
 	var gocmd string
@@ -81,28 +177,71 @@ func (t *Tools) Setup() {
 		t.Vet.cmd = replace([]string{gocmd, "vet"}, t.Vet.Method)
 		t.Vet.Args = split([]string{}, t.Vet.Args)
 	}
+	// golangci-lint if installed, go vet otherwise
+	if t.Lint.Status {
+		t.Lint.dir = true
+		t.Lint.lint = true
+		t.Lint.name = "Lint"
+		t.Lint.isTool = true
+		t.Lint.cmd = replace(linter(gocmd), t.Lint.Method)
+		t.Lint.Args = split([]string{}, t.Lint.Args)
+	}
 	// go test
 	if t.Test.Status {
 		t.Test.dir = true
 		t.Test.isTool = true
 		t.Test.name = "Test"
-		t.Test.cmd = replace([]string{gocmd, "test"}, t.Test.Method)
+		t.Test.cmd = t.Test.override(replace([]string{gocmd, "test"}, t.Test.Method))
 		t.Test.Args = split([]string{}, t.Test.Args)
 	}
 	// go install
 	t.Install.name = "Install"
-	t.Install.cmd = replace([]string{gocmd, "install"}, t.Install.Method)
+	t.Install.env = []string{"GOBIN=" + gobin()}
+	t.Install.cmd = t.Install.override(replace([]string{gocmd, "install"}, t.Install.Method))
 	t.Install.Args = split([]string{}, t.Install.Args)
+	// a custom run command has nothing to install or swap
+	if t.Run.Command != "" {
+		t.Run.Managed = false
+	}
+	// go run, managed by realize
+	if t.Run.Managed {
+		t.Run.name = "Run"
+		t.Run.cmd = t.Run.override([]string{gocmd, "build"})
+		t.Run.Args = split([]string{}, t.Build.Args)
+		// the build flags too, unless the run has its own
+		if len(t.Run.Flags) == 0 && t.Run.Ldflags == "" && len(t.Run.Tags) == 0 {
+			t.Run.Flags, t.Run.Ldflags, t.Run.Tags = t.Build.Flags, t.Build.Ldflags, t.Build.Tags
+		}
+	}
 	// go build
 	if t.Build.Status {
 		t.Build.name = "Build"
-		t.Build.cmd = replace([]string{gocmd, "build"}, t.Build.Method)
+		t.Build.cmd = t.Build.override(replace([]string{gocmd, "build"}, t.Build.Method))
 		t.Build.Args = split([]string{}, t.Build.Args)
 	}
+	// go build for webassembly
+	if t.Wasm.Status {
+		if t.Wasm.Path == "" {
+			t.Wasm.Path = "main.wasm"
+		}
+		t.Wasm.name = "Wasm"
+		t.Wasm.env = []string{"GOOS=js", "GOARCH=wasm"}
+		t.Wasm.cmd = replace([]string{gocmd, "build", "-o", t.Wasm.Path}, t.Wasm.Method)
+		t.Wasm.Args = split([]string{}, t.Wasm.Args)
+	}
+	// go build for each target, the artifacts are discarded
+	if t.Cross.Status {
+		if len(t.Cross.Targets) == 0 {
+			t.Cross.Targets = []string{"linux/amd64", "darwin/amd64", "windows/amd64"}
+		}
+		t.Cross.name = "Cross"
+		t.Cross.cmd = replace([]string{gocmd, "build"}, t.Cross.Method)
+		t.Cross.Args = split([]string{}, t.Cross.Args)
+	}
 }
 
 // Exec a go tool
-func (t *Tool) Exec(path string, stop <-chan bool) (response Response) {
+func (t *Tool) Exec(ctx context.Context, path string) (response Response) {
 	if t.dir {
 		if filepath.Ext(path) != "" {
 			path = filepath.Dir(path)
@@ -122,6 +261,12 @@ func (t *Tool) Exec(path string, stop <-chan bool) (response Response) {
 	} else if !strings.HasSuffix(path, ".go") {
 		return
 	}
+	if t.Affected {
+		return t.affected(ctx, path)
+	}
+	if t.lint {
+		return t.lintDir(ctx, path)
+	}
 	args := t.Args
 	if strings.HasSuffix(path, ".go") {
 		args = append(args, path)
@@ -131,39 +276,52 @@ func (t *Tool) Exec(path string, stop <-chan bool) (response Response) {
 		if t.parent.parent.Settings.Recovery.Tools {
 			log.Println("Tool:", t.name, path, args)
 		}
-		var out, stderr bytes.Buffer
-		done := make(chan error)
-		args = append(t.cmd, args...)
-		cmd := exec.Command(args[0], args[1:]...)
-		if t.Dir != "" {
-			cmd.Dir, _ = filepath.Abs(t.Dir)
-		} else {
-			cmd.Dir = path
-		}
-		cmd.Stdout = &out
-		cmd.Stderr = &stderr
-		// Start command
-		err := cmd.Start()
+		return t.cover(ctx, t.workdir(path), args)
+	}
+	return
+}
+
+// Exec the tool command with the given args in a dir
+func (t *Tool) exec(ctx context.Context, dir string, params []string) (response Response) {
+	var out, stderr bytes.Buffer
+	done := make(chan error, 1)
+	args := append(append([]string{}, t.cmd...), params...)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Env = t.environ()
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := runAs(cmd, t.User, t.Group); err != nil {
+		response.Name = t.name
+		response.Err = err
+		return
+	}
+	// Start command
+	err := cmd.Start()
+	if err != nil {
+		response.Name = t.name
+		response.Err = err
+		return
+	}
+	release := t.parent.hold()
+	go func() {
+		done <- cmd.Wait()
+		release()
+	}()
+	// Wait a result
+	select {
+	case <-ctx.Done():
+		// Stop running command
+		cmd.Process.Kill()
+	case err := <-done:
+		// Command completed
+		response.Name = t.name
 		if err != nil {
-			response.Name = t.name
-			response.Err = err
-			return
-		}
-		go func() { done <- cmd.Wait() }()
-		// Wait a result
-		select {
-		case <-stop:
-			// Stop running command
-			cmd.Process.Kill()
-		case err := <-done:
-			// Command completed
-			response.Name = t.name
-			if err != nil {
-				response.Err = errors.New(stderr.String() + out.String() + err.Error())
-			} else {
-				if t.Output {
-					response.Out = out.String()
-				}
+			response.Err = errors.New(stderr.String() + out.String() + err.Error())
+			response.Code = exitCode(err)
+		} else {
+			if t.Output {
+				response.Out = out.String()
 			}
 		}
 	}
@@ -171,32 +329,59 @@ func (t *Tool) Exec(path string, stop <-chan bool) (response Response) {
 }
 
 // Compile is used for build and install
-func (t *Tool) Compile(path string, stop <-chan bool) (response Response) {
+func (t *Tool) Compile(ctx context.Context, path string) (response Response) {
+	return t.compile(ctx, path, t.Args)
+}
+
+// Swap builds the project into a new temp binary, used by the managed run mode
+func (t *Tool) Swap(ctx context.Context, path string) (string, Response) {
+	bin := filepath.Join(os.TempDir(), fmt.Sprintf("%s-%d", RPrefix, time.Now().UnixNano()))
+	if runtime.GOOS == "windows" {
+		bin += RExtWin
+	}
+	args := append([]string{"-o", bin}, t.Args...)
+	return bin, t.compile(ctx, path, args)
+}
+
+// Compile a go command with the given args
+func (t *Tool) compile(ctx context.Context, path string, params []string) (response Response) {
 	var out bytes.Buffer
 	var stderr bytes.Buffer
-	done := make(chan error)
-	args := append(t.cmd, t.Args...)
-	cmd := exec.Command(args[0], args[1:]...)
-	if t.Dir != "" {
-		cmd.Dir, _ = filepath.Abs(t.Dir)
-	} else {
-		cmd.Dir = path
+	done := make(chan error, 1)
+	response.Name = t.name
+	// flags, tags and ldflags before the params, they may end with the packages
+	flags, err := t.buildFlags(t.workdir(path))
+	if err != nil {
+		response.Err = err
+		return
 	}
+	args := append(append(append([]string{}, t.cmd...), flags...), params...)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Dir = t.workdir(path)
+	cmd.Env = t.environ()
 	cmd.Stdout = &out
 	cmd.Stderr = &stderr
+	if err := runAs(cmd, t.User, t.Group); err != nil {
+		response.Err = err
+		return
+	}
 	// Start command
 	cmd.Start()
-	go func() { done <- cmd.Wait() }()
+	release := t.parent.hold()
+	go func() {
+		done <- cmd.Wait()
+		release()
+	}()
 	// Wait a result
-	response.Name = t.name
 	select {
-	case <-stop:
+	case <-ctx.Done():
 		// Stop running command
 		cmd.Process.Kill()
 	case err := <-done:
 		// Command completed
 		if err != nil {
 			response.Err = errors.New(stderr.String() + err.Error())
+			response.Code = exitCode(err)
 		}
 	}
 	return
@@ -1,6 +1,13 @@
 package realize
 
-import "testing"
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 func TestTools_Setup(t *testing.T) {
 	tools := Tools{
@@ -23,3 +30,100 @@ func TestTools_Setup(t *testing.T) {
 		t.Error("Unexpected value")
 	}
 }
+
+func TestTool_Swap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "swap_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module swap\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n\nfunc main() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tools := Tools{Run: Tool{Status: true, Managed: true}}
+	tools.Setup()
+	bin, response := tools.Run.Swap(context.Background(), dir)
+	defer os.Remove(bin)
+	if response.Err != nil {
+		t.Fatal("Unexpected error", response.Err)
+	}
+	if _, err := os.Stat(bin); err != nil {
+		t.Error("Expected a binary in", bin)
+	}
+}
+
+func TestTools_SetupWasm(t *testing.T) {
+	tools := Tools{Wasm: Tool{Status: true}}
+	tools.Setup()
+	if tools.Wasm.Path != "main.wasm" {
+		t.Error("Unexpected value", tools.Wasm.Path)
+	}
+	if len(tools.Wasm.env) != 2 || tools.Wasm.env[0] != "GOOS=js" || tools.Wasm.env[1] != "GOARCH=wasm" {
+		t.Error("Unexpected value", tools.Wasm.env)
+	}
+}
+
+func TestTools_Override(t *testing.T) {
+	tools := Tools{
+		Build: Tool{Status: true},
+		Test:  Tool{Status: true},
+		Run:   Tool{Status: true, Managed: true},
+		Vet:   Tool{Status: true},
+	}
+	tools.Install.Method = "gb install"
+	tools.Override(true, "all=-N -l")
+	tools.Setup()
+	for _, tool := range []Tool{tools.Build, tools.Test, tools.Run} {
+		if strings.Join(tool.cmd[2:], " ") != "-race -gcflags=all=-N -l" {
+			t.Error("Unexpected cmd", tool.name, tool.cmd)
+		}
+	}
+	if len(tools.Vet.cmd) != 2 || len(tools.Install.cmd) != 2 {
+		t.Error("Unexpected cmd", tools.Vet.cmd, tools.Install.cmd)
+	}
+	// a second setup doesn't repeat them
+	tools.Setup()
+	if len(tools.Build.cmd) != 4 {
+		t.Error("Unexpected cmd", tools.Build.cmd)
+	}
+}
+
+func TestTool_Bind(t *testing.T) {
+	wd, _ := os.Getwd()
+	p := Project{Path: "sub", Env: map[string]string{"A": "1"}}
+	p.Tools.Build.Dir = "cmd"
+	p.Tools.Setup()
+	p.Tools.bind(&p)
+	if dir := p.Tools.Build.workdir("sub"); dir != filepath.Join(wd, "sub", "cmd") {
+		t.Error("Unexpected dir", dir)
+	}
+	if dir := p.Tools.Install.workdir("sub"); dir != "sub" {
+		t.Error("Unexpected dir", dir)
+	}
+	env := p.Tools.Install.environ()
+	found := map[string]bool{}
+	for _, v := range env {
+		found[v] = true
+	}
+	if !found["A=1"] || !found["GOBIN="+gobin()] || len(env) < len(os.Environ())+2 {
+		t.Error("Unexpected env", env)
+	}
+	if env := (&Tool{}).environ(); env != nil {
+		t.Error("Expected the realize env", env)
+	}
+	// a dir found only against the working directory is migrated
+	dir, err := ioutil.TempDir(".", "tool")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	p = Project{Path: "sub"}
+	p.Tools.Build.Dir = dir
+	p.Tools.bind(&p)
+	if p.Tools.Build.Dir != filepath.Join("..", dir) || p.Tools.Build.workdir("sub") != filepath.Join(wd, dir) {
+		t.Error("Unexpected migration", p.Tools.Build.Dir)
+	}
+}
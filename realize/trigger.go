@@ -0,0 +1,107 @@
+package realize
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Keys of the file event and the id of a run in its context
+type (
+	triggerKey struct{}
+	runKey     struct{}
+)
+
+// Triggered context of a run, by the file event if any
+func triggered(ctx context.Context, events ...fsnotify.Event) context.Context {
+	if len(events) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, triggerKey{}, events[len(events)-1])
+}
+
+// Cycle of a reload, its id and the count of its tasks
+type cycle struct {
+	id    string
+	tasks int32
+}
+
+// Tracker of the current run, the lines stamped outside a task belong to it
+type tracker struct {
+	mu  sync.Mutex
+	run string
+}
+
+// Random id of a run or of a task outside a run
+func randomID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// Identified context of a run, a new id for its commands
+func identified(ctx context.Context) context.Context {
+	return context.WithValue(ctx, runKey{}, &cycle{id: randomID()})
+}
+
+// RunID of the context, empty outside a run
+func runID(ctx context.Context) string {
+	if c, ok := ctx.Value(runKey{}).(*cycle); ok {
+		return c.id
+	}
+	return ""
+}
+
+// TaskID of a new task, the id of the run and its sequence number
+func taskID(ctx context.Context) string {
+	if c, ok := ctx.Value(runKey{}).(*cycle); ok {
+		return c.id + "." + strconv.Itoa(int(atomic.AddInt32(&c.tasks, 1)))
+	}
+	return randomID()
+}
+
+// RunOf a task id, empty for a task outside a run
+func runOf(id string) string {
+	if i := strings.Index(id, "."); i > 0 {
+		return id[:i]
+	}
+	return ""
+}
+
+// Begin a run
+func (t *tracker) begin(run string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.run = run
+	t.mu.Unlock()
+}
+
+// Current run, empty before the first one
+func (t *tracker) current() string {
+	if t == nil {
+		return ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.run
+}
+
+// Environ of the commands of a run: the project, the file and the op of its event, its id
+func (p *Project) runEnv(ctx context.Context) []string {
+	env := []string{"REALIZE_PROJECT=" + p.Name}
+	if e, ok := ctx.Value(triggerKey{}).(fsnotify.Event); ok {
+		env = append(env, "REALIZE_FILE="+e.Name, "REALIZE_OP="+strings.ToLower(e.Op.String()))
+	}
+	if id := runID(ctx); id != "" {
+		env = append(env, "REALIZE_RUN_ID="+id)
+	}
+	return env
+}
@@ -0,0 +1,75 @@
+package realize
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestProject_RunEnv(t *testing.T) {
+	p := Project{Name: "api"}
+	if env := p.runEnv(context.Background()); len(env) != 1 || env[0] != "REALIZE_PROJECT=api" {
+		t.Error("Unexpected env", env)
+	}
+	ctx := identified(triggered(context.Background(), fsnotify.Event{Name: "/app/api.go", Op: fsnotify.Write}))
+	env := p.runEnv(ctx)
+	if len(env) != 4 || env[1] != "REALIZE_FILE=/app/api.go" || env[2] != "REALIZE_OP=write" || len(env[3]) != len("REALIZE_RUN_ID=")+16 {
+		t.Error("Unexpected env", env)
+	}
+	if other := p.runEnv(identified(ctx)); other[3] == env[3] {
+		t.Error("Expected a new run id", other[3])
+	}
+}
+
+func TestCommand_RunEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("No sh on Windows")
+	}
+	r := Realize{}
+	p := Project{parent: &r, Name: "api"}
+	c := Command{Cmd: "echo $REALIZE_PROJECT $REALIZE_OP $REALIZE_FILE", Shell: true, parent: &p}
+	ctx := identified(triggered(context.Background(), fsnotify.Event{Name: "mock.go", Op: fsnotify.Create}))
+	if res := c.exec(ctx, os.TempDir()); strings.TrimSpace(res.Out) != "api create mock.go" {
+		t.Error("Unexpected env", res.Out, res.Err)
+	}
+}
+
+func TestTaskID(t *testing.T) {
+	ctx := identified(context.Background())
+	run := runID(ctx)
+	if first, second := taskID(ctx), taskID(ctx); first != run+".1" || second != run+".2" || runOf(first) != run {
+		t.Error("Unexpected task ids", first, second)
+	}
+	// a task outside a run has its own id
+	if id := taskID(context.Background()); len(id) != 16 || runOf(id) != "" || runID(context.Background()) != "" {
+		t.Error("Unexpected task id", id)
+	}
+}
+
+func TestProject_StampRun(t *testing.T) {
+	r := Realize{Sync: make(chan string, 10)}
+	r.Projects = append(r.Projects, Project{Name: "api", parent: &r, runs: &tracker{}})
+	p := &r.Projects[0]
+	sink := &mockSink{}
+	p.AddSink(sink)
+	old := identified(context.Background())
+	p.runs.begin(runID(old))
+	p.stamp("log", BufferOut{Text: "started"}, "", "")
+	current := identified(context.Background())
+	p.runs.begin(runID(current))
+	// a task of the previous run keeps its run
+	p.stamp("log", BufferOut{Text: "finished", ID: taskID(old)}, "", "")
+	if len(sink.messages) != 2 {
+		t.Fatal("Unexpected messages", sink.messages)
+	}
+	if m := sink.messages[0].Out; m.Run != runID(old) || m.ID != "" {
+		t.Error("Unexpected run", m)
+	}
+	if m := sink.messages[1].Out; m.Run != runID(old) || m.ID != runID(old)+".1" {
+		t.Error("Unexpected run", m)
+	}
+}
@@ -0,0 +1,214 @@
+package realize
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Terminal ui, a pane for each project with its status and last lines
+type tui struct {
+	parent   *Realize
+	out      io.Writer
+	output   io.Writer
+	restore  func()
+	selected int
+	muted    map[int]bool
+	mu       sync.Mutex
+	done     chan bool
+	stopped  chan bool
+}
+
+// Start the terminal ui, the log output is hidden until stop
+func (t *tui) start() error {
+	restore, err := makeRaw(os.Stdin.Fd())
+	if err != nil {
+		return err
+	}
+	t.restore = restore
+	t.out = os.Stdout
+	t.output = Output
+	t.muted = make(map[int]bool)
+	t.done = make(chan bool)
+	t.stopped = make(chan bool)
+	Output = ioutil.Discard
+	// alternate screen and hidden cursor
+	fmt.Fprint(t.out, "\x1b[?1049h\x1b[?25l")
+	go t.keys()
+	go func() {
+		defer close(t.stopped)
+		ticker := time.NewTicker(250 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			t.draw()
+			select {
+			case <-t.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop the terminal ui and restore the terminal
+func (t *tui) stop() {
+	if t == nil || t.done == nil {
+		return
+	}
+	close(t.done)
+	<-t.stopped
+	fmt.Fprint(t.out, "\x1b[?25h\x1b[?1049l")
+	t.restore()
+	Output = t.output
+}
+
+// Keys read from the terminal: 1-9 or tab select a pane, r restarts, s silences, q quits
+func (t *tui) keys() {
+	buf := make([]byte, 1)
+	for {
+		if _, err := os.Stdin.Read(buf); err != nil {
+			return
+		}
+		select {
+		case <-t.done:
+			return
+		default:
+		}
+		t.key(buf[0])
+	}
+}
+
+// Key pressed
+func (t *tui) key(k byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := len(t.parent.Schema.Projects)
+	if n == 0 {
+		return
+	}
+	switch {
+	case k >= '1' && k <= '9' && int(k-'1') < n:
+		t.selected = int(k - '1')
+	case k == '\t' || k == 'j':
+		t.selected = (t.selected + 1) % n
+	case k == 'k':
+		t.selected = (t.selected + n - 1) % n
+	case k == 'r':
+		t.parent.Schema.Projects[t.selected].send(ActionRestart)
+	case k == 'p':
+		p := &t.parent.Schema.Projects[t.selected]
+		if paused, _, _, _ := p.watching(); paused {
+			p.send(ActionResume)
+		} else {
+			p.send(ActionPause)
+		}
+	case k == 's':
+		t.muted[t.selected] = !t.muted[t.selected]
+	case k == 'q' || k == 3:
+		go t.parent.Stop()
+	}
+}
+
+// Draw all the panes
+func (t *tui) draw() {
+	width, height, err := termSize(os.Stdout.Fd())
+	if err != nil || width <= 0 || height <= 0 {
+		width, height = 80, 24
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprint(t.out, "\x1b[H\x1b[2J"+strings.Join(t.render(width, height), "\r\n"))
+}
+
+// Render the screen lines
+func (t *tui) render(width, height int) []string {
+	var lines []string
+	projects := t.parent.Schema.Projects
+	if len(projects) == 0 {
+		return lines
+	}
+	size := (height - 1) / len(projects)
+	if size < 1 {
+		size = 1
+	}
+	for i := range projects {
+		p := &projects[i]
+		header := fmt.Sprintf(" %d %s  %s", i+1, strings.ToUpper(p.Name), p.current())
+		if d, ok := p.buildDuration(); ok {
+			header += fmt.Sprintf("  build %.3fs", d)
+		}
+		if paused, _, _, _ := p.watching(); paused {
+			header += "  paused"
+		}
+		if t.muted[i] {
+			header += "  silenced"
+		}
+		header = clip(header, width)
+		if i == t.selected {
+			if color.NoColor {
+				header = ">" + header[1:]
+			} else {
+				header = "\x1b[7m" + header + strings.Repeat(" ", width-len([]rune(header))) + "\x1b[0m"
+			}
+		}
+		lines = append(lines, header)
+		if size == 1 {
+			continue
+		}
+		var body []string
+		if !t.muted[i] {
+			for _, l := range p.lines(size - 1) {
+				text := l.Text
+				if l.Kind == "error" {
+					text = Red.Regular(clip(text, width-2))
+				} else {
+					text = clip(text, width-2)
+				}
+				body = append(body, "  "+text)
+			}
+		}
+		for len(body) < size-1 {
+			body = append(body, "")
+		}
+		lines = append(lines, body...)
+	}
+	lines = append(lines, clip(" 1-9/tab select  r restart  p pause  s silence  q quit", width))
+	return lines
+}
+
+// Duration of the last build, install or managed run of the project
+func (p *Project) buildDuration() (float64, bool) {
+	defer p.locked()()
+	var last *TaskResult
+	for i := range p.tasks {
+		switch p.tasks[i].Name {
+		case p.Tools.Build.name, p.Tools.Install.name, p.Tools.Run.name:
+			if last == nil || p.tasks[i].Time.After(last.Time) {
+				last = &p.tasks[i]
+			}
+		}
+	}
+	if last == nil {
+		return 0, false
+	}
+	return last.Duration, true
+}
+
+// Clip a line to a width, new lines are removed
+func clip(s string, width int) string {
+	s = strings.Replace(strings.Replace(s, "\r", "", -1), "\n", " ", -1)
+	if r := []rune(s); len(r) > width {
+		if width < 0 {
+			width = 0
+		}
+		return string(r[:width])
+	}
+	return s
+}
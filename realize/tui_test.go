@@ -0,0 +1,83 @@
+package realize
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTui_Render(t *testing.T) {
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{Name: "first", lifecycle: &lifecycle{state: StateRunning}}, Project{Name: "second"})
+	r.Projects[0].Tools.Build.name = "Build"
+	r.Projects[0].tasks = []TaskResult{{Name: "Build", Time: time.Now(), Duration: 1.5}}
+	r.Projects[0].Buffer.StdOut = []BufferOut{{Time: time.Now(), Text: "hello"}}
+	ui := &tui{parent: &r, muted: map[int]bool{1: true}}
+	lines := ui.render(40, 9)
+	if len(lines) != 9 {
+		t.Error("Unexpected lines", len(lines))
+	}
+	screen := strings.Join(lines, "\n")
+	for _, s := range []string{"FIRST  running  build 1.500s", "hello", "SECOND  pending  silenced"} {
+		if !strings.Contains(screen, s) {
+			t.Error("Unexpected screen", s, screen)
+		}
+	}
+	for _, l := range lines {
+		if len([]rune(strings.Replace(strings.Replace(l, "\x1b[7m", "", 1), "\x1b[0m", "", 1))) > 40 {
+			t.Error("Unexpected line width", l)
+		}
+	}
+}
+
+func TestTui_RenderRunning(t *testing.T) {
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{Name: "app", parent: &r, lifecycle: &lifecycle{}, records: &records{}})
+	p := &r.Projects[0]
+	ui := &tui{parent: &r, muted: map[int]bool{}}
+	done := make(chan bool)
+	// the tasks and the broker write while the ui draws
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			p.write(Message{Type: "out", Out: BufferOut{Time: time.Now(), Text: "line"}, retained: true})
+			p.record(Response{Name: "Build"}, time.Now())
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		ui.render(40, 9)
+	}
+	<-done
+}
+
+func TestTui_Key(t *testing.T) {
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{Name: "first"}, Project{Name: "second", control: make(chan string, 1)})
+	ui := &tui{parent: &r, muted: map[int]bool{}}
+	ui.key('2')
+	if ui.selected != 1 {
+		t.Error("Unexpected selection", ui.selected)
+	}
+	ui.key('r')
+	if len(r.Projects[1].control) != 1 {
+		t.Error("Expected a restart")
+	}
+	<-r.Projects[1].control
+	ui.key('p')
+	if action := <-r.Projects[1].control; action != ActionPause {
+		t.Error("Unexpected action", action)
+	}
+	r.Projects[1].paused = true
+	ui.key('p')
+	if action := <-r.Projects[1].control; action != ActionResume {
+		t.Error("Unexpected action", action)
+	}
+	ui.key('s')
+	if !ui.muted[1] {
+		t.Error("Expected a silenced pane")
+	}
+	ui.key('\t')
+	if ui.selected != 0 {
+		t.Error("Unexpected selection", ui.selected)
+	}
+}
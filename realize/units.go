@@ -0,0 +1,118 @@
+package realize
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keys of the config with a duration, the value has a unit: 300ms, 2s or 1m30s
+var durationKeys = []string{"debounce", "timeout", "interval", "delay", "cpu", "shutdown", "max_runtime"}
+
+// Keys of the config with a size, the value is in bytes or has a unit: 512K, 5MB or 2G
+var sizeKeys = []string{"memory", "max_size"}
+
+// Duration of a config value, a number without unit is rejected but 0
+func duration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return 0, nil
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return 0, errors.New("duration " + value + " without unit, use ms, s, m or h as 300ms or 2m")
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.New("invalid duration " + value + ", use ms, s, m or h as 300ms or 2m")
+	}
+	if d < 0 {
+		return 0, errors.New("negative duration " + value)
+	}
+	return d, nil
+}
+
+// Size in bytes of a config value as 512, 64K, 5MB or 2GiB, the units are powers of 1024
+func size(value string) (uint64, error) {
+	value = strings.ToUpper(strings.Replace(strings.TrimSpace(value), " ", "", -1))
+	if value == "" {
+		return 0, nil
+	}
+	number := strings.TrimSuffix(strings.TrimSuffix(value, "IB"), "B")
+	unit := uint64(1)
+	if number != "" {
+		switch number[len(number)-1] {
+		case 'K':
+			unit = 1 << 10
+		case 'M':
+			unit = 1 << 20
+		case 'G':
+			unit = 1 << 30
+		}
+	}
+	if unit > 1 {
+		number = number[:len(number)-1]
+	}
+	n, err := strconv.ParseUint(number, 10, 64)
+	if err != nil || n == 0 {
+		return 0, errors.New("invalid size " + value + ", use bytes or K, M, G as 512K or 5MB")
+	}
+	return n * unit, nil
+}
+
+// CheckUnits checks the durations and the sizes of a config, the error points to the line of the value
+func checkUnits(content []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	// the lines of a block scalar are text, more indented than its key
+	block := -1
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if block >= 0 {
+			if strings.TrimSpace(line) == "" || indent > block {
+				continue
+			}
+			block = -1
+		}
+		key, value, ok := keyValue(line)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			block = indent
+			continue
+		}
+		var err error
+		switch {
+		case contains(durationKeys, key):
+			_, err = duration(value)
+		case contains(sizeKeys, key):
+			_, err = size(value)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %s: %s", n, key, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// KeyValue of a config line, the value is unquoted and without comment
+func keyValue(line string) (key, value string, ok bool) {
+	line = strings.TrimSpace(line)
+	line = strings.TrimSpace(strings.TrimPrefix(line, "- "))
+	i := strings.Index(line, ":")
+	if i <= 0 || strings.ContainsAny(line[:i], " \"'#") {
+		return "", "", false
+	}
+	key, value = line[:i], strings.TrimSpace(line[i+1:])
+	if j := strings.Index(value, " #"); j >= 0 {
+		value = strings.TrimSpace(value[:j])
+	}
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	return key, value, value != ""
+}
@@ -0,0 +1,83 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	for value, d := range map[string]time.Duration{"": 0, "0": 0, "300ms": 300 * time.Millisecond, " 2m": 2 * time.Minute, "1m30s": 90 * time.Second, "1.5s": 1500 * time.Millisecond} {
+		if v, err := duration(value); err != nil || v != d {
+			t.Error("Unexpected duration", value, v, err)
+		}
+	}
+	for _, value := range []string{"300", "1.5", "2 minutes", "-1s"} {
+		if _, err := duration(value); err == nil {
+			t.Error("Expected an error", value)
+		}
+	}
+}
+
+func TestSize_Units(t *testing.T) {
+	for value, n := range map[string]uint64{"512b": 512, "64KB": 64 << 10, "5MB": 5 << 20, "5 MiB": 5 << 20, "2gb": 2 << 30} {
+		if s, err := size(value); err != nil || s != n {
+			t.Error("Unexpected size", value, s, err)
+		}
+	}
+	for _, value := range []string{"B", "MB", "0", "2T", "1.5M", "-1K"} {
+		if _, err := size(value); err == nil {
+			t.Error("Expected an error", value)
+		}
+	}
+}
+
+func TestCheckUnits(t *testing.T) {
+	valid := `settings:
+  legacy:
+    interval: 100ms # polling
+  shutdown: "10s"
+schema:
+- name: app
+  watcher:
+    debounce: 0
+    changes:
+      max_size: 5MB
+    scripts:
+    - type: before
+      command: |
+        sleep 1
+        timeout: 300
+      limits:
+        memory: 512M
+`
+	if err := checkUnits([]byte(valid)); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	invalid := strings.Replace(valid, "debounce: 0", "debounce: 300", 1)
+	if err := checkUnits([]byte(invalid)); err == nil || !strings.HasPrefix(err.Error(), "line 8: debounce: duration 300 without unit") {
+		t.Error("Unexpected error", err)
+	}
+	invalid = strings.Replace(valid, "memory: 512M", "memory: lots", 1)
+	if err := checkUnits([]byte(invalid)); err == nil || !strings.HasPrefix(err.Error(), "line 17: memory: invalid size") {
+		t.Error("Unexpected error", err)
+	}
+}
+
+func TestInclude_Units(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	base := filepath.Join(dir, "base.yaml")
+	ioutil.WriteFile(base, []byte("settings:\n  legacy:\n    interval: 2\n"), 0644)
+	file := filepath.Join(dir, File)
+	content := []byte("include: base.yaml\n")
+	if _, _, err := include(file, content, map[string]bool{}); err == nil || !strings.HasPrefix(err.Error(), base+": line 3: interval") {
+		t.Error("Unexpected error", err)
+	}
+}
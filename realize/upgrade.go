@@ -0,0 +1,256 @@
+package realize
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Release channels
+const (
+	ChannelStable = "stable"
+	ChannelEdge   = "edge"
+)
+
+// Release assets, the checksums file lists the sha256 of each binary
+const (
+	FileChecksums = "checksums.txt"
+	FileSignature = "checksums.txt.sig"
+)
+
+var (
+	// ReleasesURL lists the releases, newest first
+	ReleasesURL = "https://api.github.com/repos/oxequa/realize/releases"
+	// ReleasesKey is the base64 ed25519 public key of the checksums signature, set at build time with
+	// -ldflags "-X github.com/oxequa/realize/realize.ReleasesKey=...", without it the upgrade must be insecure
+	ReleasesKey = ""
+)
+
+// Release published with its assets
+type Release struct {
+	Tag        string         `json:"tag_name"`
+	Prerelease bool           `json:"prerelease"`
+	Draft      bool           `json:"draft"`
+	Assets     []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset is a file of a release
+type ReleaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Latest release of a channel, edge includes the prereleases
+func Latest(channel string) (Release, error) {
+	if channel != ChannelStable && channel != ChannelEdge {
+		return Release{}, errors.New("unknown channel " + channel + ", use " + ChannelStable + " or " + ChannelEdge)
+	}
+	body, err := download(ReleasesURL)
+	if err != nil {
+		return Release{}, err
+	}
+	var releases []Release
+	if err = json.Unmarshal(body, &releases); err != nil {
+		return Release{}, err
+	}
+	for _, r := range releases {
+		if !r.Draft && (!r.Prerelease || channel == ChannelEdge) {
+			return r, nil
+		}
+	}
+	return Release{}, errors.New("no " + channel + " release found")
+}
+
+// Newer is true when the release is newer than the running version
+func (r Release) Newer() bool {
+	return compareVersions(r.Tag, RVersion) > 0
+}
+
+// Binary asset name of the current platform
+func Binary() string {
+	name := RPrefix + "_" + runtime.GOOS + "_" + runtime.GOARCH
+	if runtime.GOOS == "windows" {
+		name += RExtWin
+	}
+	return name
+}
+
+// Upgrade downloads the binary of the release, verifies it and replaces the running one
+func (r Release) Upgrade(insecure bool) (string, error) {
+	// the checksums alone come from the same server as the binary
+	if ReleasesKey == "" && !insecure {
+		return "", errors.New("built without a releases key, the signature can't be verified: upgrade --insecure checks only the checksums")
+	}
+	bin, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if bin, err = filepath.EvalSymlinks(bin); err != nil {
+		return "", err
+	}
+	content, err := r.verified(Binary())
+	if err != nil {
+		return bin, err
+	}
+	return bin, swap(bin, content)
+}
+
+// Download an asset and check it against the checksums, signed when a key is set
+func (r Release) verified(name string) ([]byte, error) {
+	urls := make(map[string]string, len(r.Assets))
+	for _, a := range r.Assets {
+		urls[a.Name] = a.URL
+	}
+	for _, n := range []string{name, FileChecksums} {
+		if urls[n] == "" {
+			return nil, errors.New(r.Tag + " has no " + n)
+		}
+	}
+	sums, err := download(urls[FileChecksums])
+	if err != nil {
+		return nil, err
+	}
+	if ReleasesKey != "" {
+		if urls[FileSignature] == "" {
+			return nil, errors.New(r.Tag + " has no " + FileSignature)
+		}
+		sig, err := download(urls[FileSignature])
+		if err != nil {
+			return nil, err
+		}
+		if err = verify(ReleasesKey, sums, sig); err != nil {
+			return nil, err
+		}
+	}
+	sum, err := checksum(sums, name)
+	if err != nil {
+		return nil, err
+	}
+	content, err := download(urls[name])
+	if err != nil {
+		return nil, err
+	}
+	if actual := sha256.Sum256(content); hex.EncodeToString(actual[:]) != sum {
+		return nil, errors.New(name + " checksum mismatch")
+	}
+	return content, nil
+}
+
+// Verify the base64 ed25519 signature of the checksums
+func verify(key string, sums []byte, sig []byte) error {
+	pub, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return errors.New("invalid releases key")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return errors.New("invalid " + FileSignature)
+	}
+	if !ed25519.Verify(pub, sums, raw) {
+		return errors.New(FileSignature + " doesn't match " + FileChecksums)
+	}
+	return nil
+}
+
+// Checksum of a file in the sha256sum format
+func checksum(sums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", errors.New(FileChecksums + " has no " + name)
+}
+
+// Swap a binary, the old one is moved aside because windows can't overwrite a running executable
+func swap(bin string, content []byte) error {
+	info, err := os.Stat(bin)
+	if err != nil {
+		return err
+	}
+	tmp := bin + ".new"
+	if err = ioutil.WriteFile(tmp, content, info.Mode()); err != nil {
+		return err
+	}
+	old := bin + ".old"
+	os.Remove(old)
+	if err = os.Rename(bin, old); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err = os.Rename(tmp, bin); err != nil {
+		os.Rename(old, bin)
+		return err
+	}
+	// still in use on windows, removed by the next upgrade
+	os.Remove(old)
+	return nil
+}
+
+// Download a url
+func download(url string) ([]byte, error) {
+	client := http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(ioutil.Discard, resp.Body)
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Compare two versions as major.minor.patch, a prerelease is older than its release
+func compareVersions(a, b string) int {
+	a, b = strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v")
+	an, ap := splitVersion(a)
+	bn, bp := splitVersion(b)
+	for i := 0; i < 3; i++ {
+		if an[i] != bn[i] {
+			if an[i] > bn[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+	switch {
+	case ap == bp:
+		return 0
+	case ap == "":
+		return 1
+	case bp == "":
+		return -1
+	}
+	return strings.Compare(ap, bp)
+}
+
+func splitVersion(v string) (numbers [3]int, pre string) {
+	// the build metadata doesn't count
+	v = strings.SplitN(v, "+", 2)[0]
+	if i := strings.Index(v, "-"); i >= 0 {
+		v, pre = v[:i], v[i+1:]
+	}
+	for i, n := range strings.SplitN(v, ".", 3) {
+		numbers[i], _ = strconv.Atoi(n)
+	}
+	return
+}
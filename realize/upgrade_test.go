@@ -0,0 +1,128 @@
+package realize
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func releases(files map[string]string) (*httptest.Server, []Release) {
+	mux := http.NewServeMux()
+	ts := httptest.NewServer(mux)
+	var assets []ReleaseAsset
+	for name, content := range files {
+		content := content
+		mux.HandleFunc("/"+name, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(content))
+		})
+		assets = append(assets, ReleaseAsset{Name: name, URL: ts.URL + "/" + name})
+	}
+	list := []Release{
+		{Tag: "v9.0.0", Draft: true},
+		{Tag: "v2.1.0-beta.1", Prerelease: true, Assets: assets},
+		{Tag: "v2.0.4", Assets: assets},
+	}
+	mux.HandleFunc("/releases", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(list)
+	})
+	return ts, list
+}
+
+func TestLatest(t *testing.T) {
+	ts, _ := releases(nil)
+	defer ts.Close()
+	defer func(url string) { ReleasesURL = url }(ReleasesURL)
+	ReleasesURL = ts.URL + "/releases"
+	for channel, tag := range map[string]string{ChannelStable: "v2.0.4", ChannelEdge: "v2.1.0-beta.1"} {
+		release, err := Latest(channel)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if release.Tag != tag {
+			t.Error("Unexpected release", channel, release.Tag)
+		}
+	}
+	if _, err := Latest("nightly"); err == nil {
+		t.Error("Expected an error, unknown channel")
+	}
+}
+
+func TestRelease_Verified(t *testing.T) {
+	sum := sha256.Sum256([]byte("binary"))
+	sums := hex.EncodeToString(sum[:]) + "  realize_linux_amd64\n"
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(sums)))
+	ts, list := releases(map[string]string{"realize_linux_amd64": "binary", "realize_linux_arm64": "other", FileChecksums: sums, FileSignature: sig})
+	defer ts.Close()
+	release := list[2]
+	content, err := release.verified("realize_linux_amd64")
+	if err != nil || string(content) != "binary" {
+		t.Error("Unexpected error", err, string(content))
+	}
+	if _, err = release.verified("realize_linux_arm64"); err == nil {
+		t.Error("Expected an error, not in the checksums")
+	}
+	if _, err = release.verified("realize_darwin_amd64"); err == nil {
+		t.Error("Expected an error, missing asset")
+	}
+	if _, err = release.Upgrade(false); err == nil {
+		t.Error("Expected an error, no releases key")
+	}
+	defer func() { ReleasesKey = "" }()
+	ReleasesKey = base64.StdEncoding.EncodeToString(pub)
+	if _, err = release.verified("realize_linux_amd64"); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	other, _, _ := ed25519.GenerateKey(nil)
+	ReleasesKey = base64.StdEncoding.EncodeToString(other)
+	if _, err = release.verified("realize_linux_amd64"); err == nil {
+		t.Error("Expected an error, wrong signature")
+	}
+}
+
+func TestRelease_Newer(t *testing.T) {
+	defer func(v string) { RVersion = v }(RVersion)
+	RVersion = "2.0.3"
+	for tag, newer := range map[string]bool{"v2.0.4": true, "2.1.0-beta.1": true, "v2.0.3": false, "v2.0.3-rc.1": false, "v1.9.9": false, "v2.0.3+build": false} {
+		if (Release{Tag: tag}).Newer() != newer {
+			t.Error("Unexpected comparison", tag)
+		}
+	}
+	if compareVersions("2.1.0-beta.2", "2.1.0-beta.1") != 1 {
+		t.Error("Unexpected prerelease comparison")
+	}
+}
+
+func TestSwap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bin := filepath.Join(dir, "realize")
+	if err = ioutil.WriteFile(bin, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = swap(bin, []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := ioutil.ReadFile(bin)
+	info, _ := os.Stat(bin)
+	if string(content) != "new" || info.Mode().Perm() != 0755 {
+		t.Error("Unexpected binary", string(content), info.Mode())
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Error("Unexpected files left", len(files))
+	}
+}
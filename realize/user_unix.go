@@ -0,0 +1,85 @@
+// +build !windows
+
+package realize
+
+import (
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// Credential of a user and a group, names or ids. The group is the one of the user by default,
+// a numeric user without an entry gets the same group id
+func credential(name, group string) (*syscall.Credential, *user.User, error) {
+	cred := &syscall.Credential{Uid: uint32(os.Getuid()), Gid: uint32(os.Getgid())}
+	var u *user.User
+	if name != "" {
+		var err error
+		if u, err = user.Lookup(name); err != nil {
+			if u, err = user.LookupId(name); err != nil {
+				if _, nerr := strconv.ParseUint(name, 10, 32); nerr != nil {
+					return nil, nil, err
+				}
+				u = &user.User{Uid: name, Gid: name, Username: name}
+			}
+		}
+		uid, err := strconv.ParseUint(u.Uid, 10, 32)
+		if err != nil {
+			return nil, nil, err
+		}
+		gid, err := strconv.ParseUint(u.Gid, 10, 32)
+		if err != nil {
+			return nil, nil, err
+		}
+		cred.Uid, cred.Gid = uint32(uid), uint32(gid)
+	}
+	if group != "" {
+		g, err := user.LookupGroup(group)
+		if err != nil {
+			if g, err = user.LookupGroupId(group); err != nil {
+				if _, nerr := strconv.ParseUint(group, 10, 32); nerr != nil {
+					return nil, nil, err
+				}
+				g = &user.Group{Gid: group}
+			}
+		}
+		gid, err := strconv.ParseUint(g.Gid, 10, 32)
+		if err != nil {
+			return nil, nil, err
+		}
+		cred.Gid = uint32(gid)
+	}
+	return cred, u, nil
+}
+
+// CheckUser reports an unknown user or group
+func checkUser(name, group string) error {
+	_, _, err := credential(name, group)
+	return err
+}
+
+// RunAs starts a command as a user and a group, its home is the one of the user
+func runAs(cmd *exec.Cmd, name, group string) error {
+	if name == "" && group == "" {
+		return nil
+	}
+	cred, u, err := credential(name, group)
+	if err != nil {
+		return err
+	}
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = cred
+	// the go caches are written in the home
+	if u != nil && u.HomeDir != "" {
+		env := cmd.Env
+		if env == nil {
+			env = os.Environ()
+		}
+		cmd.Env = append(env, "HOME="+u.HomeDir, "USER="+u.Username)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// +build !windows
+
+package realize
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"os/user"
+	"strings"
+	"testing"
+)
+
+func TestCredential(t *testing.T) {
+	cred, u, err := credential("root", "")
+	if err != nil || cred.Uid != 0 || cred.Gid != 0 || u.Username != "root" {
+		t.Error("Unexpected credential", cred, u, err)
+	}
+	// a numeric user without an entry gets the same group
+	if cred, _, err = credential("4242", ""); err != nil || cred.Uid != 4242 || cred.Gid != 4242 {
+		t.Error("Unexpected credential", cred, err)
+	}
+	if cred, _, err = credential("4242", "4343"); err != nil || cred.Gid != 4343 {
+		t.Error("Unexpected credential", cred, err)
+	}
+	if err := checkUser("missing-realize-user", ""); err == nil {
+		t.Error("Expected an unknown user")
+	}
+	if err := checkUser("", "missing-realize-group"); err == nil {
+		t.Error("Expected an unknown group")
+	}
+	cmd := exec.Command("true")
+	if err := runAs(cmd, "root", ""); err != nil || cmd.SysProcAttr.Credential == nil || !strings.Contains(strings.Join(cmd.Env, ","), "USER=root") {
+		t.Error("Unexpected command", cmd.SysProcAttr, err)
+	}
+}
+
+func TestCommand_User(t *testing.T) {
+	nobody, err := user.Lookup("nobody")
+	if os.Getuid() != 0 || err != nil {
+		t.Skip("root and nobody")
+	}
+	c := Command{Cmd: "id -u", User: "nobody"}
+	r := c.exec(context.Background(), os.TempDir())
+	if r.Err != nil || strings.TrimSpace(r.Out) != nobody.Uid {
+		t.Error("Unexpected user", r.Out, r.Err)
+	}
+}
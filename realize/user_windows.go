@@ -0,0 +1,21 @@
+// +build windows
+
+package realize
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// CheckUser reports an unknown user or group, they aren't supported on windows
+func checkUser(name, group string) error {
+	if name != "" || group != "" {
+		return errors.New("user and group aren't supported on windows")
+	}
+	return nil
+}
+
+// RunAs starts a command as a user and a group, they aren't supported on windows
+func runAs(cmd *exec.Cmd, name, group string) error {
+	return checkUser(name, group)
+}
@@ -1,10 +1,15 @@
 package realize
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"go/build"
 	"log"
 	"os"
+	"os/exec"
 	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/urfave/cli/v2"
@@ -32,6 +37,70 @@ func split(args, fields []string) []string {
 	return args
 }
 
+// List of comma separated values, the empty ones are skipped
+func List(value string) (values []string) {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return
+}
+
+// Words split a command line in arguments, quotes and escapes are honored like a posix shell
+func words(line string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	var quote rune
+	found := false
+	escape := false
+	for _, r := range line {
+		switch {
+		case escape:
+			if quote == '"' && r != '"' && r != '\\' && r != '$' && r != '`' {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escape = false
+		case quote == '\'':
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\' && os.PathSeparator != '\\':
+			escape, found = true, true
+		case quote == '"':
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, found = r, true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if found {
+				args = append(args, word.String())
+				word.Reset()
+				found = false
+			}
+		default:
+			word.WriteRune(r)
+			found = true
+		}
+	}
+	if escape {
+		return nil, errors.New("Unterminated escape in '" + line + "'")
+	}
+	if quote != 0 {
+		return nil, errors.New("Unterminated quote in '" + line + "'")
+	}
+	if found {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
+
 // Duplicates check projects with same name or same combinations of main/path
 func duplicates(value Project, arr []Project) (Project, error) {
 	for _, val := range arr {
@@ -67,6 +136,31 @@ func replace(a []string, b string) []string {
 	return a
 }
 
+// Workdir resolves a relative dir against a base path
+func workdir(base, dir string) string {
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(base, dir)
+	}
+	abs, _ := filepath.Abs(dir)
+	return abs
+}
+
+// Within reports if a path is the dir or inside it, whole segments are compared
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(os.PathSeparator))
+}
+
+// Contains checks if a value is in a list
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // Wdir return current working directory
 func Wdir() string {
 	dir, err := os.Getwd()
@@ -84,3 +178,66 @@ func hasGoMod(dir string) bool {
 
 	return true
 }
+
+// Gobin is the install dir of the go binaries, the bin of the last GOPATH
+func gobin() string {
+	path := filepath.SplitList(build.Default.GOPATH)
+	if len(path) == 0 {
+		return ""
+	}
+	return filepath.Join(path[len(path)-1], "bin")
+}
+
+// Exit code of a command error, 1 if the command didn't run
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if e, ok := err.(*exec.ExitError); ok && e.ExitCode() > 0 {
+		return e.ExitCode()
+	}
+	return 1
+}
+
+// GoRun reports a command line running go run, the go command starts the program as its child
+func goRun(line string) bool {
+	args, err := words(line)
+	if err != nil {
+		return false
+	}
+	for i := 0; i+1 < len(args); i++ {
+		if strings.TrimSuffix(filepath.Base(args[i]), RExtWin) == "go" && args[i+1] == "run" {
+			return true
+		}
+	}
+	return false
+}
+
+// Failed checks if at least one response has an error
+func failed(responses []Response) bool {
+	for _, r := range responses {
+		if r.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Execute a command in a directory, stdout and stderr are merged
+func execute(ctx context.Context, dir string, args []string) (r Response) {
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Stdout, cmd.Stderr = &out, &out
+	r.Name = args[0]
+	if err := cmd.Run(); err != nil {
+		r.Err = err
+		if out.Len() > 0 {
+			r.Err = errors.New(out.String())
+		}
+		r.Code = exitCode(err)
+		return
+	}
+	r.Out = out.String()
+	return
+}
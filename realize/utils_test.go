@@ -1,10 +1,14 @@
 package realize
 
 import (
+	"errors"
 	"flag"
 	"github.com/urfave/cli/v2"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
+	"runtime"
 	"testing"
 )
 
@@ -19,6 +23,15 @@ func TestParams(t *testing.T) {
 	}
 }
 
+func TestList(t *testing.T) {
+	if values := List(" a, ,b,"); !reflect.DeepEqual(values, []string{"a", "b"}) {
+		t.Error("Unexpected values", values)
+	}
+	if values := List(""); values != nil {
+		t.Error("Unexpected values", values)
+	}
+}
+
 func TestDuplicates(t *testing.T) {
 	projects := []Project{
 		{
@@ -71,3 +84,63 @@ func TestExt(t *testing.T) {
 	}
 
 }
+
+func TestWords(t *testing.T) {
+	lines := map[string][]string{
+		`go test -run "TestFoo|TestBar"`: {"go", "test", "-run", "TestFoo|TestBar"},
+		`echo 'a  b' "c 'd'"`:            {"echo", "a  b", "c 'd'"},
+		`  ls   -la  `:                   {"ls", "-la"},
+		`echo "" ''`:                     {"echo", "", ""},
+		`echo a"b"c`:                     {"echo", "abc"},
+	}
+	for line, expected := range lines {
+		args, err := words(line)
+		if err != nil {
+			t.Error("Unexpected error", err)
+		}
+		if !reflect.DeepEqual(args, expected) {
+			t.Error("Unexpected words", args, expected)
+		}
+	}
+	if runtime.GOOS != "windows" {
+		if args, _ := words(`echo a\ b "c\"d"`); !reflect.DeepEqual(args, []string{"echo", "a b", `c"d`}) {
+			t.Error("Unexpected words", args)
+		}
+	}
+	if _, err := words(`echo "open`); err == nil {
+		t.Error("Expected an error")
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	if exitCode(nil) != 0 {
+		t.Error("Expected 0")
+	}
+	if exitCode(errors.New("error")) != 1 {
+		t.Error("Expected 1")
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	err := exec.Command("sh", "-c", "exit 5").Run()
+	if exitCode(err) != 5 {
+		t.Error("Expected 5 instead", exitCode(err))
+	}
+}
+
+func TestGoRun(t *testing.T) {
+	lines := map[string]bool{
+		"go run main.go":              true,
+		"/usr/local/go/bin/go run .":  true,
+		"env GOOS=linux go run ./cmd": true,
+		"go build":                    false,
+		"gorun main.go":               false,
+		"echo go":                     false,
+		`echo "go run"`:               false,
+	}
+	for line, expected := range lines {
+		if goRun(line) != expected {
+			t.Error("Unexpected go run", line, expected)
+		}
+	}
+}
@@ -2,15 +2,57 @@
 
 package realize
 
-import "strings"
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
 
-// isHidden check if a file or a path is hidden
-func isHidden(path string) bool {
-	arr := strings.Split(path[len(Wdir()):], "/")
+// isHidden check if a file or a path is hidden, the hidden names in except aren't
+func isHidden(path string, except []string) bool {
+	// the segments of the path relative to the working dir
+	if rel, err := filepath.Rel(Wdir(), path); err == nil {
+		path = rel
+	}
+	arr := strings.Split(path, "/")
+L:
 	for _, elm := range arr {
-		if strings.HasPrefix(elm, ".") {
-			return true
+		if !strings.HasPrefix(elm, ".") || elm == "." || elm == ".." {
+			continue
+		}
+		for _, e := range except {
+			if elm == e {
+				continue L
+			}
 		}
+		return true
 	}
 	return false
 }
+
+// Shell run a command line through sh
+func shell(line string) *exec.Cmd {
+	return exec.Command("sh", "-c", line)
+}
+
+// Group starts a command in its own process group
+func group(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// Interrupt a command and the processes of its group
+func interrupt(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGINT)
+}
+
+// Terminate kills a command and the processes of its group, the command alone without a group
+func terminate(cmd *exec.Cmd) error {
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		return cmd.Process.Kill()
+	}
+	return nil
+}
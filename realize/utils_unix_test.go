@@ -0,0 +1,111 @@
+//go:build !windows
+// +build !windows
+
+package realize
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIsHidden(t *testing.T) {
+	except := []string{".config", ".env"}
+	if isHidden(filepath.Join(Wdir(), "main.go"), except) {
+		t.Error("Unexpected hidden file")
+	}
+	if !isHidden(filepath.Join(Wdir(), ".git", "config"), except) {
+		t.Error("Expected a hidden file")
+	}
+	if isHidden(filepath.Join(Wdir(), ".config", "app.yaml"), except) || isHidden(filepath.Join(Wdir(), ".env"), except) {
+		t.Error("Unexpected hidden file in except")
+	}
+	if !isHidden(filepath.Join(Wdir(), ".config", ".secret"), except) {
+		t.Error("Expected a hidden file inside an except folder")
+	}
+	// relative or outside the working dir
+	if isHidden("main.go", except) || isHidden(filepath.Join(Wdir(), "..", "app", "main.go"), except) {
+		t.Error("Unexpected hidden file")
+	}
+	if !isHidden(filepath.Join("/", ".cache", "main.go"), except) {
+		t.Error("Expected a hidden file outside the working dir")
+	}
+}
+
+func TestTerminate(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "sleep 30 & echo $!; wait")
+	group(cmd)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	buf := make([]byte, 32)
+	n, _ := out.Read(buf)
+	child, err := strconv.Atoi(strings.TrimSpace(string(buf[:n])))
+	if err != nil {
+		t.Fatal("Unexpected child", string(buf[:n]))
+	}
+	if err := terminate(cmd); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	cmd.Wait()
+	// a killed child not reaped yet is a zombie
+	alive := func() bool {
+		stat, err := ioutil.ReadFile("/proc/" + strconv.Itoa(child) + "/stat")
+		if err != nil {
+			return syscall.Kill(child, 0) == nil
+		}
+		fields := strings.Fields(string(stat))
+		return len(fields) > 2 && fields[2] != "Z"
+	}
+	for i := 0; i < 50 && alive(); i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if alive() {
+		syscall.Kill(child, syscall.SIGKILL)
+		t.Error("Expected the child terminated with the group")
+	}
+}
+
+func TestTerminate_Ungrouped(t *testing.T) {
+	// attached to the stdin, a go run isn't started in its own group
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	if err := terminate(cmd); err != nil {
+		t.Error("Unexpected error", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("Expected the command killed without a group")
+	}
+}
+
+func TestProject_RunStdin(t *testing.T) {
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{parent: &r, Path: os.TempDir()})
+	p := &r.Projects[0]
+	// attached to the stdin, the command isn't in a group of its own
+	p.Tools.Run = Tool{Status: true, Command: "exec sleep 30", Stdin: true}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+	start := time.Now()
+	p.run(ctx, p.Path, make(chan Response, 10))
+	if elapsed := time.Since(start); elapsed > r.Settings.timeout()/2 {
+		t.Error("Expected the command interrupted before the timeout", elapsed)
+	}
+}
@@ -2,10 +2,21 @@
 
 package realize
 
-import "syscall"
+import (
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
 
-// isHidden check if a file or a path is hidden
-func isHidden(path string) bool {
+// isHidden check if a file or a path is hidden, the hidden names in except aren't
+func isHidden(path string, except []string) bool {
+	for _, e := range except {
+		if strings.EqualFold(filepath.Base(path), e) {
+			return false
+		}
+	}
 	p, e := syscall.UTF16PtrFromString(path)
 	if e != nil {
 		return false
@@ -16,3 +27,31 @@ func isHidden(path string) bool {
 	}
 	return attrs&syscall.FILE_ATTRIBUTE_HIDDEN != 0
 }
+
+// Shell run a command line through cmd, the line is passed as is
+func shell(line string) *exec.Cmd {
+	cmd := exec.Command("cmd")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: "cmd /C " + line}
+	return cmd
+}
+
+// Group starts a command in its own process group
+func group(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// Interrupt a command, the interrupt signal isn't supported on windows, its tree is killed
+func interrupt(cmd *exec.Cmd) error {
+	return terminate(cmd)
+}
+
+// Terminate kills a command and its children, the command alone if taskkill fails
+func terminate(cmd *exec.Cmd) error {
+	if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run(); err != nil {
+		return cmd.Process.Kill()
+	}
+	return nil
+}
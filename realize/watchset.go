@@ -0,0 +1,190 @@
+package realize
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WatchSet is the report of the watched paths of a project, the ignore rules that matched, the missing paths and the ones not indexed
+type WatchSet struct {
+	Paths   []WatchedPath `json:"paths"`
+	Ignored []IgnoreRule  `json:"ignored"`
+	Missing []string      `json:"missing"`
+	Errors  []WalkError   `json:"errors"`
+}
+
+// WatchedPath is a watched path with its files and folders
+type WatchedPath struct {
+	Path    string `json:"path"`
+	Files   int64  `json:"files"`
+	Folders int64  `json:"folders"`
+}
+
+// IgnoreRule is an ignore rule with the number of paths it skipped
+type IgnoreRule struct {
+	Rule    string `json:"rule"`
+	Matches int    `json:"matches"`
+}
+
+// WalkError is a path not indexed, unreadable or not watched
+type WalkError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// Recorder of the watch set, filled by the walk of the paths
+type recorder struct {
+	mu      sync.Mutex
+	paths   []WatchedPath
+	rules   map[string]int
+	missing []string
+	errs    []WalkError
+}
+
+// Ignore counts a path skipped by a rule
+func (r *recorder) ignore(rule string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if r.rules == nil {
+		r.rules = make(map[string]int)
+	}
+	r.rules[rule]++
+	r.mu.Unlock()
+}
+
+// Fail records a path not indexed
+func (r *recorder) fail(path string, err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.errs = append(r.errs, WalkError{Path: path, Error: err.Error()})
+	r.mu.Unlock()
+}
+
+// Path records the files and folders of a watched path, a missing one otherwise
+func (r *recorder) path(path string, files, folders int64, exists bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !exists {
+		r.missing = append(r.missing, path)
+		return
+	}
+	r.paths = append(r.paths, WatchedPath{Path: path, Files: files, Folders: folders})
+}
+
+// Drop a path no longer watched
+func (r *recorder) drop(path string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, v := range r.paths {
+		if v.Path == path {
+			r.paths = append(r.paths[:i], r.paths[i+1:]...)
+			break
+		}
+	}
+	for i, v := range r.missing {
+		if v == path {
+			r.missing = append(r.missing[:i], r.missing[i+1:]...)
+			break
+		}
+	}
+}
+
+// Snapshot of the watch set, the rules sorted by matches
+func (r *recorder) snapshot() WatchSet {
+	set := WatchSet{Paths: []WatchedPath{}, Ignored: []IgnoreRule{}, Missing: []string{}, Errors: []WalkError{}}
+	if r == nil {
+		return set
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	set.Paths = append(set.Paths, r.paths...)
+	set.Missing = append(set.Missing, r.missing...)
+	set.Errors = append(set.Errors, r.errs...)
+	for rule, n := range r.rules {
+		set.Ignored = append(set.Ignored, IgnoreRule{Rule: rule, Matches: n})
+	}
+	sort.Slice(set.Ignored, func(i, j int) bool {
+		if set.Ignored[i].Matches != set.Ignored[j].Matches {
+			return set.Ignored[i].Matches > set.Ignored[j].Matches
+		}
+		return set.Ignored[i].Rule < set.Ignored[j].Rule
+	})
+	return set
+}
+
+// Index a watched path and record its files and folders
+func (p *Project) indexPath(path string) {
+	base := p.abs(path)
+	if _, err := os.Stat(base); err != nil {
+		p.set.path(path, 0, 0, false)
+		p.await(path)
+		return
+	}
+	files, folders := p.files, p.folders
+	p.scan(base)
+	p.set.path(path, p.files-files, p.folders-folders, true)
+}
+
+// Summary of the watch set, the paths are listed when more than one and the empty ones are warned
+func (p *Project) summary() {
+	set := p.set.snapshot()
+	if len(set.Paths) > 1 {
+		for _, v := range set.Paths {
+			msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Blue.Regular(v.Path), Magenta.Bold(v.Files), "file/s", Magenta.Bold(v.Folders), "folder/s")
+			out := BufferOut{Time: time.Now(), Text: v.Path + " " + strconv.FormatInt(v.Files, 10) + " file/s " + strconv.FormatInt(v.Folders, 10) + " folder/s"}
+			p.stamp("log", out, msg, "")
+		}
+	}
+	if len(set.Ignored) > 0 {
+		var rules []string
+		for _, v := range set.Ignored {
+			rules = append(rules, v.Rule+" "+strconv.Itoa(v.Matches))
+		}
+		msg := fmt.Sprintln(p.pname(p.Name, 1), ":", Blue.Regular("Ignored"), strings.Join(rules, ", "))
+		out := BufferOut{Time: time.Now(), Text: "Ignored " + strings.Join(rules, ", ")}
+		p.stamp("log", out, msg, "")
+	}
+	if len(set.Errors) > 0 {
+		var lines []string
+		for _, v := range set.Errors {
+			lines = append(lines, v.Path+": "+v.Error)
+		}
+		text := fmt.Sprint(len(set.Errors), " paths not indexed")
+		msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Red.Bold(text)) + strings.Join(lines, "\n") + "\n"
+		out := BufferOut{Time: time.Now(), Text: text, Stream: strings.Join(lines, "\n")}
+		p.stamp("error", out, msg, "")
+	}
+	var warnings []string
+	for _, v := range set.Missing {
+		text := "Watched path " + v + " doesn't exist"
+		if p.watcher != nil {
+			text += ", it's watched once created"
+		}
+		warnings = append(warnings, text)
+	}
+	for _, v := range set.Paths {
+		if v.Files == 0 {
+			warnings = append(warnings, "No files watched in "+v.Path+", check the extensions "+strings.Join(p.Watcher.Exts, ",")+" and the ignored paths")
+		}
+	}
+	for _, text := range warnings {
+		msg := fmt.Sprintln(p.pname(p.Name, 2), ":", Yellow.Regular(text))
+		out := BufferOut{Time: time.Now(), Text: text}
+		p.stamp("warn", out, msg, "")
+	}
+}
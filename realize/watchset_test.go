@@ -0,0 +1,50 @@
+package realize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProject_IndexPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, file := range []string{"api/main.go", "api/v1/user.go", "api/vendor/a/a.go", "api/gen/gen.go", "web/node_modules/x/index.js", "web/app.js"} {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0755)
+		ioutil.WriteFile(filepath.Join(dir, file), []byte("x"), 0644)
+	}
+	r := Realize{}
+	r.Projects = append(r.Projects, Project{parent: &r, Path: dir, set: &recorder{}})
+	p := &r.Projects[0]
+	p.Watcher = Watch{Exts: []string{"go"}, Ignore: []string{"api/gen"}}
+	for _, path := range []string{"api", "web", "cmd"} {
+		p.indexPath(path)
+	}
+	set := p.set.snapshot()
+	if len(set.Paths) != 2 || set.Paths[0] != (WatchedPath{Path: "api", Files: 2, Folders: 2}) || set.Paths[1] != (WatchedPath{Path: "web", Files: 0, Folders: 1}) {
+		t.Error("Unexpected paths", set.Paths)
+	}
+	if len(set.Ignored) != 3 || set.Ignored[0].Rule != "api/gen" || set.Ignored[1].Rule != "node_modules" || set.Ignored[2].Rule != "vendor" {
+		t.Error("Unexpected ignore rules", set.Ignored)
+	}
+	if strings.Join(set.Missing, ",") != "cmd" {
+		t.Error("Unexpected missing paths", set.Missing)
+	}
+	p.summary()
+	var warnings []string
+	for _, v := range p.Buffer.StdErr {
+		warnings = append(warnings, v.Text)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "cmd doesn't exist") || !strings.Contains(warnings[1], "No files watched in web") {
+		t.Error("Unexpected warnings", warnings)
+	}
+	p.set.drop("cmd")
+	if set := p.set.snapshot(); len(set.Missing) != 0 {
+		t.Error("Unexpected missing paths", set.Missing)
+	}
+}
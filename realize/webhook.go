@@ -0,0 +1,186 @@
+package realize
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// Messages queued by a webhook sink and posted at most in a batch, the ones beyond the queue are dropped
+const (
+	webhookSize  = 256
+	webhookBatch = 32
+)
+
+// Webhook posts the notifications to an url, slack incoming webhooks included
+type Webhook struct {
+	URL      string   `yaml:"url" json:"url"`
+	Events   []string `yaml:"events,omitempty" json:"events,omitempty"`
+	Template string   `yaml:"template,omitempty" json:"template,omitempty"`
+	worker   *webhookWorker
+}
+
+// Worker of a webhook sink, it posts the queued messages from a single goroutine
+type webhookWorker struct {
+	ch      chan Message
+	done    chan bool
+	dropped int64
+}
+
+// Workers are started by the first message of a sink
+var webhooksMu sync.Mutex
+
+// Webhook functions available in the payload template
+var webhookFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// Notify posts a notification if its status is one of the webhook events
+func (w *Webhook) Notify(n Notification) error {
+	if !w.event(n.Status) {
+		return nil
+	}
+	return w.post(n)
+}
+
+// Write queues a message if its type is one of the webhook events, used as a sink
+func (w *Webhook) Write(m Message) error {
+	if !w.event(m.Type) {
+		return nil
+	}
+	webhooksMu.Lock()
+	defer webhooksMu.Unlock()
+	if w.worker == nil {
+		w.worker = &webhookWorker{ch: make(chan Message, webhookSize), done: make(chan bool)}
+		go w.run(w.worker)
+	}
+	select {
+	case w.worker.ch <- m:
+	default:
+		atomic.AddInt64(&w.worker.dropped, 1)
+	}
+	return nil
+}
+
+// Run posts the queued messages, a json array of the batch or a request for each one with a template
+func (w *Webhook) run(worker *webhookWorker) {
+	defer close(worker.done)
+	for m := range worker.ch {
+		batch := []Message{m}
+	L:
+		for len(batch) < webhookBatch {
+			select {
+			case m, ok := <-worker.ch:
+				if !ok {
+					break L
+				}
+				batch = append(batch, m)
+			default:
+				break L
+			}
+		}
+		if n := atomic.SwapInt64(&worker.dropped, 0); n > 0 {
+			log.Println(Red.Regular(fmt.Sprintf("webhook %s dropped %d message/s", w.URL, n)))
+		}
+		if w.Template == "" {
+			if err := w.post(batch); err != nil {
+				log.Println(Red.Regular(err.Error()))
+			}
+			continue
+		}
+		for _, m := range batch {
+			if err := w.post(m); err != nil {
+				log.Println(Red.Regular(err.Error()))
+			}
+		}
+	}
+}
+
+// Close the sink once the queued messages are posted, a slow url is waited 10s at most
+func (w *Webhook) close() {
+	webhooksMu.Lock()
+	worker := w.worker
+	w.worker = nil
+	if worker != nil {
+		close(worker.ch)
+	}
+	webhooksMu.Unlock()
+	if worker == nil {
+		return
+	}
+	select {
+	case <-worker.done:
+	case <-time.After(10 * time.Second):
+	}
+}
+
+// Event checks if an event is enabled, all are by default
+func (w *Webhook) event(name string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate the url and the template of a webhook
+func (w *Webhook) validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || u.Host == "" || u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("webhook %q isn't a valid url", w.URL)
+	}
+	if w.Template != "" {
+		if _, err := template.New("webhook").Funcs(webhookFuncs).Parse(w.Template); err != nil {
+			return fmt.Errorf("webhook %s: %s", w.URL, err)
+		}
+	}
+	return nil
+}
+
+// Post the payload of a value
+func (w *Webhook) post(v interface{}) error {
+	payload, err := w.payload(v)
+	if err != nil {
+		return err
+	}
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(w.URL, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded %s", w.URL, resp.Status)
+	}
+	return nil
+}
+
+// Payload of a notification or a message, json encoded by default
+func (w *Webhook) payload(v interface{}) ([]byte, error) {
+	if w.Template == "" {
+		return json.Marshal(v)
+	}
+	t, err := template.New("webhook").Funcs(webhookFuncs).Parse(w.Template)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,82 @@
+package realize
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestWebhook_Notify(t *testing.T) {
+	var body []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		body = append(body, string(b))
+	}))
+	defer ts.Close()
+	w := Webhook{URL: ts.URL, Events: []string{StatusFailure}, Template: `{"text": {{json .Text}}}`}
+	if err := w.Notify(Notification{Status: StatusSuccess, Text: "ok"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Notify(Notification{Status: StatusFailure, Text: `build "failed"`}); err != nil {
+		t.Fatal(err)
+	}
+	if len(body) != 1 {
+		t.Fatal("Expected one request instead", len(body))
+	}
+	if body[0] != `{"text": "build \"failed\""}` {
+		t.Error("Unexpected payload", body[0])
+	}
+}
+
+func TestWebhook_Error(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	w := Webhook{URL: ts.URL}
+	if err := w.Notify(Notification{Status: StatusFailure}); err == nil {
+		t.Error("Expected error")
+	}
+}
+
+func TestWebhook_Write(t *testing.T) {
+	var mu sync.Mutex
+	var body []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		body = append(body, string(b))
+		mu.Unlock()
+	}))
+	defer ts.Close()
+	// the messages are posted in batches of json arrays
+	w := Webhook{URL: ts.URL, Events: []string{"error"}}
+	for _, v := range []string{"error", "log", "error", "error"} {
+		w.Write(Message{Type: v, Text: v})
+	}
+	w.close()
+	var count int
+	for _, b := range body {
+		var list []map[string]interface{}
+		if err := json.Unmarshal([]byte(b), &list); err != nil {
+			t.Error("Unexpected payload", b)
+		}
+		count += len(list)
+	}
+	if count != 3 {
+		t.Error("Unexpected messages", body)
+	}
+	// a request for each message with a template
+	body = nil
+	w = Webhook{URL: ts.URL, Template: `{"text": {{json .Type}}}`}
+	for i := 0; i < 3; i++ {
+		w.Write(Message{Type: "log"})
+	}
+	w.close()
+	if len(body) != 3 || body[0] != `{"text": "log"}` {
+		t.Error("Unexpected requests", body)
+	}
+}
@@ -0,0 +1,90 @@
+package realize
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// FileWork is the file of a go workspace
+const FileWork = "go.work"
+
+// Modules of a go workspace, the dirs of its use directives relative to it
+func modules(file string) (dirs []string, err error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	block := false
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case block && fields[0] == ")":
+			block = false
+			continue
+		case block:
+		case fields[0] != "use":
+			continue
+		case len(fields) > 1 && fields[1] == "(":
+			block = true
+			continue
+		default:
+			fields = fields[1:]
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		dir := strings.Join(fields, " ")
+		if unquoted, err := strconv.Unquote(dir); err == nil {
+			dir = unquoted
+		}
+		dirs = append(dirs, filepath.Clean(filepath.FromSlash(dir)))
+	}
+	return dirs, scanner.Err()
+}
+
+// Workspace creates a project for each module of the go.work in the path, none without it.
+// The modules nested in another one are ignored by it.
+func (s *Schema) Workspace(c *cli.Context) (projects []Project) {
+	base := c.String("path")
+	dirs, err := modules(filepath.Join(base, FileWork))
+	if err != nil {
+		return nil
+	}
+	names := map[string]bool{}
+	for _, dir := range dirs {
+		p := s.New(c)
+		p.Path = filepath.Join(base, dir)
+		if filepath.IsAbs(dir) {
+			p.Path = dir
+		}
+		p.Name = filepath.Base(p.Path)
+		if p.Name == "." {
+			abs, _ := filepath.Abs(p.Path)
+			p.Name = filepath.Base(abs)
+		}
+		if names[p.Name] {
+			p.Name = filepath.ToSlash(dir)
+		}
+		names[p.Name] = true
+		for _, other := range dirs {
+			if rel, err := filepath.Rel(dir, other); other != dir && err == nil && within(other, dir) {
+				p.Watcher.Ignore = append(p.Watcher.Ignore, rel)
+			}
+		}
+		projects = append(projects, p)
+	}
+	return
+}
@@ -0,0 +1,55 @@
+package realize
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestSchema_Workspace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "realize")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, FileWork), []byte(`go 1.18
+
+use .
+use (
+	./api // the api
+	"./tools/api"
+	./worker
+)
+`), 0644)
+	r := Realize{}
+	set := flag.NewFlagSet("test", 0)
+	set.String("path", "", "")
+	set.Bool("test", false, "")
+	c := cli.NewContext(nil, set, nil)
+	set.Parse([]string{"--path", dir, "--test"})
+	projects := r.Workspace(c)
+	if len(projects) != 4 {
+		t.Fatal("Unexpected projects", projects)
+	}
+	names := []string{filepath.Base(dir), "api", "tools/api", "worker"}
+	for i, p := range projects {
+		if p.Name != names[i] || !p.Tools.Test.Status {
+			t.Error("Unexpected project", p.Name, "instead", names[i])
+		}
+	}
+	if projects[1].Path != filepath.Join(dir, "api") {
+		t.Error("Unexpected path", projects[1].Path)
+	}
+	if ignore := projects[0].Watcher.Ignore; !reflect.DeepEqual(ignore[len(ignore)-3:], []string{"api", filepath.Join("tools", "api"), "worker"}) {
+		t.Error("Unexpected ignored modules", ignore)
+	}
+	set.Parse([]string{"--path", filepath.Join(dir, "api")})
+	if projects := r.Workspace(c); projects != nil {
+		t.Error("Unexpected projects", projects)
+	}
+}